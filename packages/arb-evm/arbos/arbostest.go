@@ -0,0 +1,93 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package arbos
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+// ArbosTest isn't part of the generated bindings since it's only meant for
+// test chains. ArbOS only accepts calls to it from the zero address.
+const arbosTestABIJSON = `[
+	{"type":"function","name":"installAccount","stateMutability":"nonpayable","outputs":[],"inputs":[
+		{"name":"addr","type":"address"},
+		{"name":"isEOA","type":"bool"},
+		{"name":"balance","type":"uint256"},
+		{"name":"nonce","type":"uint256"},
+		{"name":"code","type":"bytes"},
+		{"name":"initStorage","type":"bytes"}
+	]},
+	{"type":"function","name":"getMarshalledStorage","stateMutability":"view","outputs":[],"inputs":[
+		{"name":"addr","type":"address"}
+	]}
+]`
+
+var (
+	installAccountABI       abi.Method
+	getMarshalledStorageABI abi.Method
+)
+
+func init() {
+	arbosTest, err := abi.JSON(strings.NewReader(arbosTestABIJSON))
+	if err != nil {
+		panic(err)
+	}
+
+	installAccountABI = arbosTest.Methods["installAccount"]
+	getMarshalledStorageABI = arbosTest.Methods["getMarshalledStorage"]
+}
+
+// InstallAccountData replaces the account at addr, including all of its
+// storage, with the given state
+func InstallAccountData(addr common.Address, isEOA bool, balance *big.Int, nonce *big.Int, code []byte, storage map[ethcommon.Hash]ethcommon.Hash) []byte {
+	return makeFuncData(installAccountABI, addr.ToEthAddress(), isEOA, balance, nonce, code, MarshalStorage(storage))
+}
+
+func GetMarshalledStorageData(addr common.Address) []byte {
+	return makeFuncData(getMarshalledStorageABI, addr.ToEthAddress())
+}
+
+// MarshalStorage encodes storage the way ArbosTest accepts and returns it, as
+// a sequence of 32 byte keys each followed by its 32 byte value
+func MarshalStorage(storage map[ethcommon.Hash]ethcommon.Hash) []byte {
+	data := make([]byte, 0, len(storage)*64)
+	for key, val := range storage {
+		data = append(data, key.Bytes()...)
+		data = append(data, val.Bytes()...)
+	}
+	return data
+}
+
+// ParseMarshalledStorageResult decodes the raw storage returned by
+// getMarshalledStorage
+func ParseMarshalledStorageResult(data []byte) (map[ethcommon.Hash]ethcommon.Hash, error) {
+	if len(data)%64 != 0 {
+		return nil, errors.Errorf("marshalled storage has invalid length %v", len(data))
+	}
+	storage := make(map[ethcommon.Hash]ethcommon.Hash, len(data)/64)
+	for i := 0; i < len(data); i += 64 {
+		storage[ethcommon.BytesToHash(data[i:i+32])] = ethcommon.BytesToHash(data[i+32 : i+64])
+	}
+	return storage, nil
+}
@@ -0,0 +1,95 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package evm
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/value"
+)
+
+// CallFrame is a single call inside of a transaction's call tree
+type CallFrame struct {
+	Call *CallTrace
+
+	// Create is either a *CreateTrace or a *Create2Trace if this frame is
+	// running the constructor of a new contract and nil otherwise
+	Create TraceItem
+
+	// Return is nil if the call never returned
+	Return *ReturnTrace
+
+	Calls []*CallFrame
+}
+
+// NewCallTree nests the flat list of trace items emitted by ArbOS for a
+// single transaction into the tree of calls that it made
+func NewCallTree(items []TraceItem) (*CallFrame, error) {
+	var root *CallFrame
+	var stack []*CallFrame
+	var pendingCreate TraceItem
+	for _, item := range items {
+		switch item := item.(type) {
+		case *CreateTrace, *Create2Trace:
+			if pendingCreate != nil {
+				return nil, errors.New("create must be followed by call")
+			}
+			pendingCreate = item
+		case *CallTrace:
+			frame := &CallFrame{Call: item, Create: pendingCreate}
+			pendingCreate = nil
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errors.New("trace contains multiple top level calls")
+				}
+				root = frame
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Calls = append(parent.Calls, frame)
+			}
+			stack = append(stack, frame)
+		case *ReturnTrace:
+			if len(stack) == 0 {
+				return nil, errors.New("can only return from inside call")
+			}
+			stack[len(stack)-1].Return = item
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if pendingCreate != nil {
+		return nil, errors.New("create must be followed by call")
+	}
+	if root == nil {
+		return nil, errors.New("trace contained no calls")
+	}
+	return root, nil
+}
+
+// GetTrace returns the trace items of the first EVM trace found in the
+// given debug prints, or nil if none was emitted
+func GetTrace(debugPrints []value.Value) ([]TraceItem, error) {
+	for _, debugPrint := range debugPrints {
+		line, err := NewLogLineFromValue(debugPrint)
+		if err != nil {
+			return nil, err
+		}
+		if trace, ok := line.(*EVMTrace); ok {
+			return trace.Items, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+func newTestCall(from common.Address, to common.Address) *CallTrace {
+	return &CallTrace{
+		Type:     Call,
+		Value:    big.NewInt(0),
+		From:     from,
+		To:       &to,
+		Gas:      big.NewInt(100000),
+		GasPrice: big.NewInt(0),
+	}
+}
+
+func newTestReturn(result ResultType) *ReturnTrace {
+	return &ReturnTrace{
+		Result:  result,
+		GasUsed: big.NewInt(21000),
+	}
+}
+
+func TestCallTree(t *testing.T) {
+	sender := common.RandAddress()
+	contract := common.RandAddress()
+	created := common.RandAddress()
+	other := common.RandAddress()
+
+	items := []TraceItem{
+		newTestCall(sender, contract),
+		&CreateTrace{Code: []byte{1, 2, 3}, ContractAddress: created},
+		newTestCall(contract, created),
+		newTestReturn(ReturnCode),
+		newTestCall(contract, other),
+		newTestReturn(RevertCode),
+		newTestReturn(ReturnCode),
+	}
+
+	root, err := NewCallTree(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *root.Call.To != contract {
+		t.Error("wrong root call")
+	}
+	if root.Return == nil || root.Return.Result != ReturnCode {
+		t.Error("root call should have returned successfully")
+	}
+	if len(root.Calls) != 2 {
+		t.Fatal("expected 2 subcalls but got", len(root.Calls))
+	}
+	if _, ok := root.Calls[0].Create.(*CreateTrace); !ok {
+		t.Error("first subcall should be contract creation")
+	}
+	if root.Calls[1].Create != nil {
+		t.Error("second subcall shouldn't be contract creation")
+	}
+	if root.Calls[1].Return.Result != RevertCode {
+		t.Error("second subcall should have reverted")
+	}
+}
+
+func TestCallTreeInvalid(t *testing.T) {
+	sender := common.RandAddress()
+	contract := common.RandAddress()
+
+	invalidTraces := [][]TraceItem{
+		{},
+		{newTestReturn(ReturnCode)},
+		{newTestCall(sender, contract), newTestReturn(ReturnCode), newTestReturn(ReturnCode)},
+		{newTestCall(sender, contract), newTestReturn(ReturnCode), newTestCall(sender, contract)},
+		{newTestCall(sender, contract), &CreateTrace{ContractAddress: contract}},
+	}
+	for i, items := range invalidTraces {
+		if _, err := NewCallTree(items); err == nil {
+			t.Error("expected error building call tree", i)
+		}
+	}
+}
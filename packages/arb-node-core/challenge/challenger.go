@@ -3,7 +3,9 @@ package challenge
 import (
 	"context"
 	"math/big"
+	"time"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
@@ -20,23 +22,31 @@ type Challenger struct {
 	lookup         core.ArbCoreLookup
 	challengedNode *core.NodeInfo
 	stakerAddress  common.Address
+	journal        *Journal
+
+	// Move added to the builder by the last call to HandleConflict
+	pendingMove *Move
 }
 
 func (c *Challenger) ChallengeAddress() common.Address {
 	return c.challenge.Address()
 }
 
-func NewChallenger(challenge *ethbridge.Challenge, sequencerInbox *ethbridge.SequencerInboxWatcher, lookup core.ArbCoreLookup, challengedNode *core.NodeInfo, stakerAddress common.Address) *Challenger {
+// NewChallenger creates a challenger which records its moves in the given
+// journal, which may be nil
+func NewChallenger(challenge *ethbridge.Challenge, sequencerInbox *ethbridge.SequencerInboxWatcher, lookup core.ArbCoreLookup, challengedNode *core.NodeInfo, stakerAddress common.Address, journal *Journal) *Challenger {
 	return &Challenger{
 		challenge:      challenge,
 		sequencerInbox: sequencerInbox,
 		lookup:         lookup,
 		challengedNode: challengedNode,
 		stakerAddress:  stakerAddress,
+		journal:        journal,
 	}
 }
 
 func (c *Challenger) HandleConflict(ctx context.Context) error {
+	c.pendingMove = nil
 	responder, err := c.challenge.CurrentResponder(ctx)
 	if err != nil {
 		return err
@@ -59,27 +69,77 @@ func (c *Challenger) HandleConflict(ctx context.Context) error {
 	if prevBisection == nil {
 		prevBisection = c.challengedNode.InitialExecutionBisection()
 	}
+
+	if c.journal != nil {
+		if err := c.journal.StartChallenge(c.ChallengeAddress(), c.challengedNode); err != nil {
+			return err
+		}
+		move, err := c.journal.Move(c.ChallengeAddress(), challengeState.ToEthHash())
+		if err != nil {
+			return err
+		}
+		if move != nil {
+			logger.Info().
+				Str("kind", string(move.Kind)).
+				Int("sent", len(move.Transactions)).
+				Msg("Resuming challenge move from journal")
+			return c.sendMove(ctx, prevBisection, move)
+		}
+	}
+
 	challengeImpl := ExecutionImpl{}
-	return handleChallenge(ctx, c.challenge, c.sequencerInbox, c.challengedNode.Assertion, c.lookup, challengeImpl, prevBisection)
+	move, err := computeMove(ctx, c.sequencerInbox, c.challengedNode.Assertion, c.lookup, challengeImpl, prevBisection)
+	if err != nil {
+		return err
+	}
+	move.ChallengeState = challengeState.ToEthHash()
+	move.Time = time.Now()
+	if c.journal != nil {
+		if err := c.journal.AddMove(c.ChallengeAddress(), move); err != nil {
+			return err
+		}
+	}
+	return c.sendMove(ctx, prevBisection, move)
+}
+
+func (c *Challenger) sendMove(ctx context.Context, prevBisection *core.Bisection, move *Move) error {
+	if err := submitMove(ctx, c.challenge, prevBisection, move); err != nil {
+		return err
+	}
+	c.pendingMove = move
+	return nil
+}
+
+// MoveSent records that the move from the last call to HandleConflict was
+// included in the given transaction
+func (c *Challenger) MoveSent(tx ethcommon.Hash) error {
+	if c.pendingMove == nil {
+		return nil
+	}
+	move := c.pendingMove
+	c.pendingMove = nil
+	if c.journal == nil {
+		return nil
+	}
+	return c.journal.MoveSent(c.ChallengeAddress(), move.ChallengeState, tx)
 }
 
-func handleChallenge(
+func computeMove(
 	ctx context.Context,
-	challenge *ethbridge.Challenge,
 	sequencerInbox *ethbridge.SequencerInboxWatcher,
 	assertion *core.Assertion,
 	lookup core.ArbCoreLookup,
 	challengeImpl ExecutionImpl,
 	prevBisection *core.Bisection,
-) error {
+) (*Move, error) {
 	logger.Debug().Str("start", prevBisection.ChallengedSegment.Start.String()).Str("end", prevBisection.ChallengedSegment.GetEnd().String()).Msg("Examining opponent's bisection")
 	prevCutOffsets := generateBisectionCutOffsets(prevBisection.ChallengedSegment, len(prevBisection.Cuts)-1)
 	divergence, err := challengeImpl.FindFirstDivergence(lookup, assertion, prevCutOffsets, prevBisection.Cuts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if divergence.DifferentIndex == 0 {
-		return errors.New("first cut was already wrong")
+		return nil, errors.New("first cut was already wrong")
 	}
 	cutToChallenge := divergence.DifferentIndex - 1
 	inconsistentSegment := &core.ChallengeSegment{
@@ -98,27 +158,21 @@ func handleChallenge(
 		subCutOffsets := generateBisectionCutOffsets(inconsistentSegment, segmentCount)
 		subCuts, err := challengeImpl.GetCuts(lookup, assertion, subCutOffsets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		return challengeImpl.Bisect(
-			ctx,
-			challenge,
-			prevBisection,
 			cutToChallenge,
 			inconsistentSegment,
 			subCuts,
-		)
+		), nil
 	} else if cmp < 0 {
 		// Steps == 0: Prove that the previous instruction's execution continued through this gas window
 		// Also sometimes called a zero step proof, or a constraint win
 		// We specifically don't do this when we think the endpoint is unreachable,
 		// as we need to dissect unreachable endpoints to force our opponent to fail to prove them
 		return challengeImpl.ProveContinuedExecution(
-			ctx,
-			challenge,
 			lookup,
 			assertion,
-			prevBisection,
 			cutToChallenge,
 			inconsistentSegment,
 		)
@@ -126,17 +180,50 @@ func handleChallenge(
 		// Steps == 1: Do a one step proof, proving the execution of this step specifically
 		return challengeImpl.OneStepProof(
 			ctx,
-			challenge,
 			sequencerInbox,
 			lookup,
 			assertion,
-			prevBisection,
 			cutToChallenge,
 			inconsistentSegment,
 		)
 	}
 }
 
+// submitMove adds the move to the challenge's builder
+func submitMove(ctx context.Context, challenge *ethbridge.Challenge, prevBisection *core.Bisection, move *Move) error {
+	switch move.Kind {
+	case BisectMove:
+		return challenge.BisectExecution(
+			ctx,
+			prevBisection,
+			move.SegmentIndex,
+			move.segment(),
+			move.cuts(),
+		)
+	case ContinuedExecutionMove:
+		return challenge.ProveContinuedExecution(
+			ctx,
+			prevBisection,
+			move.SegmentIndex,
+			move.segment(),
+			move.StartState.ExecutionState(),
+		)
+	case OneStepProofMove:
+		return challenge.OneStepProveExecution(
+			ctx,
+			prevBisection,
+			move.SegmentIndex,
+			move.segment(),
+			move.StartState.ExecutionState(),
+			move.ExecutionProof,
+			move.BufferProof,
+			move.Opcode,
+		)
+	default:
+		return errors.Errorf("unknown challenge move %v", move.Kind)
+	}
+}
+
 func generateBisectionCutOffsets(segment *core.ChallengeSegment, subSegmentCount int) []*big.Int {
 	cutCount := subSegmentCount + 1
 	offset := new(big.Int).Set(segment.Start)
@@ -153,6 +240,9 @@ func generateBisectionCutOffsets(segment *core.ChallengeSegment, subSegmentCount
 }
 
 func LookupBatchContaining(ctx context.Context, lookup core.ArbCoreLookup, sequencerInbox *ethbridge.SequencerInboxWatcher, seqNum *big.Int) (ethbridge.SequencerBatchRef, error) {
+	if sequencerInbox == nil {
+		return nil, errors.New("sequencer inbox unavailable for batch lookup")
+	}
 	fromBlock, err := lookup.GetSequencerBlockNumberAt(seqNum)
 	if err != nil {
 		return nil, err
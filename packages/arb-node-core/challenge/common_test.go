@@ -54,8 +54,9 @@ func executeChallenge(
 	seqInbox, err := ethbridge.NewSequencerInboxWatcher(seqInboxAddr, client)
 	test.FailIfError(t, err)
 
-	challenger := NewChallenger(challengerChallengeCon, seqInbox, correctLookup, challengedNode, challengerWallet.Address())
-	asserter := NewChallenger(asserterChallengeCon, seqInbox, falseLookup, challengedNode, asserterWallet.Address())
+	challengerJournal := NewMemoryJournal()
+	challenger := NewChallenger(challengerChallengeCon, seqInbox, correctLookup, challengedNode, challengerWallet.Address(), challengerJournal)
+	asserter := NewChallenger(asserterChallengeCon, seqInbox, falseLookup, challengedNode, asserterWallet.Address(), nil)
 
 	turn := ethbridge.CHALLENGER_TURN
 	rounds := 0
@@ -66,11 +67,22 @@ func executeChallenge(
 			err := challenger.HandleConflict(ctx)
 			test.FailIfError(t, err)
 
+			mover := challenger
+			if rounds == 0 {
+				// Drop the first move as if the validator restarted before
+				// sending it. A fresh challenger has to resume it from the
+				// journal, since without a lookup it can't recompute it.
+				challengerBackend.ClearTransactions()
+				mover = NewChallenger(challengerChallengeCon, seqInbox, nil, challengedNode, challengerWallet.Address(), challengerJournal)
+				test.FailIfError(t, mover.HandleConflict(ctx))
+			}
+
 			if challengerBackend.TransactionCount() == 0 {
 				t.Fatal("should be able to transact")
 			}
 			tx, err := challengerWallet.ExecuteTransactions(ctx, challengerBackend)
 			test.FailIfError(t, err)
+			test.FailIfError(t, mover.MoveSent(tx.Hash()))
 			client.Commit()
 			receipt, err := client.TransactionReceipt(ctx, tx.Hash())
 			test.FailIfError(t, err)
@@ -106,9 +118,58 @@ func executeChallenge(
 	}
 
 	checkChallengeCompleted(t, tester, challengerWallet.Address().ToEthAddress(), asserterWallet.Address().ToEthAddress())
+	checkJournal(t, challengerJournal, common.NewAddressFromEth(challengeAddress), (rounds+1)/2)
+	checkReplay(t, correctLookup, challengedNode, challenge, seqInbox, client, rounds)
 	return rounds
 }
 
+func checkReplay(
+	t *testing.T,
+	lookup core.ArbCoreLookup,
+	challengedNode *core.NodeInfo,
+	challenge *ethbridge.ChallengeWatcher,
+	seqInbox *ethbridge.SequencerInboxWatcher,
+	client *ethutils.SimulatedEthClient,
+	rounds int,
+) {
+	t.Helper()
+	report, err := replayChallenge(context.Background(), lookup, challengedNode, challenge, seqInbox, client)
+	test.FailIfError(t, err)
+	t.Log(report.String())
+	if report.HonestParty != ChallengerParty || report.Winner != ChallengerParty {
+		t.Error("replay found", report.HonestParty, "honest and", report.Winner, "winning")
+	}
+	// Every round but the last is a bisection, plus the original assertion
+	if len(report.Steps) != rounds {
+		t.Fatal("replay found", len(report.Steps), "steps instead of", rounds)
+	}
+	for i, step := range report.Steps {
+		if step.Honest != (step.Party == ChallengerParty) {
+			t.Error("step", i, "by", step.Party, "honest:", step.Honest)
+		}
+	}
+	if report.Proof == nil || report.Proof.Correct == nil || !*report.Proof.Correct {
+		t.Error("final proof not verified as correct")
+	}
+}
+
+func checkJournal(t *testing.T, journal *Journal, challengeAddress common.Address, moveCount int) {
+	t.Helper()
+	record, err := journal.Record(challengeAddress)
+	test.FailIfError(t, err)
+	if record == nil {
+		t.Fatal("challenge wasn't journaled")
+	}
+	if len(record.Moves) != moveCount {
+		t.Fatal("journaled", len(record.Moves), "moves instead of", moveCount)
+	}
+	for _, move := range record.Moves {
+		if len(move.Transactions) != 1 {
+			t.Error("move", move.Kind, "journaled with", len(move.Transactions), "transactions")
+		}
+	}
+}
+
 func checkTurn(t *testing.T, challenge *ethbridge.ChallengeWatcher, turn ethbridge.ChallengeTurn) {
 	t.Helper()
 	ctx := context.Background()
@@ -189,10 +250,10 @@ func initializeChallengeTest(
 ) (*ethutils.SimulatedEthClient, *ethbridgetestcontracts.ChallengeTester, ethcommon.Address, *ethbridge.ValidatorWallet, *ethbridge.ValidatorWallet, func(nd *core.NodeInfo)) {
 	ctx := context.Background()
 	clnt, pks := test.SimulatedBackend(t)
-	deployer := bind.NewKeyedTransactor(pks[0])
-	asserter := bind.NewKeyedTransactor(pks[1])
-	challenger := bind.NewKeyedTransactor(pks[2])
-	sequencer := bind.NewKeyedTransactor(pks[3])
+	deployer := test.SimulatedTransactor(t, pks[0])
+	asserter := test.SimulatedTransactor(t, pks[1])
+	challenger := test.SimulatedTransactor(t, pks[2])
+	sequencer := test.SimulatedTransactor(t, pks[3])
 	client := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
 	osp1Addr, _, _, err := ethbridgetestcontracts.DeployOneStepProof(deployer, client)
 	test.FailIfError(t, err)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"math/big"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
@@ -85,21 +86,24 @@ func (e *ExecutionImpl) FindFirstDivergence(lookup core.ArbCoreLookup, assertion
 }
 
 func (e *ExecutionImpl) Bisect(
-	ctx context.Context,
-	challenge *ethbridge.Challenge,
-	prevBisection *core.Bisection,
 	segmentToChallenge int,
 	inconsistentSegment *core.ChallengeSegment,
 	subCuts []core.Cut,
-) error {
+) *Move {
 	logger.Info().Str("start", inconsistentSegment.Start.String()).Str("end", inconsistentSegment.GetEnd().String()).Msg("Bisecting challenge")
-	return challenge.BisectExecution(
-		ctx,
-		prevBisection,
-		segmentToChallenge,
-		inconsistentSegment,
-		subCuts,
-	)
+	cutHashes := make([]ethcommon.Hash, 0, len(subCuts))
+	for _, cut := range subCuts {
+		cutHashes = append(cutHashes, cut.CutHash())
+	}
+	return &Move{
+		Kind:          BisectMove,
+		SegmentIndex:  segmentToChallenge,
+		SegmentStart:  inconsistentSegment.Start,
+		SegmentLength: inconsistentSegment.Length,
+		// GetCuts guarantees the first cut is an execution state
+		StartState: newJournalState(subCuts[0].(*core.ExecutionState)),
+		CutHashes:  cutHashes,
+	}
 }
 
 func (e *ExecutionImpl) getSegmentStartInfo(lookup core.ArbCoreLookup, assertion *core.Assertion, segment *core.ChallengeSegment) (*core.ExecutionState, machine.Machine, error) {
@@ -123,22 +127,20 @@ func (e *ExecutionImpl) getSegmentStartInfo(lookup core.ArbCoreLookup, assertion
 
 func (e *ExecutionImpl) OneStepProof(
 	ctx context.Context,
-	challenge *ethbridge.Challenge,
 	sequencerInbox *ethbridge.SequencerInboxWatcher,
 	lookup core.ArbCoreLookup,
 	assertion *core.Assertion,
-	prevBisection *core.Bisection,
 	segmentToChallenge int,
 	challengedSegment *core.ChallengeSegment,
-) error {
+) (*Move, error) {
 	previousCut, previousMachine, err := e.getSegmentStartInfo(lookup, assertion, challengedSegment)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	proofData, bufferProofData, err := previousMachine.MarshalForProof()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	opcode := proofData[0]
@@ -149,50 +151,47 @@ func (e *ExecutionImpl) OneStepProof(
 		seqNum := previousCut.TotalMessagesRead
 		batch, err := LookupBatchContaining(ctx, lookup, sequencerInbox, seqNum)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if batch == nil {
-			return errors.New("Failed to lookup batch containing message")
+			return nil, errors.New("Failed to lookup batch containing message")
 		}
 		inboxProof, err := lookup.GenInboxProof(seqNum, batch.GetBatchIndex(), batch.GetAfterCount())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		proofData = append(proofData, inboxProof...)
 	}
 
-	return challenge.OneStepProveExecution(
-		ctx,
-		prevBisection,
-		segmentToChallenge,
-		challengedSegment,
-		previousCut,
-		proofData,
-		bufferProofData,
-		opcode,
-	)
+	return &Move{
+		Kind:           OneStepProofMove,
+		SegmentIndex:   segmentToChallenge,
+		SegmentStart:   challengedSegment.Start,
+		SegmentLength:  challengedSegment.Length,
+		StartState:     newJournalState(previousCut),
+		ExecutionProof: proofData,
+		BufferProof:    bufferProofData,
+		Opcode:         opcode,
+	}, nil
 }
 
 func (e *ExecutionImpl) ProveContinuedExecution(
-	ctx context.Context,
-	challenge *ethbridge.Challenge,
 	lookup core.ArbCoreLookup,
 	assertion *core.Assertion,
-	prevBisection *core.Bisection,
 	segmentToChallenge int,
 	challengedSegment *core.ChallengeSegment,
-) error {
+) (*Move, error) {
 	logger.Info().Str("start", challengedSegment.Start.String()).Str("end", challengedSegment.GetEnd().String()).Msg("Proving continued execution")
 	previousCut, _, err := e.getSegmentStartInfo(lookup, assertion, challengedSegment)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return challenge.ProveContinuedExecution(
-		ctx,
-		prevBisection,
-		segmentToChallenge,
-		challengedSegment,
-		previousCut,
-	)
+	return &Move{
+		Kind:          ContinuedExecutionMove,
+		SegmentIndex:  segmentToChallenge,
+		SegmentStart:  challengedSegment.Start,
+		SegmentLength: challengedSegment.Length,
+		StartState:    newJournalState(previousCut),
+	}, nil
 }
@@ -0,0 +1,325 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package challenge
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+)
+
+type MoveKind string
+
+const (
+	BisectMove             MoveKind = "bisect"
+	ContinuedExecutionMove MoveKind = "continuedExecution"
+	OneStepProofMove       MoveKind = "oneStepProof"
+)
+
+// JournalState is a core.ExecutionState in a form that can be stored
+type JournalState struct {
+	MachineHash       ethcommon.Hash `json:"machineHash"`
+	InboxAcc          ethcommon.Hash `json:"inboxAcc"`
+	TotalMessagesRead *big.Int       `json:"totalMessagesRead"`
+	TotalGasConsumed  *big.Int       `json:"totalGasConsumed"`
+	TotalSendCount    *big.Int       `json:"totalSendCount"`
+	TotalLogCount     *big.Int       `json:"totalLogCount"`
+	SendAcc           ethcommon.Hash `json:"sendAcc"`
+	LogAcc            ethcommon.Hash `json:"logAcc"`
+}
+
+func newJournalState(state *core.ExecutionState) *JournalState {
+	return &JournalState{
+		MachineHash:       state.MachineHash.ToEthHash(),
+		InboxAcc:          state.InboxAcc.ToEthHash(),
+		TotalMessagesRead: state.TotalMessagesRead,
+		TotalGasConsumed:  state.TotalGasConsumed,
+		TotalSendCount:    state.TotalSendCount,
+		TotalLogCount:     state.TotalLogCount,
+		SendAcc:           state.SendAcc.ToEthHash(),
+		LogAcc:            state.LogAcc.ToEthHash(),
+	}
+}
+
+func (s *JournalState) ExecutionState() *core.ExecutionState {
+	return &core.ExecutionState{
+		MachineHash:       common.NewHashFromEth(s.MachineHash),
+		InboxAcc:          common.NewHashFromEth(s.InboxAcc),
+		TotalMessagesRead: s.TotalMessagesRead,
+		TotalGasConsumed:  s.TotalGasConsumed,
+		TotalSendCount:    s.TotalSendCount,
+		TotalLogCount:     s.TotalLogCount,
+		SendAcc:           common.NewHashFromEth(s.SendAcc),
+		LogAcc:            common.NewHashFromEth(s.LogAcc),
+	}
+}
+
+// Move is a response to the opponent's last bisection, with everything needed
+// to send it again without re-executing the machine
+type Move struct {
+	Kind MoveKind `json:"kind"`
+	// Challenge state hash of the bisection this move responds to
+	ChallengeState ethcommon.Hash `json:"challengeState"`
+	SegmentIndex   int            `json:"segmentIndex"`
+	SegmentStart   *big.Int       `json:"segmentStart"`
+	SegmentLength  *big.Int       `json:"segmentLength"`
+	// State at the start of the challenged segment, which is also the first
+	// cut of a bisection
+	StartState *JournalState `json:"startState"`
+	// Hashes of all the cuts of a bisection
+	CutHashes []ethcommon.Hash `json:"cutHashes,omitempty"`
+	// One step proof data
+	ExecutionProof hexutil.Bytes `json:"executionProof,omitempty"`
+	BufferProof    hexutil.Bytes `json:"bufferProof,omitempty"`
+	Opcode         uint8         `json:"opcode,omitempty"`
+	// Transactions the move has been sent in, more than one if it had to be
+	// resent
+	Transactions []ethcommon.Hash `json:"transactions,omitempty"`
+	Time         time.Time        `json:"time"`
+}
+
+func (m *Move) segment() *core.ChallengeSegment {
+	return &core.ChallengeSegment{
+		Start:  m.SegmentStart,
+		Length: m.SegmentLength,
+	}
+}
+
+// cuts rebuilds the bisection cuts, only the first of which needs the full
+// state
+func (m *Move) cuts() []core.Cut {
+	cuts := make([]core.Cut, 0, len(m.CutHashes))
+	cuts = append(cuts, m.StartState.ExecutionState())
+	for _, hash := range m.CutHashes[1:] {
+		cuts = append(cuts, core.NewSimpleCut(hash))
+	}
+	return cuts
+}
+
+// Record is the history of a challenge the validator took part in
+type Record struct {
+	Challenge      ethcommon.Address `json:"challenge"`
+	ChallengedNode *big.Int          `json:"challengedNode"`
+	NodeHash       ethcommon.Hash    `json:"nodeHash"`
+	Started        time.Time         `json:"started"`
+	Finished       *time.Time        `json:"finished,omitempty"`
+	Moves          []*Move           `json:"moves"`
+}
+
+func (r *Record) move(challengeState ethcommon.Hash) *Move {
+	for _, move := range r.Moves {
+		if move.ChallengeState == challengeState {
+			return move
+		}
+	}
+	return nil
+}
+
+// Journal persists the progress of challenges so that a restarted validator
+// can resume them without recomputing its moves
+type Journal struct {
+	sync.Mutex
+	db ethdb.KeyValueStore
+}
+
+func OpenJournal(path string) (*Journal, error) {
+	db, err := rawdb.NewLevelDBDatabase(path, 16, 16, "challenge_journal", false)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening challenge journal")
+	}
+	return &Journal{db: db}, nil
+}
+
+// NewMemoryJournal creates a journal which isn't persisted
+func NewMemoryJournal() *Journal {
+	return &Journal{db: memorydb.New()}
+}
+
+func (j *Journal) Close() error {
+	return j.db.Close()
+}
+
+func (j *Journal) get(challenge common.Address) (*Record, error) {
+	has, err := j.db.Has(challenge.Bytes())
+	if err != nil || !has {
+		return nil, errors.WithStack(err)
+	}
+	data, err := j.db.Get(challenge.Bytes())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	record := &Record{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, errors.Wrap(err, "corrupt challenge journal record")
+	}
+	return record, nil
+}
+
+func (j *Journal) put(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(j.db.Put(record.Challenge.Bytes(), data))
+}
+
+// Record returns the history of the given challenge, or nil if it isn't in
+// the journal
+func (j *Journal) Record(challenge common.Address) (*Record, error) {
+	j.Lock()
+	defer j.Unlock()
+	return j.get(challenge)
+}
+
+// Records returns the history of all journaled challenges
+func (j *Journal) Records() ([]*Record, error) {
+	j.Lock()
+	defer j.Unlock()
+	var records []*Record
+	it := j.db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		record := &Record{}
+		if err := json.Unmarshal(it.Value(), record); err != nil {
+			return nil, errors.Wrap(err, "corrupt challenge journal record")
+		}
+		records = append(records, record)
+	}
+	return records, errors.WithStack(it.Error())
+}
+
+// StartChallenge creates a record for the challenge if there isn't one yet
+func (j *Journal) StartChallenge(challenge common.Address, node *core.NodeInfo) error {
+	j.Lock()
+	defer j.Unlock()
+	record, err := j.get(challenge)
+	if err != nil || record != nil {
+		return err
+	}
+	return j.put(&Record{
+		Challenge:      challenge.ToEthAddress(),
+		ChallengedNode: node.NodeNum,
+		NodeHash:       node.NodeHash.ToEthHash(),
+		Started:        time.Now(),
+	})
+}
+
+// FinishChallenge marks the challenge as over
+func (j *Journal) FinishChallenge(challenge common.Address) error {
+	j.Lock()
+	defer j.Unlock()
+	record, err := j.get(challenge)
+	if err != nil || record == nil || record.Finished != nil {
+		return err
+	}
+	now := time.Now()
+	record.Finished = &now
+	return j.put(record)
+}
+
+// FinishStaleChallenges marks every unfinished challenge other than current,
+// which may be nil, as over. A validator is in at most one challenge at a time,
+// so any others ended while it wasn't running.
+func (j *Journal) FinishStaleChallenges(current *common.Address) error {
+	j.Lock()
+	defer j.Unlock()
+	var stale []*Record
+	it := j.db.NewIterator(nil, nil)
+	for it.Next() {
+		record := &Record{}
+		if err := json.Unmarshal(it.Value(), record); err != nil {
+			it.Release()
+			return errors.Wrap(err, "corrupt challenge journal record")
+		}
+		if record.Finished == nil && (current == nil || record.Challenge != current.ToEthAddress()) {
+			stale = append(stale, record)
+		}
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	now := time.Now()
+	for _, record := range stale {
+		record.Finished = &now
+		if err := j.put(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Move returns the journaled response to the given challenge state, or nil if
+// there isn't one
+func (j *Journal) Move(challenge common.Address, challengeState ethcommon.Hash) (*Move, error) {
+	j.Lock()
+	defer j.Unlock()
+	record, err := j.get(challenge)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return record.move(challengeState), nil
+}
+
+// AddMove journals a move, which must be for a challenge that was started
+func (j *Journal) AddMove(challenge common.Address, move *Move) error {
+	j.Lock()
+	defer j.Unlock()
+	record, err := j.get(challenge)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return errors.Errorf("challenge %v isn't in the journal", challenge)
+	}
+	if record.move(move.ChallengeState) != nil {
+		return nil
+	}
+	record.Moves = append(record.Moves, move)
+	return j.put(record)
+}
+
+// MoveSent records a transaction that included the given move
+func (j *Journal) MoveSent(challenge common.Address, challengeState ethcommon.Hash, tx ethcommon.Hash) error {
+	j.Lock()
+	defer j.Unlock()
+	record, err := j.get(challenge)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return errors.Errorf("challenge %v isn't in the journal", challenge)
+	}
+	move := record.move(challengeState)
+	if move == nil {
+		return errors.Errorf("no move for challenge state %v in the journal", challengeState.Hex())
+	}
+	move.Transactions = append(move.Transactions, tx)
+	return j.put(record)
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package challenge
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+)
+
+func TestJournalPersistsMoves(t *testing.T) {
+	dir, err := ioutil.TempDir("", "challenge_journal")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, "journal")
+
+	journal, err := OpenJournal(journalPath)
+	test.FailIfError(t, err)
+
+	challengeAddress := common.RandAddress()
+	node := &core.NodeInfo{NodeNum: big.NewInt(3), NodeHash: common.RandHash()}
+	startState := &core.ExecutionState{
+		MachineHash:       common.RandHash(),
+		InboxAcc:          common.RandHash(),
+		TotalMessagesRead: big.NewInt(10),
+		TotalGasConsumed:  big.NewInt(1000),
+		TotalSendCount:    big.NewInt(2),
+		TotalLogCount:     big.NewInt(4),
+		SendAcc:           common.RandHash(),
+		LogAcc:            common.RandHash(),
+	}
+	otherCut := core.NewSimpleCut(common.RandHash())
+	challengeState := common.RandHash().ToEthHash()
+	tx := common.RandHash().ToEthHash()
+
+	if err := journal.AddMove(challengeAddress, &Move{ChallengeState: challengeState}); err == nil {
+		t.Error("added move to challenge that wasn't started")
+	}
+	test.FailIfError(t, journal.StartChallenge(challengeAddress, node))
+	challengeImpl := ExecutionImpl{}
+	move := challengeImpl.Bisect(2, &core.ChallengeSegment{Start: big.NewInt(1000), Length: big.NewInt(50)}, []core.Cut{startState, otherCut})
+	move.ChallengeState = challengeState
+	test.FailIfError(t, journal.AddMove(challengeAddress, move))
+	test.FailIfError(t, journal.MoveSent(challengeAddress, challengeState, tx))
+	test.FailIfError(t, journal.Close())
+
+	journal, err = OpenJournal(journalPath)
+	test.FailIfError(t, err)
+	defer journal.Close()
+
+	missing, err := journal.Move(challengeAddress, common.RandHash().ToEthHash())
+	test.FailIfError(t, err)
+	if missing != nil {
+		t.Error("found move for unknown challenge state")
+	}
+	loaded, err := journal.Move(challengeAddress, challengeState)
+	test.FailIfError(t, err)
+	if loaded == nil {
+		t.Fatal("move wasn't persisted")
+	}
+	if loaded.Kind != BisectMove || loaded.SegmentIndex != 2 || loaded.segment().GetEnd().Cmp(big.NewInt(1050)) != 0 {
+		t.Error("wrong move loaded", loaded.Kind, loaded.SegmentIndex, loaded.segment())
+	}
+	if len(loaded.Transactions) != 1 || loaded.Transactions[0] != tx {
+		t.Error("wrong move transactions", loaded.Transactions)
+	}
+	cuts := loaded.cuts()
+	if len(cuts) != 2 || cuts[0].CutHash() != startState.CutHash() || cuts[1].CutHash() != otherCut.CutHash() {
+		t.Error("cuts weren't rebuilt correctly")
+	}
+
+	test.FailIfError(t, journal.FinishChallenge(challengeAddress))
+	records, err := journal.Records()
+	test.FailIfError(t, err)
+	if len(records) != 1 {
+		t.Fatal("wrong record count", len(records))
+	}
+	record := records[0]
+	if record.Challenge != challengeAddress.ToEthAddress() || record.ChallengedNode.Cmp(node.NodeNum) != 0 || record.Finished == nil {
+		t.Error("wrong record", record.Challenge, record.ChallengedNode, record.Finished)
+	}
+	if record.NodeHash != ethcommon.Hash(node.NodeHash) {
+		t.Error("wrong node hash", record.NodeHash)
+	}
+}
+
+func TestJournalFinishStaleChallenges(t *testing.T) {
+	journal := NewMemoryJournal()
+	defer journal.Close()
+
+	node := &core.NodeInfo{NodeNum: big.NewInt(3), NodeHash: common.RandHash()}
+	stale := common.RandAddress()
+	current := common.RandAddress()
+	test.FailIfError(t, journal.StartChallenge(stale, node))
+	test.FailIfError(t, journal.StartChallenge(current, node))
+
+	test.FailIfError(t, journal.FinishStaleChallenges(&current))
+	record, err := journal.Record(stale)
+	test.FailIfError(t, err)
+	if record.Finished == nil {
+		t.Error("stale challenge wasn't finished")
+	}
+	record, err = journal.Record(current)
+	test.FailIfError(t, err)
+	if record.Finished != nil {
+		t.Error("current challenge was finished")
+	}
+
+	test.FailIfError(t, journal.FinishStaleChallenges(nil))
+	record, err = journal.Record(current)
+	test.FailIfError(t, err)
+	if record.Finished == nil {
+		t.Error("challenge wasn't finished when no challenge is current")
+	}
+}
@@ -0,0 +1,374 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package challenge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+)
+
+type Party string
+
+const (
+	AsserterParty   Party = "asserter"
+	ChallengerParty Party = "challenger"
+)
+
+func (p Party) opponent() Party {
+	if p == AsserterParty {
+		return ChallengerParty
+	}
+	return AsserterParty
+}
+
+type TransactionFetcher interface {
+	TransactionByHash(ctx context.Context, hash ethcommon.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+// ReplayStep is a bisection made in a challenge, checked against local
+// execution. The first step is the asserter's original assertion.
+type ReplayStep struct {
+	Party          Party          `json:"party"`
+	ChallengeState ethcommon.Hash `json:"challengeState"`
+	SegmentStart   *big.Int       `json:"segmentStart"`
+	SegmentLength  *big.Int       `json:"segmentLength"`
+	Cuts           int            `json:"cuts"`
+	Honest         bool           `json:"honest"`
+	// Index of the first cut that doesn't match local execution
+	DivergentCut *int           `json:"divergentCut,omitempty"`
+	Transaction  ethcommon.Hash `json:"transaction"`
+	BlockNumber  uint64         `json:"blockNumber"`
+}
+
+// ProofReplay checks the proof that ended a challenge
+type ProofReplay struct {
+	Kind        MoveKind       `json:"kind"`
+	Party       Party          `json:"party"`
+	Transaction ethcommon.Hash `json:"transaction"`
+	// Whether the bisection the proof responded to was dishonest, meaning the
+	// prover should have been able to win
+	Justified bool `json:"justified"`
+	// Move an honest party would have made in response to the last bisection
+	Expected *Move `json:"expected,omitempty"`
+	// Differences between the proof that was sent and the expected move
+	Mismatches []string `json:"mismatches,omitempty"`
+	// Nil if the proof couldn't be fully checked
+	Correct *bool  `json:"correct,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReplayReport is the reconstructed history of a challenge
+type ReplayReport struct {
+	Challenge      ethcommon.Address `json:"challenge"`
+	ChallengedNode *big.Int          `json:"challengedNode"`
+	NodeHash       ethcommon.Hash    `json:"nodeHash"`
+	// The asserter is honest if the challenged node matches local execution
+	HonestParty Party         `json:"honestParty"`
+	Steps       []*ReplayStep `json:"steps"`
+	// Kind of the event that ended the challenge, or "ongoing"
+	Outcome string       `json:"outcome"`
+	Winner  Party        `json:"winner,omitempty"`
+	Proof   *ProofReplay `json:"proof,omitempty"`
+}
+
+func (r *ReplayReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Challenge %v on node %v (%v)\n", r.Challenge.Hex(), r.ChallengedNode, r.NodeHash.Hex())
+	fmt.Fprintf(&sb, "Honest party according to local execution: %v\n", r.HonestParty)
+	for i, step := range r.Steps {
+		verdict := "honest"
+		if !step.Honest {
+			verdict = fmt.Sprintf("DISHONEST (cut %v diverges)", *step.DivergentCut)
+		}
+		fmt.Fprintf(
+			&sb,
+			"%v. %v claimed %v cuts over gas %v-%v in block %v: %v\n",
+			i,
+			step.Party,
+			step.Cuts,
+			step.SegmentStart,
+			new(big.Int).Add(step.SegmentStart, step.SegmentLength),
+			step.BlockNumber,
+			verdict,
+		)
+	}
+	fmt.Fprintf(&sb, "Outcome: %v", r.Outcome)
+	if r.Winner != "" {
+		fmt.Fprintf(&sb, ", won by %v", r.Winner)
+	}
+	sb.WriteString("\n")
+	if r.Proof != nil {
+		p := r.Proof
+		fmt.Fprintf(&sb, "Final %v proof by %v in %v: ", p.Kind, p.Party, p.Transaction.Hex())
+		switch {
+		case p.Correct == nil:
+			sb.WriteString("not fully checked")
+		case *p.Correct:
+			sb.WriteString("correct")
+		default:
+			sb.WriteString("INCORRECT")
+		}
+		if !p.Justified {
+			sb.WriteString(", proved against an honest bisection")
+		}
+		sb.WriteString("\n")
+		for _, mismatch := range p.Mismatches {
+			fmt.Fprintf(&sb, "   %v\n", mismatch)
+		}
+		if p.Error != "" {
+			fmt.Fprintf(&sb, "   error: %v\n", p.Error)
+		}
+	}
+	return sb.String()
+}
+
+// firstDivergence returns the index of the first cut of the bisection which
+// doesn't match local execution, or -1 if all of them match
+func firstDivergence(lookup core.ArbCoreLookup, assertion *core.Assertion, bisection *core.Bisection) (int, error) {
+	offsets := generateBisectionCutOffsets(bisection.ChallengedSegment, len(bisection.Cuts)-1)
+	execTracker := core.NewExecutionTracker(lookup, true, offsets, true)
+	for i, offset := range offsets {
+		localCut, _, err := getCut(execTracker, assertion.After.TotalMessagesRead, offset)
+		if err != nil {
+			return 0, err
+		}
+		if localCut.CutHash() != bisection.Cuts[i].CutHash() {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func replayStep(lookup core.ArbCoreLookup, assertion *core.Assertion, party Party, bisection *core.Bisection) (*ReplayStep, error) {
+	divergence, err := firstDivergence(lookup, assertion, bisection)
+	if err != nil {
+		return nil, err
+	}
+	step := &ReplayStep{
+		Party:         party,
+		SegmentStart:  bisection.ChallengedSegment.Start,
+		SegmentLength: bisection.ChallengedSegment.Length,
+		Cuts:          len(bisection.Cuts),
+		Honest:        divergence < 0,
+	}
+	if divergence >= 0 {
+		step.DivergentCut = &divergence
+	}
+	return step, nil
+}
+
+// ReplayChallenge reconstructs a challenge from its logs and checks every move
+// against local execution. The sequencer inbox and transaction fetcher may be
+// nil when replaying offline, in which case inbox proofs and the contents of
+// the final one step proof can't be checked.
+func ReplayChallenge(
+	ctx context.Context,
+	lookup core.ArbCoreLookup,
+	rollup *ethbridge.RollupWatcher,
+	challenge *ethbridge.ChallengeWatcher,
+	sequencerInbox *ethbridge.SequencerInboxWatcher,
+	txFetcher TransactionFetcher,
+) (*ReplayReport, error) {
+	nodeNum, err := rollup.LookupChallengedNode(ctx, challenge.Address())
+	if err != nil {
+		return nil, err
+	}
+	node, err := rollup.LookupNode(ctx, nodeNum)
+	if err != nil {
+		return nil, err
+	}
+	return replayChallenge(ctx, lookup, node, challenge, sequencerInbox, txFetcher)
+}
+
+func replayChallenge(
+	ctx context.Context,
+	lookup core.ArbCoreLookup,
+	node *core.NodeInfo,
+	challenge *ethbridge.ChallengeWatcher,
+	sequencerInbox *ethbridge.SequencerInboxWatcher,
+	txFetcher TransactionFetcher,
+) (*ReplayReport, error) {
+	assertion := node.Assertion
+	if lookup.MachineMessagesRead().Cmp(assertion.After.TotalMessagesRead) < 0 {
+		return nil, errors.Errorf(
+			"local execution has only read %v of the %v messages in the challenged node",
+			lookup.MachineMessagesRead(),
+			assertion.After.TotalMessagesRead,
+		)
+	}
+	events, err := challenge.LookupEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prevBisection := node.InitialExecutionBisection()
+	initialStep, err := replayStep(lookup, assertion, AsserterParty, prevBisection)
+	if err != nil {
+		return nil, err
+	}
+	initialStep.BlockNumber = node.BlockProposed.Height.AsInt().Uint64()
+	report := &ReplayReport{
+		Challenge:      challenge.Address().ToEthAddress(),
+		ChallengedNode: node.NodeNum,
+		NodeHash:       node.NodeHash.ToEthHash(),
+		HonestParty:    ChallengerParty,
+		Steps:          []*ReplayStep{initialStep},
+		Outcome:        "ongoing",
+	}
+	if initialStep.Honest {
+		report.HonestParty = AsserterParty
+	}
+
+	party := AsserterParty
+	for _, ev := range events {
+		switch ev.Kind {
+		case ethbridge.BisectedEvent:
+			party = party.opponent()
+			step, err := replayStep(lookup, assertion, party, ev.Bisection)
+			if err != nil {
+				return nil, err
+			}
+			step.ChallengeState = ev.ChallengeRoot.ToEthHash()
+			step.Transaction = ev.TxHash
+			step.BlockNumber = ev.BlockNumber
+			report.Steps = append(report.Steps, step)
+			prevBisection = ev.Bisection
+		case ethbridge.OneStepProofCompletedEvent, ethbridge.ContinuedExecutionProvenEvent:
+			report.Winner = party.opponent()
+			lastStep := report.Steps[len(report.Steps)-1]
+			report.Proof = replayProof(ctx, lookup, sequencerInbox, txFetcher, challenge, assertion, prevBisection, lastStep, ev)
+		case ethbridge.AsserterTimedOutEvent:
+			report.Winner = ChallengerParty
+		case ethbridge.ChallengerTimedOutEvent:
+			report.Winner = AsserterParty
+		}
+		if ev.Kind != ethbridge.BisectedEvent {
+			report.Outcome = string(ev.Kind)
+		}
+	}
+	return report, nil
+}
+
+func replayProof(
+	ctx context.Context,
+	lookup core.ArbCoreLookup,
+	sequencerInbox *ethbridge.SequencerInboxWatcher,
+	txFetcher TransactionFetcher,
+	challenge *ethbridge.ChallengeWatcher,
+	assertion *core.Assertion,
+	prevBisection *core.Bisection,
+	prevStep *ReplayStep,
+	ev *ethbridge.ChallengeEvent,
+) *ProofReplay {
+	proof := &ProofReplay{
+		Kind:        OneStepProofMove,
+		Party:       prevStep.Party.opponent(),
+		Transaction: ev.TxHash,
+		Justified:   !prevStep.Honest,
+	}
+	if ev.Kind == ethbridge.ContinuedExecutionProvenEvent {
+		proof.Kind = ContinuedExecutionMove
+	}
+	if !proof.Justified {
+		// Nothing in an honest bisection should be provable
+		incorrect := false
+		proof.Correct = &incorrect
+		return proof
+	}
+
+	expected, err := computeMove(ctx, sequencerInbox, assertion, lookup, ExecutionImpl{}, prevBisection)
+	if err != nil {
+		proof.Error = err.Error()
+		return proof
+	}
+	proof.Expected = expected
+	if expected.Kind != proof.Kind {
+		proof.Mismatches = append(proof.Mismatches, fmt.Sprintf("expected a %v move", expected.Kind))
+	}
+	if proof.Kind != OneStepProofMove || len(proof.Mismatches) > 0 {
+		proof.setCorrect()
+		return proof
+	}
+	if txFetcher == nil {
+		// Without the transaction the proof data can't be checked
+		return proof
+	}
+
+	tx, _, err := txFetcher.TransactionByHash(ctx, ev.TxHash)
+	if err != nil {
+		proof.Error = errors.Wrap(err, "error fetching proof transaction").Error()
+		return proof
+	}
+	call, err := ethbridge.FindOneStepProof(tx, challenge.Address().ToEthAddress())
+	if err != nil {
+		proof.Error = err.Error()
+		return proof
+	}
+	if call == nil {
+		proof.Error = "couldn't find one step proof call in transaction"
+		return proof
+	}
+	proof.Mismatches = compareOneStepProof(expected, call)
+	proof.setCorrect()
+	return proof
+}
+
+// setCorrect marks the proof as correct if nothing differed from the expected
+// move
+func (p *ProofReplay) setCorrect() {
+	correct := len(p.Mismatches) == 0
+	p.Correct = &correct
+}
+
+func compareOneStepProof(expected *Move, call *ethbridge.OneStepProofCall) []string {
+	var mismatches []string
+	compareInt := func(name string, expected, actual *big.Int) {
+		if expected.Cmp(actual) != 0 {
+			mismatches = append(mismatches, fmt.Sprintf("%v: expected %v, got %v", name, expected, actual))
+		}
+	}
+	compareHash := func(name string, expected ethcommon.Hash, actual ethcommon.Hash) {
+		if expected != actual {
+			mismatches = append(mismatches, fmt.Sprintf("%v: expected %v, got %v", name, expected.Hex(), actual.Hex()))
+		}
+	}
+	compareInt("segment start", expected.SegmentStart, call.SegmentStart)
+	compareInt("segment length", expected.SegmentLength, call.SegmentLength)
+	compareInt("messages read", expected.StartState.TotalMessagesRead, call.TotalMessagesRead)
+	compareInt("gas consumed", expected.StartState.TotalGasConsumed, call.TotalGasConsumed)
+	compareInt("send count", expected.StartState.TotalSendCount, call.TotalSendCount)
+	compareInt("log count", expected.StartState.TotalLogCount, call.TotalLogCount)
+	compareHash("send accumulator", expected.StartState.SendAcc, call.SendAcc.ToEthHash())
+	compareHash("log accumulator", expected.StartState.LogAcc, call.LogAcc.ToEthHash())
+	if !bytes.Equal(expected.ExecutionProof, call.ExecutionProof) {
+		mismatches = append(mismatches, "execution proof differs")
+	}
+	if !bytes.Equal(expected.BufferProof, call.BufferProof) {
+		mismatches = append(mismatches, "buffer proof differs")
+	}
+	return mismatches
+}
@@ -98,7 +98,10 @@ func startup() error {
 	}
 
 	// Start up an arbitrum sequencer relay
-	arbRelay := NewArbRelay(config.Feed)
+	arbRelay, err := NewArbRelay(config.Feed)
+	if err != nil {
+		return err
+	}
 	relayDone, err := arbRelay.Start(ctx)
 	if err != nil {
 		return err
@@ -113,11 +116,19 @@ func startup() error {
 	}
 }
 
-func NewArbRelay(settings configuration.Feed) *ArbRelay {
+func NewArbRelay(settings configuration.Feed) (*ArbRelay, error) {
+	sequencerAddress, err := settings.Input.GetSequencerAddress()
+	if err != nil {
+		return nil, err
+	}
+	if sequencerAddress == nil {
+		logger.Warn().Msg("Missing --feed.input.sequencer-address so relaying feed messages without verifying signatures")
+	}
+
 	var broadcastClients []*broadcastclient.BroadcastClient
 	confirmedAccumulatorChan := make(chan common.Hash, 1)
 	for _, address := range settings.Input.URLs {
-		client := broadcastclient.NewBroadcastClient(address, nil, settings.Input.Timeout)
+		client := broadcastclient.NewBroadcastClient(address, nil, settings.Input.Timeout, sequencerAddress)
 		client.ConfirmedAccumulatorListener = confirmedAccumulatorChan
 		broadcastClients = append(broadcastClients, client)
 	}
@@ -125,7 +136,7 @@ func NewArbRelay(settings configuration.Feed) *ArbRelay {
 		broadcaster:              broadcaster.NewBroadcaster(settings.Output),
 		broadcastClients:         broadcastClients,
 		confirmedAccumulatorChan: confirmedAccumulatorChan,
-	}
+	}, nil
 }
 
 const RECENT_FEED_ITEM_TTL time.Duration = time.Second * 10
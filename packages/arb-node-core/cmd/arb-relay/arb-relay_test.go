@@ -68,7 +68,10 @@ func TestRelayRebroadcasts(t *testing.T) {
 	}
 
 	// Start up an arbitrum sequencer relay
-	arbRelay := NewArbRelay(relaySettings)
+	arbRelay, err := NewArbRelay(relaySettings)
+	if err != nil {
+		t.Fatal(err)
+	}
 	_, err = arbRelay.Start(ctx)
 	if err != nil {
 		t.Fatal(err)
@@ -96,7 +99,7 @@ func TestRelayRebroadcasts(t *testing.T) {
 }
 
 func makeRelayClient(t *testing.T, expectedCount int, wg *sync.WaitGroup) {
-	broadcastClient := broadcastclient.NewBroadcastClient("ws://127.0.0.1:7429/", nil, 20*time.Second)
+	broadcastClient := broadcastclient.NewBroadcastClient("ws://127.0.0.1:7429/", nil, 20*time.Second, nil)
 	broadcastClient.ConfirmedAccumulatorListener = make(chan common.Hash, 1)
 	defer wg.Done()
 	messageCount := 0
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -36,7 +37,9 @@ import (
 	"github.com/rs/zerolog/pkgerrors"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/challenge"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/cmdhelp"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
@@ -46,6 +49,7 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-util/broadcaster"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
 )
 
 var logger zerolog.Logger
@@ -72,28 +76,51 @@ func main() {
 	// Print line number that log was created on
 	logger = log.With().Caller().Stack().Str("component", "arb-validator").Logger()
 
-	if err := startup(); err != nil {
+	// `arb-validator plan` runs the staker's decision logic once and prints
+	// the calls it would make instead of running the validator
+	planOnly := len(os.Args) > 1 && os.Args[1] == "plan"
+	if planOnly {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
+	exitCode, err := startup(planOnly)
+	if err != nil {
 		logger.Error().Err(err).Msg("Error running validator")
 	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
 
-func startup() error {
+type validatorRunner interface {
+	GetInitialMachineHash(ctx context.Context) ([32]byte, error)
+	RunInBackground(ctx context.Context) chan bool
+}
+
+func startup(planOnly bool) (int, error) {
 	ctx, cancelFunc, cancelChan := cmdhelp.CreateLaunchContext()
 	defer cancelFunc()
 
 	config, wallet, l1Client, l1ChainId, err := configuration.ParseValidator(ctx)
 	if err != nil || len(config.Persistent.GlobalConfig) == 0 || len(config.L1.URL) == 0 ||
 		len(config.Rollup.Address) == 0 || len(config.BridgeUtilsAddress) == 0 ||
-		len(config.Validator.UtilsAddress) == 0 || len(config.Validator.WalletFactoryAddress) == 0 ||
-		len(config.Validator.Strategy) == 0 {
+		len(config.Validator.UtilsAddress) == 0 || len(config.Validator.Strategy) == 0 ||
+		(len(config.Validator.WalletFactoryAddress) == 0 && config.Validator.Strategy != "Watchtower") {
 		fmt.Printf("\n")
 		fmt.Printf("Sample usage: arb-validator --conf=<filename> \n")
 		fmt.Printf("          or: arb-validator --persistent.storage.path=<path> --l1.url=<L1 RPC> --feed.input.url=<feed websocket>\n")
+		fmt.Printf("          or: arb-validator plan --conf=<filename> to print the transactions the validator would make\n")
 		if err != nil && !strings.Contains(err.Error(), "help requested") {
 			fmt.Printf("%s\n", err.Error())
 		}
 
-		return nil
+		return 0, nil
+	}
+
+	if config.Healthcheck.L1Node {
+		if err := nodehealth.CheckL1HealthProvider(config.Healthcheck.L1Provider); err != nil {
+			return 0, err
+		}
 	}
 
 	defer logger.Log().Msg("Cleanly shutting down validator")
@@ -111,6 +138,7 @@ func startup() error {
 	metricsConfig := metrics.NewMetricsConfig(&config.Healthcheck.MetricsPrefix)
 	metricsConfig.RegisterSystemMetrics()
 	metricsConfig.RegisterStaticMetrics()
+	metricsConfig.RegisterMetrics(staker.StakeGauge, staker.RequiredStakeGauge, staker.WithdrawableFundsGauge, staker.StakeChangeCounter)
 
 	const largeChannelBuffer = 200
 	healthChan := make(chan nodehealth.Log, largeChannelBuffer)
@@ -124,9 +152,17 @@ func startup() error {
 
 	healthChan <- nodehealth.Log{Config: true, Var: "healthcheckMetrics", ValBool: config.Healthcheck.Metrics}
 	healthChan <- nodehealth.Log{Config: true, Var: "disablePrimaryCheck", ValBool: !config.Healthcheck.Sequencer}
-	healthChan <- nodehealth.Log{Config: true, Var: "disableOpenEthereumCheck", ValBool: !config.Healthcheck.L1Node}
+	healthChan <- nodehealth.Log{Config: true, Var: "disableL1Check", ValBool: !config.Healthcheck.L1Node}
+	healthChan <- nodehealth.Log{Config: true, Var: "l1HealthProvider", ValStr: config.Healthcheck.L1Provider}
+	healthChan <- nodehealth.Log{Config: true, Var: "l1ChainID", ValBigInt: l1ChainId}
+	healthChan <- nodehealth.Log{Config: true, Var: "l1BlockMaxAge", ValTime: config.Healthcheck.L1MaxBlockAge}
+	healthChan <- nodehealth.Log{Config: true, Var: "peerMinimum", ValInt: int64(config.Healthcheck.L1MinPeers)}
 	healthChan <- nodehealth.Log{Config: true, Var: "healthcheckRPC", ValStr: config.Healthcheck.Addr + ":" + config.Healthcheck.Port}
-	healthChan <- nodehealth.Log{Config: true, Var: "openethereumHealthcheckRPC", ValStr: config.L1.URL}
+	if config.Healthcheck.L1Provider == nodehealth.OpenEthereumL1Provider {
+		healthChan <- nodehealth.Log{Config: true, Var: "openethereumHealthcheckRPC", ValStr: config.L1.URL}
+	} else {
+		healthChan <- nodehealth.Log{Config: true, Var: "l1API", ValStr: config.L1.URL}
+	}
 	nodehealth.Init(healthChan)
 
 	logger.Debug().Str("chainid", l1ChainId.String()).Msg("connected to l1 chain")
@@ -134,12 +170,6 @@ func startup() error {
 	rollupAddr := ethcommon.HexToAddress(config.Rollup.Address)
 	bridgeUtilsAddr := ethcommon.HexToAddress(config.BridgeUtilsAddress)
 	validatorUtilsAddr := ethcommon.HexToAddress(config.Validator.UtilsAddress)
-	validatorWalletFactoryAddr := ethcommon.HexToAddress(config.Validator.WalletFactoryAddress)
-	auth, _, err := cmdhelp.GetKeystore(config.Persistent.Chain, wallet, config.GasPrice, l1ChainId)
-	if err != nil {
-		return errors.Wrap(err, "error loading wallet keystore")
-	}
-	logger.Info().Str("address", auth.From.String()).Msg("Loaded wallet")
 
 	strategyString := config.Validator.Strategy
 	var strategy staker.Strategy
@@ -149,34 +179,251 @@ func startup() error {
 		strategy = staker.StakeLatestStrategy
 	} else if strategyString == "Defensive" {
 		strategy = staker.DefensiveStrategy
+	} else if strategyString == "Watchtower" {
+		strategy = staker.WatchtowerStrategy
+	} else {
+		return 0, errors.New("unsupported strategy specified. Currently supported: MakeNodes, StakeLatest, Defensive, Watchtower")
+	}
+
+	if planOnly {
+		if strategy == staker.WatchtowerStrategy {
+			return 0, errors.New("the watchtower strategy doesn't make any transactions to plan")
+		}
+		config.Validator.DryRun = true
+	}
+
+	mon, err := monitor.NewMonitor(config.GetValidatorDatabasePath(), config.Rollup.Machine.Filename)
+	if err != nil {
+		return 0, errors.Wrap(err, "error opening monitor")
+	}
+	defer mon.Close()
+
+	var runner validatorRunner
+	var stakerManager *staker.Staker
+	var exitChan <-chan int
+	if strategy == staker.WatchtowerStrategy {
+		// The watchtower only observes so it doesn't need a wallet
+		var sinks []staker.AlertSink
+		if len(config.Validator.Watchtower.WebhookURL) != 0 {
+			sinks = append(sinks, staker.NewWebhookAlertSink(config.Validator.Watchtower.WebhookURL))
+		}
+		if len(config.Validator.Watchtower.LogFile) != 0 {
+			sinks = append(sinks, staker.NewLogFileAlertSink(config.Validator.Watchtower.LogFile))
+		}
+		if config.Validator.Watchtower.ExitCode != 0 {
+			exitSink := staker.NewExitAlertSink(config.Validator.Watchtower.ExitCode)
+			sinks = append(sinks, exitSink)
+			exitChan = exitSink.Done()
+		}
+		watchtower, err := staker.NewWatchtower(ctx, mon.Core, l1Client, common.NewAddressFromEth(rollupAddr), config.Rollup.FromBlock, common.NewAddressFromEth(validatorUtilsAddr), sinks)
+		if err != nil {
+			return 0, errors.Wrap(err, "error setting up watchtower")
+		}
+		watchtower.Interval = config.Validator.Watchtower.Interval
+		runner = watchtower
 	} else {
-		return errors.New("unsupported strategy specified. Currently supported: MakeNodes, StakeLatest")
+		val, err := setupValidatorWallet(ctx, config, wallet, l1Client, l1ChainId)
+		if err != nil {
+			return 0, err
+		}
+
+		stakerManager, _, err = staker.NewStaker(ctx, mon.Core, l1Client, val, config.Rollup.FromBlock, common.NewAddressFromEth(validatorUtilsAddr), strategy)
+		if err != nil {
+			return 0, errors.Wrap(err, "error setting up staker")
+		}
+		stakerManager.DryRun = config.Validator.DryRun
+		stakerManager.StakePolicy, err = getStakePolicy(config)
+		if err != nil {
+			return 0, err
+		}
+		if !config.Validator.DryRun {
+			journal, err := challenge.OpenJournal(config.GetChallengeJournalPath())
+			if err != nil {
+				return 0, err
+			}
+			defer journal.Close()
+			stakerManager.ChallengeJournal = journal
+		}
+		runner = stakerManager
+	}
+
+	chainMachineHash, err := runner.GetInitialMachineHash(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "error checking initial chain state")
+	}
+	initialExecutionCursor, err := mon.Core.GetExecutionCursor(big.NewInt(0))
+	if err != nil {
+		return 0, errors.Wrap(err, "error loading initial ArbCore machine")
+	}
+	initialMachineHash := initialExecutionCursor.MachineHash()
+	if initialMachineHash != chainMachineHash {
+		return 0, errors.Errorf("Initial machine hash loaded from arbos.mexe doesn't match chain's initial machine hash: chain %v, arbCore %v", hexutil.Encode(chainMachineHash[:]), initialMachineHash)
+	}
+
+	inboxReaderConfig := monitor.InboxReaderConfig{
+		ConfirmationDepth: config.InboxReader.ConfirmationDepth,
+		Finality:          config.InboxReader.Finality,
+	}
+	inboxReader, err := mon.StartInboxReader(ctx, l1Client, common.NewAddressFromEth(rollupAddr), config.Rollup.FromBlock, common.NewAddressFromEth(bridgeUtilsAddr), inboxReaderConfig, healthChan, dummySequencerFeed)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create inbox reader")
+	}
+
+	if planOnly {
+		return 0, printPlan(ctx, mon, inboxReader, stakerManager)
+	}
+	if config.Validator.DryRun {
+		logger.Warn().Msg("Dry run enabled, validator transactions will be logged instead of sent")
+	}
+	if config.Validator.Status.Enable {
+		if stakerManager == nil {
+			logger.Warn().Msg("Validator status is only available for staking strategies")
+		} else {
+			statusHandler, err := staker.NewStatusHandler(stakerManager)
+			if err != nil {
+				return 0, errors.Wrap(err, "error creating validator status handler")
+			}
+			go func() {
+				err := launchStatusServer(ctx, statusHandler, config.Validator.Status.Addr, config.Validator.Status.Port)
+				if err != nil {
+					logger.Error().Err(err).Msg("validator status server failed")
+				}
+			}()
+		}
+	}
+
+	logger.Info().Int("strategy", int(strategy)).Msg("Initialized validator")
+	select {
+	case <-cancelChan:
+		return 0, nil
+	case <-runner.RunInBackground(ctx):
+		return 0, nil
+	case exitCode := <-exitChan:
+		logger.Warn().Int("code", exitCode).Msg("Exiting after watchtower alert")
+		return exitCode, nil
+	}
+}
+
+func launchStatusServer(ctx context.Context, handler http.Handler, addr string, port string) error {
+	server := &http.Server{Addr: addr + ":" + port, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			logger.Warn().Err(err).Msg("error shutting down validator status server")
+		}
+	}()
+	logger.Info().Str("addr", server.Addr).Msg("Launching validator status server")
+	err := server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// printPlan waits for the local node to catch up with the chain and prints
+// the transaction the staker would make next
+func printPlan(ctx context.Context, mon *monitor.Monitor, inboxReader *monitor.InboxReader, stakerManager *staker.Staker) error {
+	logger.Info().Msg("Waiting to catch up to the chain before planning")
+	inboxReader.WaitToCatchUp(ctx)
+	for {
+		messageCount, err := mon.Core.GetMessageCount()
+		if err != nil {
+			return err
+		}
+		if mon.Core.MachineMessagesRead().Cmp(messageCount) >= 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
 	}
 
+	plan, err := stakerManager.Plan(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error planning validator transactions")
+	}
+	fmt.Print(plan.String())
+	if len(plan.Calls) == 0 {
+		fmt.Printf("\n")
+	}
+	return nil
+}
+
+// getStakePolicy builds the staker's stake policy from the validator config
+func getStakePolicy(config *configuration.Config) (staker.StakePolicy, error) {
+	stakeConfig := config.Validator.Stake
+	policy := staker.StakePolicy{
+		TopUp:          stakeConfig.TopUp,
+		WithdrawExcess: stakeConfig.WithdrawExcess,
+	}
+	if len(stakeConfig.ColdAddress) > 0 {
+		if !ethcommon.IsHexAddress(stakeConfig.ColdAddress) {
+			return policy, errors.Errorf("invalid cold address %v", stakeConfig.ColdAddress)
+		}
+		coldAddress := common.HexToAddress(stakeConfig.ColdAddress)
+		policy.ColdAddress = &coldAddress
+	}
+	if stakeConfig.MaxCommitment > 0 {
+		policy.MaxCommitment, _ = new(big.Float).Mul(
+			big.NewFloat(stakeConfig.MaxCommitment),
+			big.NewFloat(params.Ether),
+		).Int(nil)
+	}
+	return policy, nil
+}
+
+// setupValidatorWallet loads the keystore and returns the validator wallet,
+// deploying one if the chain state doesn't record an existing wallet. In dry
+// run mode it never sends a transaction, so the wallet must already exist.
+func setupValidatorWallet(
+	ctx context.Context,
+	config *configuration.Config,
+	wallet *configuration.Wallet,
+	l1Client ethutils.EthClient,
+	l1ChainId *big.Int,
+) (*ethbridge.ValidatorWallet, error) {
+	rollupAddr := ethcommon.HexToAddress(config.Rollup.Address)
+	validatorWalletFactoryAddr := ethcommon.HexToAddress(config.Validator.WalletFactoryAddress)
+	auth, _, err := cmdhelp.GetSigner(ctx, config.Persistent.Chain, wallet, config.GasPrice, l1ChainId)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading wallet")
+	}
+	logger.Info().Str("address", auth.From.String()).Msg("Loaded wallet")
+
 	chainState := ChainState{}
 	chainStatePath := path.Join(config.Persistent.Chain, "chainState.json")
 	chainStateFile, err := os.Open(chainStatePath)
 	if err != nil {
 		if !os.IsNotExist(err) {
-			return errors.Wrap(err, "failed to open chainState.json")
+			return nil, errors.Wrap(err, "failed to open chainState.json")
 		}
 	} else {
 		chainStateData, err := ioutil.ReadAll(chainStateFile)
 		if err != nil {
-			return errors.Wrap(err, "failed to read chain state")
+			return nil, errors.Wrap(err, "failed to read chain state")
 		}
 		err = json.Unmarshal(chainStateData, &chainState)
 		if err != nil {
-			return errors.Wrap(err, "failed to unmarshal chain state")
+			return nil, errors.Wrap(err, "failed to unmarshal chain state")
 		}
 	}
 
-	valAuth, err := ethbridge.NewTransactAuth(ctx, l1Client, auth, config.GasPriceUrl)
+	var valAuth *ethbridge.TransactAuth
+	if config.Validator.DryRun {
+		// Skip resubmission so no transactions are replaced
+		valAuth, err = ethbridge.NewTransactAuth(ctx, l1Client, auth, config.GasPriceUrl)
+	} else {
+		valAuth, err = ethbridge.NewTransactAuthAdvanced(ctx, l1Client, auth, cmdhelp.GetTransactAuthConfig(config))
+	}
 	if err != nil {
-		return errors.Wrap(err, "error creating connecting to chain")
+		return nil, errors.Wrap(err, "error creating connecting to chain")
 	}
 	validatorAddress := ethcommon.Address{}
-	if chainState.ValidatorWallet == "" {
+	if chainState.ValidatorWallet == "" && config.Validator.DryRun {
+		return nil, errors.Errorf("no validator wallet recorded in %v, run without dry run once to deploy it", chainStatePath)
+	} else if chainState.ValidatorWallet == "" {
 		for {
 			validatorAddress, err = ethbridge.CreateValidatorWallet(ctx, validatorWalletFactoryAddr, config.Rollup.FromBlock, valAuth, l1Client)
 			if err == nil {
@@ -191,54 +438,18 @@ func startup() error {
 
 		newChainStateData, err := json.Marshal(chainState)
 		if err != nil {
-			return errors.Wrap(err, "failed to marshal chain state")
+			return nil, errors.Wrap(err, "failed to marshal chain state")
 		}
 		if err := ioutil.WriteFile(chainStatePath, newChainStateData, 0644); err != nil {
-			return errors.Wrap(err, "failed to write chain state config")
+			return nil, errors.Wrap(err, "failed to write chain state config")
 		}
 	} else {
 		validatorAddress = ethcommon.HexToAddress(chainState.ValidatorWallet)
 	}
 
-	mon, err := monitor.NewMonitor(config.GetValidatorDatabasePath(), config.Rollup.Machine.Filename)
-	if err != nil {
-		return errors.Wrap(err, "error opening monitor")
-	}
-	defer mon.Close()
-
 	val, err := ethbridge.NewValidator(validatorAddress, rollupAddr, l1Client, valAuth)
 	if err != nil {
-		return errors.Wrap(err, "error creating validator wallet")
-	}
-
-	stakerManager, _, err := staker.NewStaker(ctx, mon.Core, l1Client, val, config.Rollup.FromBlock, common.NewAddressFromEth(validatorUtilsAddr), strategy)
-	if err != nil {
-		return errors.Wrap(err, "error setting up staker")
-	}
-
-	chainMachineHash, err := stakerManager.GetInitialMachineHash(ctx)
-	if err != nil {
-		return errors.Wrap(err, "error checking initial chain state")
-	}
-	initialExecutionCursor, err := mon.Core.GetExecutionCursor(big.NewInt(0))
-	if err != nil {
-		return errors.Wrap(err, "error loading initial ArbCore machine")
-	}
-	initialMachineHash := initialExecutionCursor.MachineHash()
-	if initialMachineHash != chainMachineHash {
-		return errors.Errorf("Initial machine hash loaded from arbos.mexe doesn't match chain's initial machine hash: chain %v, arbCore %v", hexutil.Encode(chainMachineHash[:]), initialMachineHash)
-	}
-
-	_, err = mon.StartInboxReader(ctx, l1Client, common.NewAddressFromEth(rollupAddr), config.Rollup.FromBlock, common.NewAddressFromEth(bridgeUtilsAddr), healthChan, dummySequencerFeed)
-	if err != nil {
-		return errors.Wrap(err, "failed to create inbox reader")
-	}
-
-	logger.Info().Int("strategy", int(strategy)).Msg("Initialized validator")
-	select {
-	case <-cancelChan:
-		return nil
-	case <-stakerManager.RunInBackground(ctx):
-		return nil
+		return nil, errors.Wrap(err, "error creating validator wallet")
 	}
+	return val, nil
 }
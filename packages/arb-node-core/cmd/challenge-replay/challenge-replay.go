@@ -0,0 +1,140 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	golog "log"
+	"os"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog/pkgerrors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/challenge"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/cmdhelp"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/monitor"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+var logger zerolog.Logger
+
+func main() {
+	// Enable line numbers in logging
+	golog.SetFlags(golog.LstdFlags | golog.Lshortfile)
+
+	// Print stack trace when `.Error().Stack().Err(err).` is added to zerolog call
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	// Print line number that log was created on
+	logger = log.With().Caller().Stack().Str("component", "challenge-replay").Logger()
+
+	if err := startup(); err != nil {
+		logger.Error().Err(err).Msg("Error replaying challenge")
+		os.Exit(1)
+	}
+}
+
+func startup() error {
+	ctx, cancelFunc, _ := cmdhelp.CreateLaunchContext()
+	defer cancelFunc()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	l1URL := fs.String("l1url", "", "L1 RPC to read the challenge from")
+	logFile := fs.String("logs", "", "JSON file of the rollup and challenge logs, as returned by eth_getLogs, to replay without an L1 connection")
+	fromBlock := fs.Int64("fromblock", 0, "L1 block to start searching for events from")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	gethLogLevel, arbLogLevel := cmdhelp.AddLogFlags(fs)
+
+	err := fs.Parse(os.Args[1:])
+	if err != nil {
+		return errors.Wrap(err, "error parsing arguments")
+	}
+
+	if fs.NArg() != 4 || (len(*l1URL) == 0) == (len(*logFile) == 0) {
+		fmt.Println("usage: challenge-replay (-l1url <L1 RPC> | -logs <file>) <database> <machine> <rollup_address> <challenge_address>")
+		return errors.New("invalid arguments")
+	}
+
+	if err := cmdhelp.ParseLogFlags(gethLogLevel, arbLogLevel); err != nil {
+		return err
+	}
+
+	rollupAddr := ethcommon.HexToAddress(fs.Arg(2))
+	challengeAddr := ethcommon.HexToAddress(fs.Arg(3))
+
+	var client ethutils.EthClient
+	// Only available with an L1 connection
+	var sequencerInbox *ethbridge.SequencerInboxWatcher
+	var txFetcher challenge.TransactionFetcher
+	if len(*logFile) > 0 {
+		client, err = ethutils.NewLogFileClient(*logFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		client, err = ethutils.NewRPCEthClient(*l1URL)
+		if err != nil {
+			return errors.Wrap(err, "error connecting to L1")
+		}
+		txFetcher = client
+	}
+
+	rollup, err := ethbridge.NewRollupWatcher(rollupAddr, *fromBlock, client)
+	if err != nil {
+		return err
+	}
+	challengeWatcher, err := ethbridge.NewChallengeWatcher(challengeAddr, *fromBlock, client)
+	if err != nil {
+		return err
+	}
+	if txFetcher != nil {
+		sequencerBridge, err := rollup.SequencerBridge(ctx)
+		if err != nil {
+			return err
+		}
+		sequencerInbox, err = ethbridge.NewSequencerInboxWatcher(sequencerBridge.ToEthAddress(), client)
+		if err != nil {
+			return err
+		}
+	}
+
+	mon, err := monitor.NewMonitor(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return errors.Wrap(err, "error opening database")
+	}
+	defer mon.Close()
+
+	report, err := challenge.ReplayChallenge(ctx, mon.Core, rollup, challengeWatcher, sequencerInbox, txFetcher)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return errors.WithStack(encoder.Encode(report))
+	}
+	fmt.Print(report.String())
+	return nil
+}
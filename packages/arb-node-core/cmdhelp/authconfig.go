@@ -0,0 +1,60 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmdhelp
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
+)
+
+// GetTransactAuthConfig returns the settings for pricing and resubmitting L1
+// transactions
+func GetTransactAuthConfig(config *configuration.Config) ethbridge.TransactAuthConfig {
+	authConfig := ethbridge.TransactAuthConfig{
+		GasPriceUrl: config.GasPriceUrl,
+	}
+	if config.L1.DynamicFees.Enable {
+		authConfig.DynamicFees = &ethbridge.DynamicFeeConfig{
+			Blocks:               config.L1.DynamicFees.Blocks,
+			RewardPercentile:     config.L1.DynamicFees.RewardPercentile,
+			MaxFeePerGas:         gweiToWei(config.L1.DynamicFees.MaxFee),
+			MaxPriorityFeePerGas: gweiToWei(config.L1.DynamicFees.MaxPriorityFee),
+		}
+	}
+	if config.L1.Resubmit.Enable {
+		authConfig.Resubmit = &ethbridge.ResubmitConfig{
+			BlockDelay:        config.L1.Resubmit.BlockDelay,
+			BumpPercent:       config.L1.Resubmit.BumpPercent,
+			MaxGasPrice:       gweiToWei(config.L1.Resubmit.MaxGasPrice),
+			ConfirmationDepth: config.L1.Resubmit.ConfirmationDepth,
+			StateDir:          config.Persistent.Chain,
+		}
+	}
+	return authConfig
+}
+
+// gweiToWei returns nil for prices that aren't positive, meaning no limit
+func gweiToWei(gwei float64) *big.Int {
+	wei := 1e9 * gwei
+	if wei <= 0 || wei >= math.MaxInt64 {
+		return nil
+	}
+	return big.NewInt(int64(wei))
+}
@@ -17,6 +17,7 @@
 package cmdhelp
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/big"
@@ -27,11 +28,41 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/signer"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
 )
 
+// GetSigner returns a transaction authorization and feed data signer backed
+// by the remote signer if one is configured, and otherwise by the local
+// keystore as in GetKeystore.
+func GetSigner(
+	ctx context.Context,
+	validatorFolder string,
+	wallet *configuration.Wallet,
+	gasPrice float64,
+	chainId *big.Int,
+) (*bind.TransactOpts, func([]byte) ([]byte, error), error) {
+	if len(wallet.RemoteSigner.URL) == 0 {
+		return GetKeystore(validatorFolder, wallet, gasPrice, chainId)
+	}
+	var address ethcommon.Address
+	if len(wallet.RemoteSigner.Address) != 0 {
+		if !ethcommon.IsHexAddress(wallet.RemoteSigner.Address) {
+			return nil, nil, errors.Errorf("invalid remote signer address %v", wallet.RemoteSigner.Address)
+		}
+		address = ethcommon.HexToAddress(wallet.RemoteSigner.Address)
+	}
+	remoteSigner, err := signer.NewRemoteSigner(ctx, wallet.RemoteSigner.URL, address, wallet.RemoteSigner.API)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signerAuth(remoteSigner, gasPrice, chainId)
+}
+
 // GetKeystore returns a transaction authorization based on an existing ethereum
 // keystore located in validatorFolder/wallets or creates one if it does not
 // exist. It accepts a password using the "password" command line argument or
@@ -82,21 +113,18 @@ func GetKeystore(
 		}
 	}
 
-	auth, err := bind.NewKeyStoreTransactorWithChainID(ks, account, chainId)
-	if err != nil {
-		return nil, nil, err
-	}
+	return signerAuth(signer.NewKeystoreSigner(ks, account), gasPrice, chainId)
+}
+
+func signerAuth(s signer.Signer, gasPrice float64, chainId *big.Int) (*bind.TransactOpts, func([]byte) ([]byte, error), error) {
+	auth := signer.TransactOpts(s, chainId)
 
 	gasPriceAsFloat := 1e9 * gasPrice
 	if gasPriceAsFloat < math.MaxInt64 {
 		auth.GasPrice = big.NewInt(int64(gasPriceAsFloat))
 	}
 
-	signer := func(data []byte) ([]byte, error) {
-		return ks.SignHash(account, data)
-	}
-
-	return auth, signer, nil
+	return auth, signer.DataSigner(s), nil
 }
 
 const WalletArgsString = "[--wallet.password=pass] [--wallet.gasprice==FloatInGwei]"
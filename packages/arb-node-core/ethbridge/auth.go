@@ -44,8 +44,21 @@ const (
 
 type TransactAuth struct {
 	sync.Mutex
+	client      ethutils.EthClient
 	auth        *bind.TransactOpts
 	gasPriceUrl string
+	dynamicFees *DynamicFeeConfig
+	feeFetcher  ethutils.FeeHistoryFetcher
+	resubmitter *Resubmitter
+}
+
+type TransactAuthConfig struct {
+	GasPriceUrl string
+	// Send dynamic fee transactions priced using the L1 fee history, takes
+	// precedence over GasPriceUrl if set
+	DynamicFees *DynamicFeeConfig
+	// Replace transactions that aren't included, disabled if nil
+	Resubmit *ResubmitConfig
 }
 
 func NewTransactAuth(ctx context.Context, client ethutils.EthClient, auth *bind.TransactOpts, gasPriceUrl string) (*TransactAuth, error) {
@@ -57,11 +70,88 @@ func NewTransactAuth(ctx context.Context, client ethutils.EthClient, auth *bind.
 		auth.Nonce = new(big.Int).SetUint64(nonce)
 	}
 	return &TransactAuth{
+		client:      client,
 		auth:        auth,
 		gasPriceUrl: gasPriceUrl,
 	}, nil
 }
 
+// NewTransactAuthAdvanced is like NewTransactAuth but also enables the
+// optional pricing and resubmission features from the config
+func NewTransactAuthAdvanced(ctx context.Context, client ethutils.EthClient, auth *bind.TransactOpts, config TransactAuthConfig) (*TransactAuth, error) {
+	transactAuth, err := NewTransactAuth(ctx, client, auth, config.GasPriceUrl)
+	if err != nil {
+		return nil, err
+	}
+	if config.DynamicFees != nil {
+		if err := transactAuth.EnableDynamicFees(*config.DynamicFees); err != nil {
+			return nil, err
+		}
+	}
+	if config.Resubmit != nil {
+		if err := transactAuth.EnableResubmission(ctx, *config.Resubmit); err != nil {
+			return nil, err
+		}
+	}
+	return transactAuth, nil
+}
+
+// EnableDynamicFees sends transactions as EIP-1559 dynamic fee transactions,
+// with the max fee and priority fee based on the base fee and priority fees
+// reported by the L1 node's eth_feeHistory
+func (t *TransactAuth) EnableDynamicFees(config DynamicFeeConfig) error {
+	feeFetcher, ok := t.client.(ethutils.FeeHistoryFetcher)
+	if !ok {
+		return errors.New("L1 client doesn't support fee history")
+	}
+	if config.Blocks == 0 {
+		return errors.New("fee history block count must be greater than 0")
+	}
+	if config.RewardPercentile < 0 || config.RewardPercentile > 100 {
+		return errors.New("fee history reward percentile must be between 0 and 100")
+	}
+	t.dynamicFees = &config
+	t.feeFetcher = feeFetcher
+	return nil
+}
+
+// EnableResubmission tracks all transactions sent from now on, as well as
+// those persisted from a previous run, and replaces them with higher gas
+// price versions if they get stuck
+func (t *TransactAuth) EnableResubmission(ctx context.Context, config ResubmitConfig) error {
+	resubmitter, err := NewResubmitter(t.client, t.auth, config)
+	if err != nil {
+		return err
+	}
+	resubmitter.Start(ctx)
+	t.resubmitter = resubmitter
+	return nil
+}
+
+func (t *TransactAuth) track(tx *types.Transaction) {
+	if t.resubmitter == nil {
+		return
+	}
+	if err := t.resubmitter.Track(tx); err != nil {
+		logger.Error().Err(err).Hex("tx", tx.Hash().Bytes()).Msg("failed to track transaction for resubmission")
+	}
+}
+
+// TransactionReceipt returns the receipt of the given transaction, or of the
+// replacement that was included in its place if resubmission is enabled
+func (t *TransactAuth) TransactionReceipt(ctx context.Context, txHash ethcommon.Hash) (*types.Receipt, error) {
+	if t.resubmitter == nil {
+		return t.client.TransactionReceipt(ctx, txHash)
+	}
+	return t.resubmitter.TransactionReceipt(ctx, txHash)
+}
+
+// WaitForReceipt waits until the given transaction or its replacement is
+// included and returns an error if it failed
+func (t *TransactAuth) WaitForReceipt(ctx context.Context, tx *types.Transaction, methodName string) (*types.Receipt, error) {
+	return waitForReceiptWithResults(ctx, t, t.client, t.auth.From, tx, methodName)
+}
+
 func (t *TransactAuth) makeContract(ctx context.Context, contractFunc func(auth *bind.TransactOpts) (ethcommon.Address, *types.Transaction, interface{}, error)) (ethcommon.Address, *types.Transaction, error) {
 	auth, err := t.getAuth(ctx)
 	if err != nil {
@@ -101,6 +191,7 @@ func (t *TransactAuth) makeContract(ctx context.Context, contractFunc func(auth
 
 	// Transaction successful, increment nonce for next time
 	logger.Info().Str("nonce", auth.Nonce.String()).Hex("sender", t.auth.From.Bytes()).Send()
+	t.track(tx)
 
 	t.auth.Nonce = t.auth.Nonce.Add(t.auth.Nonce, big.NewInt(1))
 	return addr, tx, err
@@ -127,8 +218,26 @@ type gasPriceInfo struct {
 
 // May use Etherscan's API to get gas price: https://etherscan.io/apis
 func (t *TransactAuth) getAuth(ctx context.Context) (*bind.TransactOpts, error) {
-	gasPrice := t.auth.GasPrice
-	if t.gasPriceUrl != "" {
+	auth := &bind.TransactOpts{
+		From:     t.auth.From,
+		Nonce:    t.auth.Nonce,
+		Signer:   t.auth.Signer,
+		Value:    t.auth.Value,
+		GasPrice: t.auth.GasPrice,
+		GasLimit: t.auth.GasLimit,
+		Context:  ctx,
+	}
+	if t.dynamicFees != nil {
+		fees, err := SuggestDynamicFees(ctx, t.feeFetcher, *t.dynamicFees)
+		if err != nil {
+			return nil, err
+		}
+		// Setting the fee caps without a gas price makes the bindings build a
+		// dynamic fee transaction
+		auth.GasPrice = nil
+		auth.GasFeeCap = fees.MaxFeePerGas
+		auth.GasTipCap = fees.MaxPriorityFeePerGas
+	} else if t.gasPriceUrl != "" {
 		resp, err := http.Get(t.gasPriceUrl)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get gas price")
@@ -147,15 +256,7 @@ func (t *TransactAuth) getAuth(ctx context.Context) (*bind.TransactOpts, error)
 		if !ok {
 			return nil, errors.New("failed to parse gas price")
 		}
-		gasPrice, _ = gasPriceFloat.Mul(gasPriceFloat, big.NewFloat(1e9)).Int(new(big.Int))
+		auth.GasPrice, _ = gasPriceFloat.Mul(gasPriceFloat, big.NewFloat(1e9)).Int(new(big.Int))
 	}
-	return &bind.TransactOpts{
-		From:     t.auth.From,
-		Nonce:    t.auth.Nonce,
-		Signer:   t.auth.Signer,
-		Value:    t.auth.Value,
-		GasPrice: gasPrice,
-		GasLimit: t.auth.GasLimit,
-		Context:  ctx,
-	}, nil
+	return auth, nil
 }
@@ -52,8 +52,10 @@ type CountAndAccumulator struct {
 	Accumulator common.Hash
 }
 
-func (b *BridgeUtils) GetCountsAndAccumulators(ctx context.Context) (delayedRet, seqRet CountAndAccumulator, err error) {
-	contractRet, contractErr := b.con.GetCountsAndAccumulators(&bind.CallOpts{Context: ctx}, b.delayedBridgeAddr, b.sequencerInboxAddr)
+// GetCountsAndAccumulators returns the inbox counts and accumulators as of
+// blockNumber, or the latest block if blockNumber is nil
+func (b *BridgeUtils) GetCountsAndAccumulators(ctx context.Context, blockNumber *big.Int) (delayedRet, seqRet CountAndAccumulator, err error) {
+	contractRet, contractErr := b.con.GetCountsAndAccumulators(&bind.CallOpts{Context: ctx, BlockNumber: blockNumber}, b.delayedBridgeAddr, b.sequencerInboxAddr)
 	err = errors.WithStack(contractErr)
 	if err != nil {
 		return
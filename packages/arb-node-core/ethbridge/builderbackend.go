@@ -31,6 +31,8 @@ import (
 
 type BuilderBackend struct {
 	transactions []*types.Transaction
+	// Estimated gas of executing transactions[:i+1] through the wallet
+	gasEstimates []uint64
 	builderAuth  *bind.TransactOpts
 	realSender   common.Address
 	wallet       common.Address
@@ -57,6 +59,7 @@ func (b *BuilderBackend) TransactionCount() int {
 
 func (b *BuilderBackend) ClearTransactions() {
 	b.transactions = nil
+	b.gasEstimates = nil
 }
 
 func (b *BuilderBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
@@ -83,6 +86,17 @@ func (b *BuilderBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error)
 	return big.NewInt(0), nil
 }
 
+func (b *BuilderBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+// HeaderByNumber returns a header without a base fee so that the calls
+// collected here are built as legacy transactions, since only their data is
+// used
+func (b *BuilderBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{}, nil
+}
+
 func (b *BuilderBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (gas uint64, err error) {
 	return 0, nil
 }
@@ -101,8 +115,12 @@ func (b *BuilderBackend) SendTransaction(ctx context.Context, tx *types.Transact
 		Value:    totalAmount,
 		Data:     realData,
 	}
-	_, err = b.realClient.EstimateGas(ctx, msg)
-	return errors.WithStack(err)
+	gas, err := b.realClient.EstimateGas(ctx, msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	b.gasEstimates = append(b.gasEstimates, gas)
+	return nil
 }
 
 func (b *BuilderBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
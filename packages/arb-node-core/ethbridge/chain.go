@@ -69,7 +69,11 @@ func WaitForReceiptWithResultsSimple(ctx context.Context, client ethutils.Receip
 }
 
 func WaitForReceiptWithResults(ctx context.Context, client ethutils.EthClient, from ethcommon.Address, tx *types.Transaction, methodName string) (*types.Receipt, error) {
-	receipt, err := WaitForReceiptWithResultsSimple(ctx, client, tx.Hash())
+	return waitForReceiptWithResults(ctx, client, client, from, tx, methodName)
+}
+
+func waitForReceiptWithResults(ctx context.Context, receiptFetcher ethutils.ReceiptFetcher, client ethutils.EthClient, from ethcommon.Address, tx *types.Transaction, methodName string) (*types.Receipt, error) {
+	receipt, err := WaitForReceiptWithResultsSimple(ctx, receiptFetcher, tx.Hash())
 	if err != nil {
 		logger.Warn().Err(err).Hex("tx", tx.Hash().Bytes()).Msg("error while waiting for transaction receipt")
 		return nil, errors.WithStack(err)
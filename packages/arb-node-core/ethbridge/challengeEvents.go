@@ -0,0 +1,209 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+)
+
+var challengeABI abi.ABI
+var asserterTimedOutID ethcommon.Hash
+var challengerTimedOutID ethcommon.Hash
+var continuedExecutionProvenID ethcommon.Hash
+var oneStepProofCompletedID ethcommon.Hash
+
+func init() {
+	parsedChallenge, err := abi.JSON(strings.NewReader(ethbridgecontracts.ChallengeABI))
+	if err != nil {
+		panic(err)
+	}
+	challengeABI = parsedChallenge
+	asserterTimedOutID = parsedChallenge.Events["AsserterTimedOut"].ID
+	challengerTimedOutID = parsedChallenge.Events["ChallengerTimedOut"].ID
+	continuedExecutionProvenID = parsedChallenge.Events["ContinuedExecutionProven"].ID
+	oneStepProofCompletedID = parsedChallenge.Events["OneStepProofCompleted"].ID
+}
+
+type ChallengeEventKind string
+
+const (
+	BisectedEvent                 ChallengeEventKind = "bisected"
+	ContinuedExecutionProvenEvent ChallengeEventKind = "continuedExecutionProven"
+	OneStepProofCompletedEvent    ChallengeEventKind = "oneStepProofCompleted"
+	AsserterTimedOutEvent         ChallengeEventKind = "asserterTimedOut"
+	ChallengerTimedOutEvent       ChallengeEventKind = "challengerTimedOut"
+)
+
+// ChallengeEvent is a move in a challenge as recorded in the challenge's logs
+type ChallengeEvent struct {
+	Kind ChallengeEventKind
+	// Set for bisections only
+	ChallengeRoot common.Hash
+	Bisection     *core.Bisection
+	BlockNumber   uint64
+	TxHash        ethcommon.Hash
+}
+
+// LookupEvents returns every move made in the challenge in the order they
+// happened
+func (c *ChallengeWatcher) LookupEvents(ctx context.Context) ([]*ChallengeEvent, error) {
+	var query = ethereum.FilterQuery{
+		BlockHash: nil,
+		FromBlock: big.NewInt(c.fromBlock),
+		ToBlock:   nil,
+		Addresses: []ethcommon.Address{c.address},
+		Topics: [][]ethcommon.Hash{{
+			bisectedID,
+			continuedExecutionProvenID,
+			oneStepProofCompletedID,
+			asserterTimedOutID,
+			challengerTimedOutID,
+		}},
+	}
+	logs, err := c.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	events := make([]*ChallengeEvent, 0, len(logs))
+	for _, ethLog := range logs {
+		ev := &ChallengeEvent{
+			BlockNumber: ethLog.BlockNumber,
+			TxHash:      ethLog.TxHash,
+		}
+		switch ethLog.Topics[0] {
+		case bisectedID:
+			ev.Kind = BisectedEvent
+			ev.ChallengeRoot = common.NewHashFromEth(ethLog.Topics[1])
+			ev.Bisection, err = c.parseBisection(ethLog)
+			if err != nil {
+				return nil, err
+			}
+		case continuedExecutionProvenID:
+			ev.Kind = ContinuedExecutionProvenEvent
+		case oneStepProofCompletedID:
+			ev.Kind = OneStepProofCompletedEvent
+		case asserterTimedOutID:
+			ev.Kind = AsserterTimedOutEvent
+		case challengerTimedOutID:
+			ev.Kind = ChallengerTimedOutEvent
+		default:
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// OneStepProofCall holds the arguments of a oneStepProveExecution call
+type OneStepProofCall struct {
+	SegmentStart      *big.Int
+	SegmentLength     *big.Int
+	OldEndHash        common.Hash
+	TotalMessagesRead *big.Int
+	SendAcc           common.Hash
+	LogAcc            common.Hash
+	TotalGasConsumed  *big.Int
+	TotalSendCount    *big.Int
+	TotalLogCount     *big.Int
+	ExecutionProof    []byte
+	BufferProof       []byte
+	Prover            uint8
+}
+
+// FindOneStepProof extracts the oneStepProveExecution call on the given
+// challenge from a transaction, which may call the challenge directly or
+// through a validator wallet. It returns nil if the transaction doesn't contain
+// one.
+func FindOneStepProof(tx *types.Transaction, challenge ethcommon.Address) (*OneStepProofCall, error) {
+	if tx.To() == nil {
+		return nil, nil
+	}
+	var calls [][]byte
+	if *tx.To() == challenge {
+		calls = append(calls, tx.Data())
+	} else if len(tx.Data()) >= 4 {
+		method, err := validatorABI.MethodById(tx.Data()[:4])
+		if err != nil {
+			return nil, nil
+		}
+		values, err := method.Inputs.Unpack(tx.Data()[4:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		switch method.RawName {
+		case "executeTransaction":
+			if values[1].(ethcommon.Address) == challenge {
+				calls = append(calls, values[0].([]byte))
+			}
+		case "executeTransactions":
+			dests := values[1].([]ethcommon.Address)
+			for i, data := range values[0].([][]byte) {
+				if dests[i] == challenge {
+					calls = append(calls, data)
+				}
+			}
+		}
+	}
+
+	method := challengeABI.Methods["oneStepProveExecution"]
+	for _, data := range calls {
+		if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+			continue
+		}
+		values, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		accs := values[6].([2][32]byte)
+		state := values[7].([3]*big.Int)
+		return &OneStepProofCall{
+			SegmentStart:      values[2].(*big.Int),
+			SegmentLength:     values[3].(*big.Int),
+			OldEndHash:        values[4].([32]byte),
+			TotalMessagesRead: values[5].(*big.Int),
+			SendAcc:           accs[0],
+			LogAcc:            accs[1],
+			TotalGasConsumed:  state[0],
+			TotalSendCount:    state[1],
+			TotalLogCount:     state[2],
+			ExecutionProof:    values[8].([]byte),
+			BufferProof:       values[9].([]byte),
+			Prover:            values[10].(uint8),
+		}, nil
+	}
+	return nil, nil
+}
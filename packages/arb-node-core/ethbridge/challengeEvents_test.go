@@ -0,0 +1,117 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+func TestFindOneStepProof(t *testing.T) {
+	challengeAddress := common.RandAddress().ToEthAddress()
+	otherAddress := common.RandAddress().ToEthAddress()
+	oldEndHash := common.RandHash()
+	sendAcc := common.RandHash()
+	logAcc := common.RandHash()
+
+	proofData, err := challengeABI.Pack(
+		"oneStepProveExecution",
+		[][32]byte{common.RandHash()},
+		big.NewInt(3),
+		big.NewInt(100),
+		big.NewInt(1),
+		oldEndHash,
+		big.NewInt(7),
+		[2][32]byte{sendAcc, logAcc},
+		[3]*big.Int{big.NewInt(100), big.NewInt(2), big.NewInt(4)},
+		[]byte{1, 2, 3},
+		[]byte{4, 5},
+		uint8(1),
+	)
+	test.FailIfError(t, err)
+	timeoutData, err := challengeABI.Pack("timeout")
+	test.FailIfError(t, err)
+
+	checkProof := func(t *testing.T, tx *types.Transaction) {
+		t.Helper()
+		proof, err := FindOneStepProof(tx, challengeAddress)
+		test.FailIfError(t, err)
+		if proof == nil {
+			t.Fatal("proof not found")
+		}
+		if proof.SegmentStart.Cmp(big.NewInt(100)) != 0 || proof.SegmentLength.Cmp(big.NewInt(1)) != 0 {
+			t.Error("wrong segment", proof.SegmentStart, proof.SegmentLength)
+		}
+		if proof.OldEndHash != oldEndHash || proof.SendAcc != sendAcc || proof.LogAcc != logAcc {
+			t.Error("wrong hashes")
+		}
+		if proof.TotalMessagesRead.Cmp(big.NewInt(7)) != 0 ||
+			proof.TotalGasConsumed.Cmp(big.NewInt(100)) != 0 ||
+			proof.TotalSendCount.Cmp(big.NewInt(2)) != 0 ||
+			proof.TotalLogCount.Cmp(big.NewInt(4)) != 0 {
+			t.Error("wrong initial state")
+		}
+		if !bytes.Equal(proof.ExecutionProof, []byte{1, 2, 3}) || !bytes.Equal(proof.BufferProof, []byte{4, 5}) {
+			t.Error("wrong proof data")
+		}
+		if proof.Prover != 1 {
+			t.Error("wrong prover", proof.Prover)
+		}
+	}
+
+	t.Run("Direct", func(t *testing.T) {
+		checkProof(t, types.NewTransaction(0, challengeAddress, big.NewInt(0), 0, big.NewInt(0), proofData))
+	})
+
+	t.Run("Wallet", func(t *testing.T) {
+		data, err := validatorABI.Pack("executeTransaction", proofData, challengeAddress, big.NewInt(0))
+		test.FailIfError(t, err)
+		checkProof(t, types.NewTransaction(0, otherAddress, big.NewInt(0), 0, big.NewInt(0), data))
+	})
+
+	t.Run("WalletBatch", func(t *testing.T) {
+		data, err := validatorABI.Pack(
+			"executeTransactions",
+			[][]byte{timeoutData, proofData, proofData},
+			[]ethcommon.Address{challengeAddress, otherAddress, challengeAddress},
+			[]*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0)},
+		)
+		test.FailIfError(t, err)
+		checkProof(t, types.NewTransaction(0, otherAddress, big.NewInt(0), 0, big.NewInt(0), data))
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		tx := types.NewTransaction(0, challengeAddress, big.NewInt(0), 0, big.NewInt(0), timeoutData)
+		proof, err := FindOneStepProof(tx, challengeAddress)
+		test.FailIfError(t, err)
+		if proof != nil {
+			t.Error("found proof in timeout transaction")
+		}
+		proof, err = FindOneStepProof(types.NewTransaction(0, otherAddress, big.NewInt(0), 0, big.NewInt(0), proofData), challengeAddress)
+		test.FailIfError(t, err)
+		if proof != nil {
+			t.Error("found proof for another contract")
+		}
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
@@ -134,7 +135,11 @@ func (c *ChallengeWatcher) LookupBisection(ctx context.Context, challengeState c
 		return nil, errors.New("too many matching bisections")
 	}
 
-	parsedLog, err := c.con.ParseBisected(logs[0])
+	return c.parseBisection(logs[0])
+}
+
+func (c *ChallengeWatcher) parseBisection(ethLog types.Log) (*core.Bisection, error) {
+	parsedLog, err := c.con.ParseBisected(ethLog)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
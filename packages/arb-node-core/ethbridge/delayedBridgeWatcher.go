@@ -94,6 +94,20 @@ func (r *DelayedBridgeWatcher) CurrentBlockHeight(ctx context.Context) (*big.Int
 	return latestHeader.Number, nil
 }
 
+// BlockHeightByTag returns the height of the block the L1 node labels with
+// tag, such as "safe" or "finalized"
+func (r *DelayedBridgeWatcher) BlockHeightByTag(ctx context.Context, tag string) (*big.Int, error) {
+	fetcher, ok := r.client.(ethutils.BlockTagFetcher)
+	if !ok {
+		return nil, errors.New("L1 client doesn't support fetching blocks by tag")
+	}
+	info, err := fetcher.BlockInfoByTag(ctx, tag)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return info.Number.ToInt(), nil
+}
+
 func (r *DelayedBridgeWatcher) LookupMessagesInRange(ctx context.Context, from, to *big.Int) ([]*DeliveredInboxMessage, error) {
 	query := ethereum.FilterQuery{
 		BlockHash: nil,
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+// DynamicFeeConfig configures sending L1 transactions as EIP-1559 dynamic
+// fee transactions with fees derived from the L1 fee history
+type DynamicFeeConfig struct {
+	// Number of recent blocks to base the priority fee on
+	Blocks uint64
+	// Percentile of the priority fees paid in each block to use
+	RewardPercentile float64
+	// Upper limit on the max fee per gas, or nil for no limit
+	MaxFeePerGas *big.Int
+	// Upper limit on the max priority fee per gas, or nil for no limit
+	MaxPriorityFeePerGas *big.Int
+}
+
+type DynamicFees struct {
+	BaseFee              *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// SuggestDynamicFees derives fees from the L1 node's recent fee history,
+// following the same approach as wallets that support EIP-1559: the priority
+// fee is the median of the chosen percentile across recent blocks and the max
+// fee allows for the base fee doubling. It fails if the configured max fee is
+// below the next block's base fee, since a transaction with these fees
+// couldn't be included.
+func SuggestDynamicFees(ctx context.Context, client ethutils.FeeHistoryFetcher, config DynamicFeeConfig) (*DynamicFees, error) {
+	history, err := client.FeeHistory(ctx, config.Blocks, []float64{config.RewardPercentile})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get fee history")
+	}
+	// The last base fee is the one for the block after the latest
+	baseFee := history.BaseFeePerGas[len(history.BaseFeePerGas)-1].ToInt()
+
+	var rewards []*big.Int
+	for _, blockRewards := range history.Reward {
+		if len(blockRewards) > 0 && blockRewards[0] != nil {
+			rewards = append(rewards, blockRewards[0].ToInt())
+		}
+	}
+	tip := big.NewInt(0)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		tip.Set(rewards[len(rewards)/2])
+	}
+	if config.MaxPriorityFeePerGas != nil && tip.Cmp(config.MaxPriorityFeePerGas) > 0 {
+		tip.Set(config.MaxPriorityFeePerGas)
+	}
+
+	maxFee := new(big.Int).Mul(baseFee, big.NewInt(2))
+	maxFee.Add(maxFee, tip)
+	if config.MaxFeePerGas != nil && maxFee.Cmp(config.MaxFeePerGas) > 0 {
+		maxFee.Set(config.MaxFeePerGas)
+	}
+	if maxFee.Cmp(baseFee) < 0 {
+		return nil, errors.Errorf("max fee per gas %v is below the base fee %v", maxFee, baseFee)
+	}
+	if tip.Cmp(maxFee) > 0 {
+		tip.Set(maxFee)
+	}
+	return &DynamicFees{
+		BaseFee:              baseFee,
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: tip,
+	}, nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+type testFeeHistory struct {
+	baseFees []int64
+	rewards  []int64
+}
+
+func (h testFeeHistory) FeeHistory(_ context.Context, blockCount uint64, _ []float64) (*ethutils.FeeHistory, error) {
+	history := &ethutils.FeeHistory{}
+	for _, fee := range h.baseFees {
+		history.BaseFeePerGas = append(history.BaseFeePerGas, (*hexutil.Big)(big.NewInt(fee)))
+	}
+	for _, reward := range h.rewards {
+		history.Reward = append(history.Reward, []*hexutil.Big{(*hexutil.Big)(big.NewInt(reward))})
+	}
+	return history, nil
+}
+
+// feeHistoryClient is a simulated L1 client with a fixed fee history
+type feeHistoryClient struct {
+	*ethutils.SimulatedEthClient
+	testFeeHistory
+}
+
+func TestSuggestDynamicFees(t *testing.T) {
+	ctx := context.Background()
+	history := testFeeHistory{
+		baseFees: []int64{90, 95, 100},
+		rewards:  []int64{1, 7, 3},
+	}
+
+	fees, err := SuggestDynamicFees(ctx, history, DynamicFeeConfig{Blocks: 2, RewardPercentile: 50})
+	test.FailIfError(t, err)
+	if fees.MaxPriorityFeePerGas.Cmp(big.NewInt(3)) != 0 {
+		t.Error("wrong priority fee", fees.MaxPriorityFeePerGas)
+	}
+	if fees.MaxFeePerGas.Cmp(big.NewInt(203)) != 0 {
+		t.Error("wrong max fee", fees.MaxFeePerGas)
+	}
+	if fees.BaseFee.Cmp(big.NewInt(100)) != 0 {
+		t.Error("wrong base fee", fees.BaseFee)
+	}
+
+	fees, err = SuggestDynamicFees(ctx, history, DynamicFeeConfig{
+		Blocks:               2,
+		RewardPercentile:     50,
+		MaxFeePerGas:         big.NewInt(101),
+		MaxPriorityFeePerGas: big.NewInt(2),
+	})
+	test.FailIfError(t, err)
+	if fees.MaxPriorityFeePerGas.Cmp(big.NewInt(2)) != 0 {
+		t.Error("priority fee should have been capped", fees.MaxPriorityFeePerGas)
+	}
+	if fees.MaxFeePerGas.Cmp(big.NewInt(101)) != 0 {
+		t.Error("max fee should have been capped", fees.MaxFeePerGas)
+	}
+
+	_, err = SuggestDynamicFees(ctx, history, DynamicFeeConfig{
+		Blocks:           2,
+		RewardPercentile: 50,
+		MaxFeePerGas:     big.NewInt(99),
+	})
+	if err == nil {
+		t.Error("suggesting fees should fail when max fee is below the base fee")
+	}
+}
+
+func TestDynamicFeeTransactions(t *testing.T) {
+	ctx := context.Background()
+	clnt, pks := test.SimulatedBackend(t)
+	simClient := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
+	client := &feeHistoryClient{
+		SimulatedEthClient: simClient,
+		testFeeHistory:     testFeeHistory{baseFees: []int64{2e9}, rewards: []int64{1e9}},
+	}
+	auth := test.SimulatedTransactor(t, pks[0])
+	walletCreatorAddr, _, _, err := ethbridgecontracts.DeployValidatorWalletCreator(auth, client)
+	test.FailIfError(t, err)
+	client.Commit()
+	walletCreator, err := ethbridgecontracts.NewValidatorWalletCreator(walletCreatorAddr, client)
+	test.FailIfError(t, err)
+
+	transactAuth, err := NewTransactAuthAdvanced(ctx, client, auth, TransactAuthConfig{
+		DynamicFees: &DynamicFeeConfig{Blocks: 1, RewardPercentile: 50},
+	})
+	test.FailIfError(t, err)
+	tx, err := transactAuth.makeTx(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return walletCreator.CreateWallet(auth)
+	})
+	test.FailIfError(t, err)
+	if tx.Type() != types.DynamicFeeTxType {
+		t.Fatal("sent transaction of type", tx.Type(), "instead of a dynamic fee transaction")
+	}
+	if tx.GasFeeCap().Cmp(big.NewInt(5e9)) != 0 {
+		t.Error("wrong max fee", tx.GasFeeCap())
+	}
+	if tx.GasTipCap().Cmp(big.NewInt(1e9)) != 0 {
+		t.Error("wrong priority fee", tx.GasTipCap())
+	}
+	client.Commit()
+	receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+	test.FailIfError(t, err)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Error("dynamic fee transaction failed")
+	}
+}
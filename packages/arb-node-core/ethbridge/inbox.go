@@ -177,6 +177,7 @@ func AddSequencerL2BatchFromOriginCustomNonce(ctx context.Context, inbox *ethbri
 	if err != nil {
 		return nil, err
 	}
+	auth.track(tx)
 	nonce.Add(nonce, big.NewInt(1))
 	if auth.auth.Nonce.Cmp(nonce) < 0 {
 		auth.auth.Nonce.Set(nonce)
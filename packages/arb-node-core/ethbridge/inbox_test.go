@@ -32,7 +32,7 @@ import (
 
 func TestRetryable(t *testing.T) {
 	clnt, pks := test.SimulatedBackend(t)
-	auth := bind.NewKeyedTransactor(pks[0])
+	auth := test.SimulatedTransactor(t, pks[0])
 	bridgeAddress, _, bridge, err := ethbridgecontracts.DeployBridge(auth, clnt)
 	test.FailIfError(t, err)
 	inboxAddress, _, inbox, err := ethbridgecontracts.DeployInbox(auth, clnt)
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+// plannedABIs are the contracts the validator sends calls to, used to decode
+// planned calls for display
+var plannedABIs []abi.ABI
+
+func init() {
+	for _, def := range []string{
+		ethbridgecontracts.RollupUserFacetABI,
+		ethbridgecontracts.ChallengeABI,
+		ethbridgecontracts.ValidatorABI,
+	} {
+		parsed, err := abi.JSON(strings.NewReader(def))
+		if err != nil {
+			panic(err)
+		}
+		plannedABIs = append(plannedABIs, parsed)
+	}
+}
+
+type PlannedArg struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PlannedCall is a contract call the validator intends to make
+type PlannedCall struct {
+	To     ethcommon.Address `json:"to"`
+	Method string            `json:"method"`
+	Args   []PlannedArg      `json:"args"`
+	Value  *big.Int          `json:"value"`
+	// Estimated gas attributable to this call, 0 if it couldn't be estimated
+	Gas uint64 `json:"gas"`
+}
+
+// Plan is the set of calls the validator would send through its wallet in a
+// single transaction
+type Plan struct {
+	Wallet ethcommon.Address `json:"wallet"`
+	Calls  []*PlannedCall    `json:"calls"`
+	// Estimated gas for the whole wallet transaction
+	Gas uint64 `json:"gas"`
+}
+
+func (p *Plan) String() string {
+	if len(p.Calls) == 0 {
+		return "no calls planned"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v calls through wallet %v, estimated gas %v\n", len(p.Calls), p.Wallet, p.Gas)
+	for i, call := range p.Calls {
+		fmt.Fprintf(&sb, "%v. %v on %v", i+1, call.Method, call.To)
+		if call.Value != nil && call.Value.Sign() != 0 {
+			fmt.Fprintf(&sb, " with value %v", call.Value)
+		}
+		fmt.Fprintf(&sb, ", estimated gas %v\n", call.Gas)
+		for _, arg := range call.Args {
+			fmt.Fprintf(&sb, "     %v: %v\n", arg.Name, arg.Value)
+		}
+	}
+	return sb.String()
+}
+
+// decodeCall looks up the called method in the known contract ABIs, falling
+// back to the raw calldata if it isn't found
+func decodeCall(to ethcommon.Address, data []byte, value *big.Int) *PlannedCall {
+	call := &PlannedCall{
+		To:    to,
+		Value: value,
+	}
+	if len(data) >= 4 {
+		for _, contractABI := range plannedABIs {
+			method, err := contractABI.MethodById(data[:4])
+			if err != nil {
+				continue
+			}
+			values, err := method.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			call.Method = method.RawName
+			for i, input := range method.Inputs {
+				call.Args = append(call.Args, PlannedArg{
+					Name:  input.Name,
+					Value: formatArg(values[i]),
+				})
+			}
+			return call
+		}
+	}
+	call.Method = "unknown"
+	call.Args = []PlannedArg{{Name: "data", Value: hexutil.Encode(data)}}
+	return call
+}
+
+func formatArg(val interface{}) string {
+	switch val := val.(type) {
+	case ethcommon.Address:
+		return val.Hex()
+	case *big.Int:
+		return val.String()
+	case []byte:
+		return hexutil.Encode(val)
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(data), rv)
+			return hexutil.Encode(data)
+		}
+		items := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, formatArg(rv.Index(i).Interface()))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(val)
+}
+
+// PlanTransactions describes the transaction ExecuteTransactions would send
+// for the calls currently in the builder without sending it
+func (v *ValidatorWallet) PlanTransactions(builder *BuilderBackend) *Plan {
+	plan := &Plan{Wallet: v.address}
+	var prevGas uint64
+	for i, tx := range builder.transactions {
+		call := decodeCall(*tx.To(), tx.Data(), tx.Value())
+		// The builder estimates the wallet transaction each time a call is
+		// added, so each call's cost is the increase over the previous estimate
+		if i < len(builder.gasEstimates) && builder.gasEstimates[i] > prevGas {
+			call.Gas = builder.gasEstimates[i] - prevGas
+			prevGas = builder.gasEstimates[i]
+		}
+		plan.Calls = append(plan.Calls, call)
+	}
+	plan.Gas = prevGas
+	return plan
+}
+
+// PlanReturnOldDeposits describes the transaction ReturnOldDeposits would send
+func (v *ValidatorWallet) PlanReturnOldDeposits(ctx context.Context, stakers []common.Address) (*Plan, error) {
+	data, err := validatorABI.Pack("returnOldDeposits", v.rollupAddress, common.AddressArrayToEth(stakers))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return v.planWalletCall(ctx, data)
+}
+
+// PlanTimeoutChallenges describes the transaction TimeoutChallenges would send
+func (v *ValidatorWallet) PlanTimeoutChallenges(ctx context.Context, challenges []common.Address) (*Plan, error) {
+	data, err := validatorABI.Pack("timeoutChallenges", common.AddressArrayToEth(challenges))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return v.planWalletCall(ctx, data)
+}
+
+func (v *ValidatorWallet) planWalletCall(ctx context.Context, data []byte) (*Plan, error) {
+	gas, err := v.client.EstimateGas(ctx, ethereum.CallMsg{
+		From: v.auth.auth.From,
+		To:   &v.address,
+		Data: data,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error estimating gas")
+	}
+	call := decodeCall(v.address, data, big.NewInt(0))
+	call.Gas = gas
+	return &Plan{
+		Wallet: v.address,
+		Calls:  []*PlannedCall{call},
+		Gas:    gas,
+	}, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+func TestPlanTransactions(t *testing.T) {
+	rollupABI, err := abi.JSON(strings.NewReader(ethbridgecontracts.RollupUserFacetABI))
+	test.FailIfError(t, err)
+	challengeABI, err := abi.JSON(strings.NewReader(ethbridgecontracts.ChallengeABI))
+	test.FailIfError(t, err)
+
+	rollupAddress := common.RandAddress().ToEthAddress()
+	challengeAddress := common.RandAddress().ToEthAddress()
+	staker := common.RandAddress().ToEthAddress()
+	nodeHash := common.RandHash()
+
+	stakeData, err := rollupABI.Pack("stakeOnExistingNode", big.NewInt(5), nodeHash)
+	test.FailIfError(t, err)
+	rejectData, err := rollupABI.Pack("rejectNextNode", staker)
+	test.FailIfError(t, err)
+	timeoutData, err := challengeABI.Pack("timeout")
+	test.FailIfError(t, err)
+
+	builder := &BuilderBackend{
+		transactions: []*types.Transaction{
+			types.NewTransaction(0, rollupAddress, big.NewInt(10), 0, big.NewInt(0), stakeData),
+			types.NewTransaction(0, rollupAddress, big.NewInt(0), 0, big.NewInt(0), rejectData),
+			types.NewTransaction(0, challengeAddress, big.NewInt(0), 0, big.NewInt(0), timeoutData),
+			types.NewTransaction(0, challengeAddress, big.NewInt(0), 0, big.NewInt(0), []byte{1, 2, 3}),
+		},
+		gasEstimates: []uint64{100000, 150000, 170000, 180000},
+	}
+	wallet := &ValidatorWallet{address: common.RandAddress().ToEthAddress()}
+	plan := wallet.PlanTransactions(builder)
+
+	if plan.Wallet != wallet.address {
+		t.Error("wrong wallet address", plan.Wallet)
+	}
+	if plan.Gas != 180000 {
+		t.Error("wrong total gas", plan.Gas)
+	}
+	if len(plan.Calls) != 4 {
+		t.Fatal("wrong call count", len(plan.Calls))
+	}
+
+	expectedMethods := []string{"stakeOnExistingNode", "rejectNextNode", "timeout", "unknown"}
+	expectedGas := []uint64{100000, 50000, 20000, 10000}
+	for i, call := range plan.Calls {
+		if call.Method != expectedMethods[i] {
+			t.Error("call", i, "has method", call.Method, "instead of", expectedMethods[i])
+		}
+		if call.Gas != expectedGas[i] {
+			t.Error("call", i, "has gas", call.Gas, "instead of", expectedGas[i])
+		}
+	}
+
+	stakeCall := plan.Calls[0]
+	if stakeCall.To != rollupAddress || stakeCall.Value.Cmp(big.NewInt(10)) != 0 {
+		t.Error("wrong stake call target", stakeCall.To, stakeCall.Value)
+	}
+	if len(stakeCall.Args) != 2 || stakeCall.Args[0].Value != "5" || stakeCall.Args[1].Value != nodeHash.String() {
+		t.Error("wrong stake call args", stakeCall.Args)
+	}
+	if plan.Calls[1].Args[0].Value != staker.Hex() {
+		t.Error("wrong reject call args", plan.Calls[1].Args)
+	}
+	if plan.Calls[3].Args[0].Value != "0x010203" {
+		t.Error("wrong unknown call args", plan.Calls[3].Args)
+	}
+}
+
+func TestFormatArg(t *testing.T) {
+	hashes := [][32]byte{{1}, {2}}
+	formatted := formatArg(hashes)
+	expected := "[" + ethcommon.Hash(hashes[0]).Hex() + ", " + ethcommon.Hash(hashes[1]).Hex() + "]"
+	if formatted != expected {
+		t.Error("got", formatted, "instead of", expected)
+	}
+	if formatArg([2]*big.Int{big.NewInt(1), big.NewInt(2)}) != "[1, 2]" {
+		t.Error("wrong int array formatting")
+	}
+}
@@ -0,0 +1,468 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+// Nodes won't accept a replacement transaction unless its gas price, or both
+// its fee caps for dynamic fee transactions, are at least this much higher
+// than the one it replaces
+const minBumpPercent = 10
+
+var errNonceUsed = errors.New("transaction nonce was used by another transaction")
+
+type ResubmitConfig struct {
+	// Number of L1 blocks to wait for inclusion before bumping the gas price
+	BlockDelay uint64
+	// Percentage the gas price, or both fee caps of dynamic fee transactions,
+	// are increased by on each resubmission
+	BumpPercent int64
+	// Gas price or max fee per gas that resubmissions won't go above, or nil
+	// for no limit
+	MaxGasPrice *big.Int
+	// Number of L1 blocks the outcome of a finished transaction is remembered
+	// for after it is seen
+	ConfirmationDepth uint64
+	// Directory the pending transactions are persisted in
+	StateDir string
+}
+
+type pendingTx struct {
+	// All versions of the transaction that have been broadcast, in order
+	Attempts []*types.Transaction `json:"attempts"`
+	// Gas price, or max fee per gas for dynamic fee transactions, of the most
+	// recent replacement that was tried
+	GasPrice *big.Int `json:"gasPrice"`
+	// Max priority fee per gas of the most recent replacement that was tried,
+	// which is the gas price for other transaction types
+	GasTipCap *big.Int `json:"gasTipCap,omitempty"`
+	// L1 block at which the latest attempt was broadcast
+	SentBlock uint64 `json:"sentBlock"`
+}
+
+func (p *pendingTx) original() *types.Transaction {
+	return p.Attempts[0]
+}
+
+func (p *pendingTx) latest() *types.Transaction {
+	return p.Attempts[len(p.Attempts)-1]
+}
+
+func (p *pendingTx) tipCap() *big.Int {
+	if p.GasTipCap == nil {
+		// Saved before tip caps were tracked
+		return p.latest().GasTipCap()
+	}
+	return p.GasTipCap
+}
+
+type finishedTx struct {
+	hash ethcommon.Hash
+	err  error
+	// L1 block at which the transaction was seen to be finished
+	block uint64
+}
+
+// Resubmitter tracks transactions sent by a single account and replaces
+// them with higher gas price versions if they aren't included quickly enough
+type Resubmitter struct {
+	client    ethutils.EthClient
+	auth      *bind.TransactOpts
+	config    ResubmitConfig
+	statePath string
+
+	mutex    sync.Mutex
+	pending  map[ethcommon.Hash]*pendingTx
+	finished map[ethcommon.Hash]finishedTx
+}
+
+func NewResubmitter(client ethutils.EthClient, auth *bind.TransactOpts, config ResubmitConfig) (*Resubmitter, error) {
+	if config.BumpPercent < minBumpPercent {
+		return nil, errors.Errorf("gas price bump must be at least %v percent", minBumpPercent)
+	}
+	if config.BlockDelay == 0 {
+		return nil, errors.New("resubmission block delay must be greater than 0")
+	}
+	r := &Resubmitter{
+		client:    client,
+		auth:      auth,
+		config:    config,
+		statePath: path.Join(config.StateDir, "pending_txs_"+auth.From.Hex()+".json"),
+		pending:   make(map[ethcommon.Hash]*pendingTx),
+		finished:  make(map[ethcommon.Hash]finishedTx),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Resubmitter) load() error {
+	data, err := ioutil.ReadFile(r.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to read pending transactions")
+	}
+	var pending []*pendingTx
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return errors.Wrap(err, "failed to unmarshal pending transactions")
+	}
+	for _, p := range pending {
+		if len(p.Attempts) == 0 {
+			continue
+		}
+		logger.Info().
+			Hex("tx", p.original().Hash().Bytes()).
+			Uint64("nonce", p.original().Nonce()).
+			Msg("resuming tracking of pending transaction")
+		r.pending[p.original().Hash()] = p
+	}
+	return nil
+}
+
+// save expects the mutex to be held
+func (r *Resubmitter) save() error {
+	pending := make([]*pendingTx, 0, len(r.pending))
+	for _, p := range r.pending {
+		pending = append(pending, p)
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pending transactions")
+	}
+	tmpPath := r.statePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write pending transactions")
+	}
+	return errors.Wrap(os.Rename(tmpPath, r.statePath), "failed to write pending transactions")
+}
+
+// Track starts watching the given transaction, which must have already been
+// broadcast
+func (r *Resubmitter) Track(tx *types.Transaction) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pending[tx.Hash()] = &pendingTx{
+		Attempts:  []*types.Transaction{tx},
+		GasPrice:  tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+	}
+	return r.save()
+}
+
+// PendingCount returns the number of transactions which haven't been
+// included yet
+func (r *Resubmitter) PendingCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.pending)
+}
+
+// TransactionReceipt returns the receipt of the given transaction or of the
+// replacement that was included in its place
+func (r *Resubmitter) TransactionReceipt(ctx context.Context, txHash ethcommon.Hash) (*types.Receipt, error) {
+	hashes := []ethcommon.Hash{txHash}
+	r.mutex.Lock()
+	if p, ok := r.pending[txHash]; ok {
+		hashes = make([]ethcommon.Hash, 0, len(p.Attempts))
+		for _, tx := range p.Attempts {
+			hashes = append(hashes, tx.Hash())
+		}
+	} else if finished, ok := r.finished[txHash]; ok {
+		if finished.err != nil {
+			r.mutex.Unlock()
+			return nil, finished.err
+		}
+		hashes[0] = finished.hash
+	}
+	r.mutex.Unlock()
+
+	receipt, err := r.findReceipt(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (r *Resubmitter) findReceipt(ctx context.Context, hashes []ethcommon.Hash) (*types.Receipt, error) {
+	for _, hash := range hashes {
+		receipt, err := r.client.TransactionReceipt(ctx, hash)
+		if err != nil {
+			if err.Error() == ethereum.NotFound.Error() {
+				continue
+			}
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+	}
+	return nil, nil
+}
+
+// Start checks on the pending transactions every time a new L1 block is seen
+// until the context is cancelled
+func (r *Resubmitter) Start(ctx context.Context) {
+	go func() {
+		for {
+			if err := r.update(ctx); err != nil {
+				logger.Warn().Err(err).Msg("error checking on pending transactions")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+}
+
+func (r *Resubmitter) update(ctx context.Context) error {
+	r.mutex.Lock()
+	pending := make([]*pendingTx, 0, len(r.pending))
+	for _, p := range r.pending {
+		pending = append(pending, p)
+	}
+	finishedCount := len(r.finished)
+	r.mutex.Unlock()
+	if len(pending) == 0 && finishedCount == 0 {
+		return nil
+	}
+
+	header, err := r.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to get latest block")
+	}
+	currentBlock := header.Number.Uint64()
+	r.pruneFinished(currentBlock)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Fetch the nonce before receipts so that a used nonce always has a
+	// receipt visible if it was used by one of our transactions
+	confirmedNonce, err := r.client.NonceAt(ctx, r.auth.From, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to get nonce")
+	}
+
+	for _, p := range pending {
+		// Keep going so that one transaction can't hold up the others
+		if err := r.updateTx(ctx, p, currentBlock, confirmedNonce); err != nil {
+			logger.Warn().Err(err).Hex("tx", p.original().Hash().Bytes()).Msg("error checking on pending transaction")
+		}
+	}
+	return nil
+}
+
+// pruneFinished forgets transactions that finished more than the
+// confirmation depth before the given block
+func (r *Resubmitter) pruneFinished(currentBlock uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for hash, finished := range r.finished {
+		if currentBlock >= finished.block+r.config.ConfirmationDepth {
+			delete(r.finished, hash)
+		}
+	}
+}
+
+func (r *Resubmitter) updateTx(ctx context.Context, p *pendingTx, currentBlock uint64, confirmedNonce uint64) error {
+	r.mutex.Lock()
+	hashes := make([]ethcommon.Hash, 0, len(p.Attempts))
+	for _, tx := range p.Attempts {
+		hashes = append(hashes, tx.Hash())
+	}
+	r.mutex.Unlock()
+
+	receipt, err := r.findReceipt(ctx, hashes)
+	if err != nil {
+		return err
+	}
+	if receipt != nil {
+		logger.Info().
+			Hex("tx", p.original().Hash().Bytes()).
+			Hex("included", receipt.TxHash.Bytes()).
+			Int("attempts", len(p.Attempts)).
+			Msg("pending transaction was included")
+		return r.finish(p, finishedTx{hash: receipt.TxHash, block: currentBlock})
+	}
+	if confirmedNonce > p.original().Nonce() {
+		logger.Error().
+			Hex("tx", p.original().Hash().Bytes()).
+			Uint64("nonce", p.original().Nonce()).
+			Msg("transaction nonce was used by another transaction")
+		return r.finish(p, finishedTx{err: errNonceUsed, block: currentBlock})
+	}
+
+	tx, err := r.prepareReplacement(p, currentBlock)
+	if err != nil || tx == nil {
+		return err
+	}
+
+	// Don't hold the lock while waiting on the L1 node
+	sendErr := r.client.SendTransaction(ctx, tx)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if sendErr != nil {
+		if strings.Contains(sendErr.Error(), "underpriced") {
+			// Try again with a higher price next time
+			p.GasPrice = tx.GasFeeCap()
+			p.GasTipCap = tx.GasTipCap()
+			return r.save()
+		}
+		logger.Warn().Err(sendErr).Hex("tx", tx.Hash().Bytes()).Msg("failed to resubmit transaction")
+		return nil
+	}
+	logger.Info().
+		Hex("tx", p.original().Hash().Bytes()).
+		Hex("replacement", tx.Hash().Bytes()).
+		Uint64("nonce", tx.Nonce()).
+		Str("gasPrice", tx.GasFeeCap().String()).
+		Str("gasTipCap", tx.GasTipCap().String()).
+		Msg("resubmitted transaction with higher gas price")
+	p.Attempts = append(p.Attempts, tx)
+	p.GasPrice = tx.GasFeeCap()
+	p.GasTipCap = tx.GasTipCap()
+	p.SentBlock = currentBlock
+	return r.save()
+}
+
+// prepareReplacement returns the signed replacement for p, or nil if p
+// shouldn't be resubmitted yet
+func (r *Resubmitter) prepareReplacement(p *pendingTx, currentBlock uint64) (*types.Transaction, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p.SentBlock == 0 {
+		p.SentBlock = currentBlock
+		return nil, r.save()
+	}
+	if currentBlock < p.SentBlock+r.config.BlockDelay {
+		return nil, nil
+	}
+	gasPrice, ok := r.bumpGasPrice(p.GasPrice)
+	if !ok {
+		return nil, nil
+	}
+	tipCap := r.bumpTipCap(p.tipCap(), gasPrice)
+	return r.replaceTransaction(p.latest(), gasPrice, tipCap)
+}
+
+func (r *Resubmitter) finish(p *pendingTx, result finishedTx) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.pending, p.original().Hash())
+	r.finished[p.original().Hash()] = result
+	return r.save()
+}
+
+// bumpGasPrice returns the price to resubmit with or false if the maximum has
+// already been reached
+func (r *Resubmitter) bumpGasPrice(gasPrice *big.Int) (*big.Int, bool) {
+	maxGasPrice := r.config.MaxGasPrice
+	if maxGasPrice != nil && gasPrice.Cmp(maxGasPrice) >= 0 {
+		return nil, false
+	}
+	bumped := new(big.Int).Mul(gasPrice, big.NewInt(100+r.config.BumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(gasPrice) <= 0 {
+		bumped.Add(gasPrice, big.NewInt(1))
+	}
+	if maxGasPrice != nil && bumped.Cmp(maxGasPrice) > 0 {
+		bumped.Set(maxGasPrice)
+	}
+	return bumped, true
+}
+
+// bumpTipCap returns the priority fee to resubmit a dynamic fee transaction
+// with, which can't be above its new max fee
+func (r *Resubmitter) bumpTipCap(tipCap *big.Int, feeCap *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(tipCap, big.NewInt(100+r.config.BumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(tipCap) <= 0 {
+		bumped.Add(tipCap, big.NewInt(1))
+	}
+	if bumped.Cmp(feeCap) > 0 {
+		bumped.Set(feeCap)
+	}
+	return bumped
+}
+
+func (r *Resubmitter) replaceTransaction(tx *types.Transaction, gasPrice *big.Int, tipCap *big.Int) (*types.Transaction, error) {
+	var data types.TxData
+	switch tx.Type() {
+	case types.LegacyTxType:
+		data = &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: gasPrice,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}
+	case types.AccessListTxType:
+		data = &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   gasPrice,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	case types.DynamicFeeTxType:
+		data = &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tipCap,
+			GasFeeCap:  gasPrice,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	default:
+		return nil, errors.Errorf("can't replace transaction of type %v", tx.Type())
+	}
+	return r.auth.Signer(r.auth.From, types.NewTx(data))
+}
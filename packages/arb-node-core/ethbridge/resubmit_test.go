@@ -0,0 +1,274 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethbridge
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+// resubmitTestClient is an L1 client that never includes transactions until
+// told to
+type resubmitTestClient struct {
+	ethutils.EthClient
+	blockNumber int64
+	nonce       uint64
+	sent        []*types.Transaction
+	included    map[ethcommon.Hash]bool
+	failing     map[ethcommon.Hash]bool
+}
+
+func (c *resubmitTestClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(c.blockNumber)}, nil
+}
+
+func (c *resubmitTestClient) NonceAt(context.Context, ethcommon.Address, *big.Int) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *resubmitTestClient) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *resubmitTestClient) TransactionReceipt(_ context.Context, txHash ethcommon.Hash) (*types.Receipt, error) {
+	if c.failing[txHash] {
+		return nil, errors.New("receipt lookup failed")
+	}
+	if !c.included[txHash] {
+		return nil, ethereum.NotFound
+	}
+	return &types.Receipt{TxHash: txHash, Status: 1}, nil
+}
+
+func TestResubmitter(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	auth := bind.NewKeyedTransactor(pk)
+
+	stateDir, err := ioutil.TempDir("", "resubmit")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	client := &resubmitTestClient{blockNumber: 100, included: make(map[ethcommon.Hash]bool)}
+	config := ResubmitConfig{
+		BlockDelay:        2,
+		BumpPercent:       50,
+		MaxGasPrice:       big.NewInt(200),
+		ConfirmationDepth: 3,
+		StateDir:          stateDir,
+	}
+	resubmitter, err := NewResubmitter(client, auth, config)
+	test.FailIfError(t, err)
+
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, ethcommon.Address{}, big.NewInt(0), 21000, big.NewInt(100), nil))
+	test.FailIfError(t, err)
+	test.FailIfError(t, resubmitter.Track(tx))
+
+	// First update records the block the transaction was seen at
+	test.FailIfError(t, resubmitter.update(ctx))
+	client.blockNumber++
+	test.FailIfError(t, resubmitter.update(ctx))
+	if len(client.sent) != 0 {
+		t.Fatal("resubmitted transaction too early")
+	}
+
+	client.blockNumber++
+	test.FailIfError(t, resubmitter.update(ctx))
+	if len(client.sent) != 1 {
+		t.Fatal("expected transaction to be resubmitted")
+	}
+	replacement := client.sent[0]
+	if replacement.Nonce() != tx.Nonce() {
+		t.Error("replacement should reuse the nonce")
+	}
+	if replacement.GasPrice().Cmp(big.NewInt(150)) != 0 {
+		t.Error("wrong replacement gas price", replacement.GasPrice())
+	}
+
+	// Gas price bump should stop at the maximum
+	for i := 0; i < 10; i++ {
+		client.blockNumber += 2
+		test.FailIfError(t, resubmitter.update(ctx))
+	}
+	if len(client.sent) != 2 {
+		t.Fatal("expected exactly one more resubmission but got", len(client.sent)-1)
+	}
+	if client.sent[1].GasPrice().Cmp(config.MaxGasPrice) != 0 {
+		t.Error("gas price should have been capped", client.sent[1].GasPrice())
+	}
+
+	// A restart should resume tracking the transaction
+	restarted, err := NewResubmitter(client, auth, config)
+	test.FailIfError(t, err)
+	if restarted.PendingCount() != 1 {
+		t.Fatal("pending transaction wasn't persisted")
+	}
+
+	client.included[client.sent[0].Hash()] = true
+	client.nonce = 1
+	test.FailIfError(t, restarted.update(ctx))
+	if restarted.PendingCount() != 0 {
+		t.Error("included transaction should no longer be pending")
+	}
+	receipt, err := restarted.TransactionReceipt(ctx, tx.Hash())
+	test.FailIfError(t, err)
+	if receipt.TxHash != client.sent[0].Hash() {
+		t.Error("receipt should be for the included replacement")
+	}
+
+	// The outcome is forgotten once it's past the confirmation depth
+	client.blockNumber += 2
+	test.FailIfError(t, restarted.update(ctx))
+	if len(restarted.finished) != 1 {
+		t.Error("finished transaction forgotten too early")
+	}
+	client.blockNumber++
+	test.FailIfError(t, restarted.update(ctx))
+	if len(restarted.finished) != 0 {
+		t.Error("finished transaction wasn't pruned")
+	}
+}
+
+func TestResubmitterNonceUsed(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	auth := bind.NewKeyedTransactor(pk)
+
+	stateDir, err := ioutil.TempDir("", "resubmit")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	client := &resubmitTestClient{blockNumber: 100, included: make(map[ethcommon.Hash]bool)}
+	resubmitter, err := NewResubmitter(client, auth, ResubmitConfig{BlockDelay: 1, BumpPercent: 10, StateDir: stateDir})
+	test.FailIfError(t, err)
+
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, ethcommon.Address{}, big.NewInt(0), 21000, big.NewInt(100), nil))
+	test.FailIfError(t, err)
+	test.FailIfError(t, resubmitter.Track(tx))
+
+	client.nonce = 1
+	test.FailIfError(t, resubmitter.update(ctx))
+	if _, err := resubmitter.TransactionReceipt(ctx, tx.Hash()); err != errNonceUsed {
+		t.Error("expected nonce used error but got", err)
+	}
+}
+
+func TestResubmitterReceiptError(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	auth := bind.NewKeyedTransactor(pk)
+
+	stateDir, err := ioutil.TempDir("", "resubmit")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	client := &resubmitTestClient{
+		blockNumber: 100,
+		included:    make(map[ethcommon.Hash]bool),
+		failing:     make(map[ethcommon.Hash]bool),
+	}
+	resubmitter, err := NewResubmitter(client, auth, ResubmitConfig{BlockDelay: 1, BumpPercent: 10, StateDir: stateDir})
+	test.FailIfError(t, err)
+
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx, err := auth.Signer(auth.From, types.NewTransaction(nonce, ethcommon.Address{}, big.NewInt(0), 21000, big.NewInt(100), nil))
+		test.FailIfError(t, err)
+		test.FailIfError(t, resubmitter.Track(tx))
+		if nonce == 0 {
+			client.failing[tx.Hash()] = true
+		}
+	}
+
+	test.FailIfError(t, resubmitter.update(ctx))
+	client.blockNumber++
+	test.FailIfError(t, resubmitter.update(ctx))
+	if len(client.sent) != 1 || client.sent[0].Nonce() != 1 {
+		t.Fatal("failed receipt lookup blocked resubmitting other transactions")
+	}
+}
+
+func TestResubmitterDynamicFees(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	chainId := big.NewInt(1337)
+	auth, err := bind.NewKeyedTransactorWithChainID(pk, chainId)
+	test.FailIfError(t, err)
+
+	stateDir, err := ioutil.TempDir("", "resubmit")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	client := &resubmitTestClient{blockNumber: 100, included: make(map[ethcommon.Hash]bool)}
+	config := ResubmitConfig{BlockDelay: 1, BumpPercent: 50, MaxGasPrice: big.NewInt(250), StateDir: stateDir}
+	resubmitter, err := NewResubmitter(client, auth, config)
+	test.FailIfError(t, err)
+
+	tx, err := auth.Signer(auth.From, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     0,
+		GasTipCap: big.NewInt(80),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &ethcommon.Address{},
+		Value:     big.NewInt(0),
+	}))
+	test.FailIfError(t, err)
+	test.FailIfError(t, resubmitter.Track(tx))
+
+	for i := 0; i < 6; i++ {
+		test.FailIfError(t, resubmitter.update(ctx))
+		client.blockNumber++
+	}
+	if len(client.sent) != 3 {
+		t.Fatal("expected 3 resubmissions but got", len(client.sent))
+	}
+	expectedCaps := [][2]int64{{150, 120}, {225, 180}, {250, 250}}
+	for i, replacement := range client.sent {
+		if replacement.Type() != types.DynamicFeeTxType || replacement.Nonce() != tx.Nonce() {
+			t.Fatal("replacement should be a dynamic fee transaction with the same nonce")
+		}
+		// The max fee stops at the maximum and the priority fee can't go
+		// above the max fee
+		if replacement.GasFeeCap().Int64() != expectedCaps[i][0] || replacement.GasTipCap().Int64() != expectedCaps[i][1] {
+			t.Error("wrong fee caps for replacement", i, replacement.GasFeeCap(), replacement.GasTipCap())
+		}
+	}
+	last := client.sent[len(client.sent)-1]
+	sender, err := types.Sender(types.LatestSignerForChainID(chainId), last)
+	test.FailIfError(t, err)
+	if sender != auth.From {
+		t.Error("replacement signed by wrong account")
+	}
+}
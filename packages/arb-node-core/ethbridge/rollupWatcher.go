@@ -286,6 +286,11 @@ func (r *RollupWatcher) CurrentRequiredStake(ctx context.Context) (*big.Int, err
 	return stake, errors.WithStack(err)
 }
 
+func (r *RollupWatcher) WithdrawableFunds(ctx context.Context, owner common.Address) (*big.Int, error) {
+	funds, err := r.con.WithdrawableFunds(&bind.CallOpts{Context: ctx}, owner.ToEthAddress())
+	return funds, errors.WithStack(err)
+}
+
 func (r *RollupWatcher) BaseStake(ctx context.Context) (*big.Int, error) {
 	stake, err := r.con.BaseStake(&bind.CallOpts{Context: ctx})
 	return stake, errors.WithStack(err)
@@ -79,6 +79,12 @@ func (v *ValidatorWallet) From() common.Address {
 	return common.NewAddressFromEth(v.auth.auth.From)
 }
 
+// WaitForReceipt waits until a transaction sent by the wallet owner, or its
+// replacement, is included
+func (v *ValidatorWallet) WaitForReceipt(ctx context.Context, tx *types.Transaction, methodName string) (*types.Receipt, error) {
+	return v.auth.WaitForReceipt(ctx, tx, methodName)
+}
+
 func (v *ValidatorWallet) RollupAddress() common.Address {
 	return common.NewAddressFromEth(v.rollupAddress)
 }
@@ -116,7 +122,7 @@ func (v *ValidatorWallet) ExecuteTransactions(ctx context.Context, builder *Buil
 		if err != nil {
 			return nil, err
 		}
-		builder.transactions = nil
+		builder.ClearTransactions()
 		return tx, nil
 	}
 
@@ -139,7 +145,7 @@ func (v *ValidatorWallet) ExecuteTransactions(ctx context.Context, builder *Buil
 	if err != nil {
 		return nil, err
 	}
-	builder.transactions = nil
+	builder.ClearTransactions()
 	return tx, nil
 }
 
@@ -192,7 +198,7 @@ func CreateValidatorWallet(ctx context.Context, validatorWalletFactoryAddr ethco
 		simulatedBackend.Commit()
 	}
 
-	receipt, err := WaitForReceiptWithResults(ctx, client, auth.auth.From, tx, "CreateWallet")
+	receipt, err := auth.WaitForReceipt(ctx, tx, "CreateWallet")
 	if err != nil {
 		return ethcommon.Address{}, err
 	}
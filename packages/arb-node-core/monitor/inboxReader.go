@@ -62,12 +62,40 @@ var (
 
 const RECENT_FEED_ITEM_TTL time.Duration = time.Second * 10
 
+// InboxReaderConfig controls how far behind the L1 head messages are read.
+// By default the reader stays a few blocks behind the head and relies on
+// reorg detection to unwind anything that changes. Setting a confirmation
+// depth or finality tag instead only delivers messages from L1 blocks that
+// are considered final, leaving the sequencer feed to provide newer data.
+type InboxReaderConfig struct {
+	// Number of blocks behind the L1 head to read up to
+	ConfirmationDepth int64
+	// Read up to the L1 block with this tag, either "safe" or "finalized".
+	// Takes precedence over ConfirmationDepth.
+	Finality string
+}
+
+func (c InboxReaderConfig) confirmed() bool {
+	return c.ConfirmationDepth > 0 || c.Finality != ""
+}
+
+func (c InboxReaderConfig) Validate() error {
+	if c.ConfirmationDepth < 0 {
+		return errors.New("inbox reader confirmation depth can't be negative")
+	}
+	if c.Finality != "" && c.Finality != "safe" && c.Finality != "finalized" {
+		return errors.Errorf("unsupported inbox reader finality %v, must be safe or finalized", c.Finality)
+	}
+	return nil
+}
+
 type InboxReader struct {
 	// Only in run thread
 	delayedBridge      *ethbridge.DelayedBridgeWatcher
 	sequencerInbox     *ethbridge.SequencerInboxWatcher
 	bridgeUtils        *ethbridge.BridgeUtils
 	db                 core.ArbCore
+	config             InboxReaderConfig
 	firstMessageBlock  *big.Int
 	caughtUp           bool
 	caughtUpTarget     *big.Int
@@ -94,9 +122,13 @@ func NewInboxReader(
 	sequencerInbox *ethbridge.SequencerInboxWatcher,
 	bridgeUtils *ethbridge.BridgeUtils,
 	db core.ArbCore,
+	config InboxReaderConfig,
 	healthChan chan nodehealth.Log,
 	broadcastFeed chan broadcaster.BroadcastFeedMessage,
 ) (*InboxReader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	firstMessageBlock, err := bridge.LookupMessageBlock(ctx, big.NewInt(0))
 	if err != nil {
 		return nil, err
@@ -106,6 +138,7 @@ func NewInboxReader(
 		sequencerInbox:    sequencerInbox,
 		bridgeUtils:       bridgeUtils,
 		db:                db,
+		config:            config,
 		firstMessageBlock: firstMessageBlock.Height.AsInt(),
 		recentFeedItems:   make(map[common.Hash]time.Time),
 		completed:         make(chan bool, 1),
@@ -173,7 +206,14 @@ func (ir *InboxReader) getMessages(ctx context.Context) error {
 		default:
 		}
 
-		currentHeight, err := ir.delayedBridge.CurrentBlockHeight(ctx)
+		var currentHeight *big.Int
+		var countsBlock *big.Int
+		if ir.config.confirmed() {
+			currentHeight, err = ir.confirmedBlockHeight(ctx)
+			countsBlock = currentHeight
+		} else {
+			currentHeight, err = ir.delayedBridge.CurrentBlockHeight(ctx)
+		}
 		if err != nil {
 			return err
 		}
@@ -181,7 +221,7 @@ func (ir *InboxReader) getMessages(ctx context.Context) error {
 		reorgingDelayed := false
 		reorgingSequencer := false
 		if ir.caughtUp {
-			latestDelayed, latestSeq, err := ir.bridgeUtils.GetCountsAndAccumulators(ctx)
+			latestDelayed, latestSeq, err := ir.bridgeUtils.GetCountsAndAccumulators(ctx, countsBlock)
 			if err != nil {
 				return err
 			}
@@ -199,7 +239,7 @@ func (ir *InboxReader) getMessages(ctx context.Context) error {
 			}
 		}
 
-		if !reorgingDelayed && !reorgingSequencer && inboxReaderDelay > 0 {
+		if !ir.config.confirmed() && !reorgingDelayed && !reorgingSequencer && inboxReaderDelay > 0 {
 			currentHeight = new(big.Int).Sub(currentHeight, big.NewInt(inboxReaderDelay))
 			if currentHeight.Sign() <= 0 {
 				currentHeight = currentHeight.SetInt64(1)
@@ -399,6 +439,22 @@ func (ir *InboxReader) getMessages(ctx context.Context) error {
 	}
 }
 
+// confirmedBlockHeight returns the newest L1 block whose messages may be delivered
+func (ir *InboxReader) confirmedBlockHeight(ctx context.Context) (*big.Int, error) {
+	if ir.config.Finality != "" {
+		return ir.delayedBridge.BlockHeightByTag(ctx, ir.config.Finality)
+	}
+	latest, err := ir.delayedBridge.CurrentBlockHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+	height := new(big.Int).Sub(latest, big.NewInt(ir.config.ConfirmationDepth))
+	if height.Sign() <= 0 {
+		height.SetInt64(1)
+	}
+	return height, nil
+}
+
 func (ir *InboxReader) deliverQueueItems() {
 	if len(ir.sequencerFeedQueue) > 0 && ir.sequencerFeedQueue[0].PrevAcc == ir.lastAcc {
 		queueItems := make([]inbox.SequencerBatchItem, 0, len(ir.sequencerFeedQueue))
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package monitor
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgetestcontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/hashing"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/inbox"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
+)
+
+// tagErrorClient is an L1 client whose node can't look up tagged blocks
+type tagErrorClient struct {
+	*ethutils.SimulatedEthClient
+}
+
+func (c *tagErrorClient) BlockInfoByTag(context.Context, string) (*ethutils.BlockInfo, error) {
+	return nil, errors.New("finalized block not found")
+}
+
+type testInbox struct {
+	delayedBridge  *ethbridge.DelayedBridgeWatcher
+	sequencerInbox *ethbridge.SequencerInboxWatcher
+	bridgeUtils    *ethbridge.BridgeUtils
+}
+
+// deployTestInbox deploys the L1 inbox contracts and posts a sequencer batch
+// containing an init message from the delayed inbox, which is 2 messages
+func deployTestInbox(t *testing.T, client *ethutils.SimulatedEthClient, readerClient ethutils.EthClient, deployer, sequencer *bind.TransactOpts) *testInbox {
+	t.Helper()
+	ctx := context.Background()
+	rollupAddr, _, rollup, err := ethbridgetestcontracts.DeployRollupMock(deployer, client)
+	test.FailIfError(t, err)
+	bridgeUtilsAddr, _, _, err := ethbridgecontracts.DeployBridgeUtils(deployer, client)
+	test.FailIfError(t, err)
+	delayedBridgeAddr, _, delayedBridge, err := ethbridgecontracts.DeployBridge(deployer, client)
+	test.FailIfError(t, err)
+	client.Commit()
+	_, err = delayedBridge.Initialize(deployer)
+	test.FailIfError(t, err)
+	client.Commit()
+	_, err = delayedBridge.SetInbox(deployer, deployer.From, true)
+	test.FailIfError(t, err)
+	_, err = rollup.SetMock(deployer, big.NewInt(60), big.NewInt(900))
+	test.FailIfError(t, err)
+	client.Commit()
+
+	init, err := message.NewInitMessage(protocol.ChainParams{
+		StakeRequirement:          big.NewInt(0),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocks(big.NewInt(3)),
+		MaxExecutionSteps:         0,
+		ArbGasSpeedLimitPerSecond: 0,
+	}, common.RandAddress(), nil)
+	test.FailIfError(t, err)
+	tx, err := delayedBridge.DeliverMessageToInbox(deployer, uint8(init.Type()), rollupAddr, hashing.SoliditySHA3(init.AsData()))
+	test.FailIfError(t, err)
+	client.Commit()
+	initReceipt, err := client.TransactionReceipt(ctx, tx.Hash())
+	test.FailIfError(t, err)
+	initBlock, err := client.BlockByHash(ctx, initReceipt.BlockHash)
+	test.FailIfError(t, err)
+	initMsg := message.NewInboxMessage(
+		init,
+		common.NewAddressFromEth(rollupAddr),
+		big.NewInt(0),
+		tx.GasPrice(),
+		inbox.ChainTime{
+			BlockNum:  common.NewTimeBlocks(initBlock.Number()),
+			Timestamp: new(big.Int).SetUint64(initBlock.Time()),
+		},
+	)
+
+	sequencerInboxAddr, _, sequencerInbox, err := ethbridgecontracts.DeploySequencerInbox(deployer, client)
+	test.FailIfError(t, err)
+	client.Commit()
+	_, err = sequencerInbox.Initialize(deployer, delayedBridgeAddr, sequencer.From, rollupAddr)
+	test.FailIfError(t, err)
+	client.Commit()
+	latestHeader, err := client.HeaderByNumber(ctx, nil)
+	test.FailIfError(t, err)
+	chainTime := inbox.ChainTime{
+		BlockNum:  common.NewTimeBlocks(latestHeader.Number),
+		Timestamp: new(big.Int).SetUint64(latestHeader.Time),
+	}
+	delayed := inbox.NewDelayedMessage(common.Hash{}, initMsg)
+	delayedItem := inbox.NewDelayedItem(big.NewInt(0), big.NewInt(1), common.Hash{}, big.NewInt(0), delayed.DelayedAccumulator)
+	endOfBlockMessage := message.NewInboxMessage(
+		message.EndBlockMessage{},
+		common.Address{},
+		big.NewInt(1),
+		big.NewInt(0),
+		chainTime,
+	)
+	endOfBlockItem := inbox.NewSequencerItem(big.NewInt(1), endOfBlockMessage, delayedItem.Accumulator)
+	delayedAccInt := new(big.Int).SetBytes(delayed.DelayedAccumulator.Bytes())
+	batchMetadata := []*big.Int{big.NewInt(0), chainTime.BlockNum.AsInt(), chainTime.Timestamp, big.NewInt(1), delayedAccInt}
+	_, err = sequencerInbox.AddSequencerL2BatchFromOrigin(sequencer, nil, nil, batchMetadata, endOfBlockItem.Accumulator)
+	test.FailIfError(t, err)
+	client.Commit()
+
+	delayedBridgeWatcher, err := ethbridge.NewDelayedBridgeWatcher(delayedBridgeAddr, 0, readerClient)
+	test.FailIfError(t, err)
+	sequencerInboxWatcher, err := ethbridge.NewSequencerInboxWatcher(sequencerInboxAddr, readerClient)
+	test.FailIfError(t, err)
+	bridgeUtils, err := ethbridge.NewBridgeUtils(bridgeUtilsAddr, readerClient, delayedBridgeWatcher, sequencerInboxWatcher)
+	test.FailIfError(t, err)
+	return &testInbox{
+		delayedBridge:  delayedBridgeWatcher,
+		sequencerInbox: sequencerInboxWatcher,
+		bridgeUtils:    bridgeUtils,
+	}
+}
+
+func TestInboxReaderConfirmationDepth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clnt, pks := test.SimulatedBackend(t)
+	client := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
+	inboxContracts := deployTestInbox(t, client, client, test.SimulatedTransactor(t, pks[0]), test.SimulatedTransactor(t, pks[1]))
+
+	mon, shutdown := PrepareArbCore(t)
+	defer shutdown()
+
+	const confirmationDepth = 10
+	reader, err := NewInboxReader(
+		ctx,
+		inboxContracts.delayedBridge,
+		inboxContracts.sequencerInbox,
+		inboxContracts.bridgeUtils,
+		mon.Core,
+		InboxReaderConfig{ConfirmationDepth: confirmationDepth},
+		nil,
+		nil,
+	)
+	test.FailIfError(t, err)
+	reader.Start(ctx)
+	defer reader.Stop()
+
+	// The batch is newer than the confirmed height so it isn't delivered
+	<-time.After(time.Second * 2)
+	messageCount, err := mon.Core.GetMessageCount()
+	test.FailIfError(t, err)
+	if messageCount.Sign() != 0 {
+		t.Fatal("delivered", messageCount, "messages past the confirmed height")
+	}
+
+	for i := 0; i < confirmationDepth; i++ {
+		client.Commit()
+	}
+	for i := 0; ; i++ {
+		messageCount, err := mon.Core.GetMessageCount()
+		test.FailIfError(t, err)
+		if messageCount.Cmp(big.NewInt(2)) == 0 {
+			break
+		}
+		if i == 30 {
+			t.Fatal("delivered", messageCount, "messages instead of 2 once confirmed")
+		}
+		<-time.After(time.Millisecond * 500)
+	}
+}
+
+func TestInboxReaderFinalityError(t *testing.T) {
+	ctx := context.Background()
+	clnt, pks := test.SimulatedBackend(t)
+	client := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
+	inboxContracts := deployTestInbox(t, client, &tagErrorClient{SimulatedEthClient: client}, test.SimulatedTransactor(t, pks[0]), test.SimulatedTransactor(t, pks[1]))
+
+	mon, shutdown := PrepareArbCore(t)
+	defer shutdown()
+
+	reader, err := NewInboxReader(
+		ctx,
+		inboxContracts.delayedBridge,
+		inboxContracts.sequencerInbox,
+		inboxContracts.bridgeUtils,
+		mon.Core,
+		InboxReaderConfig{Finality: "finalized"},
+		nil,
+		nil,
+	)
+	test.FailIfError(t, err)
+	err = reader.getMessages(ctx)
+	if err == nil || !strings.Contains(err.Error(), "finalized block not found") {
+		t.Fatal("finalized block lookup error not surfaced:", err)
+	}
+	messageCount, err := mon.Core.GetMessageCount()
+	test.FailIfError(t, err)
+	if messageCount.Sign() != 0 {
+		t.Error("delivered", messageCount, "messages without a finalized block")
+	}
+}
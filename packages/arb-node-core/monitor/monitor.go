@@ -78,6 +78,7 @@ func (m *Monitor) StartInboxReader(
 	rollupAddress common.Address,
 	fromBlock int64,
 	bridgeUtilsAddress common.Address,
+	config InboxReaderConfig,
 	healthChan chan nodehealth.Log,
 	sequencerFeed chan broadcaster.BroadcastFeedMessage,
 ) (*InboxReader, error) {
@@ -105,7 +106,7 @@ func (m *Monitor) StartInboxReader(
 	if err != nil {
 		return nil, err
 	}
-	reader, err := NewInboxReader(ctx, delayedBridgeWatcher, sequencerInboxWatcher, bridgeUtils, m.Core, healthChan, sequencerFeed)
+	reader, err := NewInboxReader(ctx, delayedBridgeWatcher, sequencerInboxWatcher, bridgeUtils, m.Core, config, healthChan, sequencerFeed)
 	if err != nil {
 		return nil, err
 	}
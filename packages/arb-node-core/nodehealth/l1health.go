@@ -0,0 +1,232 @@
+package nodehealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/heptiolabs/healthcheck"
+)
+
+//Names used to select an L1 health provider
+const (
+	StandardL1Provider     = "standard"
+	OpenEthereumL1Provider = "openethereum"
+)
+
+//JSON-RPC error code returned by nodes that don't implement a method
+const methodNotFoundCode = -32601
+
+//Readiness check for the L1 node along with the name it is exposed under
+type l1HealthCheck struct {
+	name  string
+	check healthcheck.Check
+}
+
+//Constructor for the readiness checks a provider runs against the L1 node
+type l1HealthProvider func(config *configStruct, asyncData *asyncDataStruct) []l1HealthCheck
+
+//Available L1 health providers keyed by the name used to select them
+var l1HealthProviders = map[string]l1HealthProvider{
+	StandardL1Provider:     standardProvider,
+	OpenEthereumL1Provider: openEthereumProvider,
+}
+
+//Check that name refers to a known L1 health provider
+func CheckL1HealthProvider(name string) error {
+	if _, ok := l1HealthProviders[name]; !ok {
+		return fmt.Errorf("unknown L1 health provider: %v", name)
+	}
+	return nil
+}
+
+//Check whether the endpoint required by the selected L1 health provider is set
+func l1ConfigLoaded(config *configStruct) bool {
+	if config.l1HealthProvider == StandardL1Provider {
+		return config.l1API != ""
+	}
+	return config.openethereumAPI != "" || config.openethereumHealthcheckRPC != ""
+}
+
+//Client agnostic checks using only the standard Ethereum JSON-RPC API
+func standardProvider(config *configStruct, asyncData *asyncDataStruct) []l1HealthCheck {
+	return []l1HealthCheck{
+		//Check if the L1 node is within blockSyncDifference of the highest known block
+		{name: "l1-sync-status", check: l1SyncCheck(config)},
+
+		//Check the L1 node has at least peerMinimum peers connected to it
+		{name: "l1-peer-status", check: l1PeerCountCheck(config)},
+
+		//Check the latest L1 block was produced within l1BlockMaxAge
+		{name: "l1-block-age-status", check: l1BlockAgeCheck(config)},
+
+		//Check the L1 node is on the expected chain
+		{name: "l1-chain-id-status", check: l1ChainIDCheck(config)},
+	}
+}
+
+//Checks relying on OpenEthereum specific APIs or its healthcheck server
+func openEthereumProvider(config *configStruct, asyncData *asyncDataStruct) []l1HealthCheck {
+	if config.openethereumAPI == "" {
+		//Check the healthcheck endpoint for OpenEthereum
+		return []l1HealthCheck{
+			{name: "openethereum-status", check: checkEndpoint(config, &config.openethereumHealthcheckRPC, &config.openethereumHealthcheckRPCPort)},
+		}
+	}
+
+	return []l1HealthCheck{
+		//Check if the OpenEthereum API is accepting pings
+		{name: "openethereum-api-status", check: openEthereumTCPDialCheck(config, asyncData)},
+
+		//Request eth_syncing status from OpenEthereum
+		{name: "openethereum-sync-response-status", check: ethSyncCheck(config, asyncData)},
+
+		//Request parity_netPeers status from OpenEthereum
+		{name: "openethereum-netpeers-response-status", check: netPeersCheck(config, asyncData)},
+
+		//Check if OpenEthereum is within blockSyncDifference from the estimated block
+		{name: "openethereum-sync-status", check: openEthereumBlockSyncCheck(config, asyncData)},
+
+		//Check OpenEthereum has more than peerMinimum peers currently connected to it
+		{name: "openethereum-peer-status", check: openEthereumPeerCount(config, asyncData)},
+
+		//Check OpenEthereum is refreshing its currentBlock quicker than the blockUpdateTimeout
+		{name: "openethereum-block-refresh-status", check: openEthereumBlockUpdateCheck(config, asyncData)},
+	}
+}
+
+//Send a JSON-RPC call to the L1 node and decode the result
+func l1Call(config *configStruct, result interface{}, method string, args ...interface{}) error {
+	//Copy the endpoint so the mutex isn't held during the request
+	config.mu.Lock()
+	l1API := config.l1API
+	config.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.requestTimeout)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, l1API)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.CallContext(ctx, result, method, args...)
+}
+
+//Check the eth_syncing status of the L1 node
+func l1SyncCheck(config *configStruct) healthcheck.Check {
+	check := healthcheck.Async(func() error {
+		var resp json.RawMessage
+		if err := l1Call(config, &resp, "eth_syncing"); err != nil {
+			return err
+		}
+
+		//The node returns false when it isn't syncing
+		var syncing bool
+		if err := json.Unmarshal(resp, &syncing); err == nil {
+			return nil
+		}
+
+		//Otherwise parse the sync progress
+		var progress struct {
+			CurrentBlock hexutil.Uint64 `json:"currentBlock"`
+			HighestBlock hexutil.Uint64 `json:"highestBlock"`
+		}
+		if err := json.Unmarshal(resp, &progress); err != nil {
+			return err
+		}
+
+		//Compare to blockSyncDifference
+		blockDifference := int64(progress.HighestBlock) - int64(progress.CurrentBlock)
+		if blockDifference > config.blockSyncDifference {
+			return fmt.Errorf("syncing block %d of %d", progress.CurrentBlock, progress.HighestBlock)
+		}
+
+		return nil
+	}, config.pollingRate)
+	return check
+}
+
+//Check the net_peerCount of the L1 node against peerMinimum
+func l1PeerCountCheck(config *configStruct) healthcheck.Check {
+	check := healthcheck.Async(func() error {
+		if config.peerMinimum <= 0 {
+			return nil
+		}
+
+		var peerCount hexutil.Uint64
+		if err := l1Call(config, &peerCount, "net_peerCount"); err != nil {
+			//Skip the check if the node doesn't expose its peers
+			if rpcErr, ok := err.(rpc.Error); ok && rpcErr.ErrorCode() == methodNotFoundCode {
+				return nil
+			}
+			return err
+		}
+
+		if int(peerCount) < config.peerMinimum {
+			return fmt.Errorf("minimumPeers :%d", peerCount)
+		}
+
+		return nil
+	}, config.pollingRate)
+	return check
+}
+
+//Check the timestamp of the L1 node's latest block against l1BlockMaxAge
+func l1BlockAgeCheck(config *configStruct) healthcheck.Check {
+	check := healthcheck.Async(func() error {
+		if config.l1BlockMaxAge <= 0 {
+			return nil
+		}
+
+		var header struct {
+			Number    hexutil.Uint64 `json:"number"`
+			Timestamp hexutil.Uint64 `json:"timestamp"`
+		}
+		if err := l1Call(config, &header, "eth_getBlockByNumber", "latest", false); err != nil {
+			return err
+		}
+
+		age := time.Since(time.Unix(int64(header.Timestamp), 0))
+		if age > config.l1BlockMaxAge {
+			return fmt.Errorf("latest block %d is %v old", header.Number, age.Round(time.Second))
+		}
+
+		return nil
+	}, config.pollingRate)
+	return check
+}
+
+//Check the eth_chainId of the L1 node matches l1ChainID
+func l1ChainIDCheck(config *configStruct) healthcheck.Check {
+	check := healthcheck.Async(func() error {
+		//Copy the expected chain ID so the mutex isn't held during the request
+		config.mu.Lock()
+		var expected *big.Int
+		if config.l1ChainID != nil {
+			expected = new(big.Int).Set(config.l1ChainID)
+		}
+		config.mu.Unlock()
+
+		if expected == nil {
+			return nil
+		}
+
+		var chainID hexutil.Big
+		if err := l1Call(config, &chainID, "eth_chainId"); err != nil {
+			return err
+		}
+
+		if chainID.ToInt().Cmp(expected) != 0 {
+			return fmt.Errorf("chain ID %v doesn't match expected %v", chainID.ToInt(), expected)
+		}
+
+		return nil
+	}, config.pollingRate)
+	return check
+}
@@ -0,0 +1,123 @@
+package nodehealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/heptiolabs/healthcheck"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Start a JSON-RPC server answering each method with the given raw result
+func startL1Server(t *testing.T, results map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		result, ok := results[req.Method]
+		if !ok {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":%d,"message":"method not found"}}`, req.ID, methodNotFoundCode)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+//Wait for the first result of an async healthcheck
+func waitCheck(t *testing.T, check healthcheck.Check) error {
+	for i := 0; i < 100; i++ {
+		err := check()
+		if err != healthcheck.ErrNoData {
+			return err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("healthcheck didn't complete")
+	return nil
+}
+
+func TestStandardL1Checks(t *testing.T) {
+	latest := fmt.Sprintf(`{"number":"0x10","timestamp":"0x%x"}`, time.Now().Unix())
+	stale := fmt.Sprintf(`{"number":"0x10","timestamp":"0x%x"}`, time.Now().Add(-time.Hour).Unix())
+
+	healthy := map[string]string{
+		"eth_syncing":          "false",
+		"net_peerCount":        `"0x5"`,
+		"eth_getBlockByNumber": latest,
+		"eth_chainId":          `"0x2a"`,
+	}
+
+	tests := []struct {
+		name    string
+		check   func(*configStruct) healthcheck.Check
+		results map[string]string
+		fail    bool
+	}{
+		{"SyncHealthy", l1SyncCheck, healthy, false},
+		{"SyncCaughtUp", l1SyncCheck, map[string]string{"eth_syncing": `{"currentBlock":"0x10","highestBlock":"0x12"}`}, false},
+		{"SyncBehind", l1SyncCheck, map[string]string{"eth_syncing": `{"currentBlock":"0x10","highestBlock":"0x100"}`}, true},
+		{"PeersHealthy", l1PeerCountCheck, healthy, false},
+		{"PeersMissing", l1PeerCountCheck, map[string]string{"net_peerCount": `"0x0"`}, true},
+		{"PeersUnsupported", l1PeerCountCheck, map[string]string{}, false},
+		{"BlockAgeHealthy", l1BlockAgeCheck, healthy, false},
+		{"BlockAgeStale", l1BlockAgeCheck, map[string]string{"eth_getBlockByNumber": stale}, true},
+		{"ChainIDHealthy", l1ChainIDCheck, healthy, false},
+		{"ChainIDMismatch", l1ChainIDCheck, map[string]string{"eth_chainId": `"0x1"`}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := startL1Server(t, test.results)
+			registry := prometheus.NewRegistry()
+			config := newConfig(registry, registry)
+			config.l1API = server.URL
+			config.l1ChainID = big.NewInt(42)
+
+			err := waitCheck(t, test.check(config))
+			if test.fail && err == nil {
+				t.Error("expected check to fail")
+			}
+			if !test.fail && err != nil {
+				t.Error("unexpected check failure:", err)
+			}
+		})
+	}
+}
+
+func TestL1HealthProvider(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := newConfig(registry, registry)
+
+	updateConfig(config, Log{Config: true, Var: "l1HealthProvider", ValStr: "unknown"})
+	if config.l1HealthProvider != OpenEthereumL1Provider {
+		t.Error("unknown provider replaced the default")
+	}
+	if err := CheckL1HealthProvider("unknown"); err == nil {
+		t.Error("unknown provider accepted")
+	}
+
+	updateConfig(config, Log{Config: true, Var: "l1HealthProvider", ValStr: StandardL1Provider})
+	if config.l1HealthProvider != StandardL1Provider {
+		t.Error("provider not selected")
+	}
+	if l1ConfigLoaded(config) {
+		t.Error("config loaded without an L1 API")
+	}
+	updateConfig(config, Log{Config: true, Var: "l1API", ValStr: "http://127.0.0.1:8545"})
+	if !l1ConfigLoaded(config) {
+		t.Error("config not loaded with an L1 API")
+	}
+}
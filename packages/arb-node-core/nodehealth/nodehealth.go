@@ -35,8 +35,10 @@ type configStruct struct {
 	healthcheckMetrics bool
 	//Disable checking the primary aggregator
 	disablePrimaryCheck bool
-	//Disable checking the OpenEthereum node
-	disableOpenEthereumCheck bool
+	//Disable checking the L1 node
+	disableL1Check bool
+	//Name of the provider supplying the L1 node healthchecks
+	l1HealthProvider string
 
 	//Map to dynamically allocate Prometheus Histograms
 	prometheusHistograms map[string]*prometheus.HistogramVec
@@ -63,6 +65,14 @@ type configStruct struct {
 	//Blocks between arbCorePosition and caughtUpTarget to consider acceptable
 	blockDifferenceTolerance int64
 
+	//Standard L1 Healthcheck Config
+	//Address to the L1 node's JSON-RPC API
+	l1API string
+	//Chain ID the L1 node is expected to be on, unchecked if nil
+	l1ChainID *big.Int
+	//Maximum age of the L1 node's latest block before a healthcheck error is triggered
+	l1BlockMaxAge time.Duration
+
 	//OpenEthereum Healthcheck Config
 	//Address to the OpenEthereum API
 	openethereumAPI string
@@ -109,6 +119,9 @@ type asyncDataStruct struct {
 	//Map to dynamically allocate new healthchecks
 	healthchecks map[string]healthcheck.Check
 
+	//Readiness checks supplied by the L1 health provider
+	l1Checks []l1HealthCheck
+
 	//Response structs to process the OpenEthereum responses
 	ethSyncResp        OpenEthereumResponse
 	parityNetPeersResp OpenEthereumResponse
@@ -184,7 +197,8 @@ func newConfig(prometheusRegistry *prometheus.Registry, prometheusRegisterer pro
 	const healthcheckRPC = ""
 	const healthcheckMetrics = false
 	const disablePrimaryCheck = false
-	const disableOpenEthereumCheck = false
+	const disableL1Check = false
+	const l1HealthProvider = OpenEthereumL1Provider
 
 	//Node health configuration
 	const defaultSuccessCode = 200
@@ -193,6 +207,9 @@ func newConfig(prometheusRegistry *prometheus.Registry, prometheusRegisterer pro
 	const loopDelayTimer = 1 * time.Second
 	const defaultHealthCheckPort = "8080"
 
+	//Standard L1 health configuration
+	const l1BlockMaxAge = 5 * time.Minute
+
 	//OpenEthereum health configuration
 	const requestTimeout = 10 * time.Second
 	const blockSyncDifference = 10
@@ -210,7 +227,8 @@ func newConfig(prometheusRegistry *prometheus.Registry, prometheusRegisterer pro
 	config.healthcheckRPC = healthcheckRPC
 	config.healthcheckMetrics = healthcheckMetrics
 	config.disablePrimaryCheck = disablePrimaryCheck
-	config.disableOpenEthereumCheck = disableOpenEthereumCheck
+	config.disableL1Check = disableL1Check
+	config.l1HealthProvider = l1HealthProvider
 
 	config.openethereumHealthcheckRPCPort = defaultHealthCheckPort
 	config.primaryHealthcheckRPCPort = defaultHealthCheckPort
@@ -221,6 +239,9 @@ func newConfig(prometheusRegistry *prometheus.Registry, prometheusRegisterer pro
 	config.successCode = defaultSuccessCode
 	config.blockDifferenceTolerance = defaultBlockDifferenceTolerance
 
+	config.l1API = ""
+	config.l1BlockMaxAge = l1BlockMaxAge
+
 	config.openethereumAPI = ""
 	config.requestTimeout = requestTimeout
 	config.blockSyncDifference = blockSyncDifference
@@ -237,28 +258,10 @@ func newAsyncUpstream(state *healthState, config *configStruct) *asyncDataStruct
 	asyncData := asyncDataStruct{}
 	//Allocate memory for healthcheck map
 	asyncData.healthchecks = make(map[string]healthcheck.Check)
-	if config.openethereumAPI != "" {
-		//Request eth_syncing status from OpenEthereum
-		asyncData.healthchecks["ethSyncCheck"] = ethSyncCheck(config, &asyncData)
-
-		//Request eth_syncing status from OpenEthereum
-		asyncData.healthchecks["parityNetPeersCheck"] = netPeersCheck(config, &asyncData)
-
-		//Check OpenEthereum has more than peerMinimum peers currently connected to it
-		asyncData.healthchecks["minimumPeersCheck"] = openEthereumPeerCount(config, &asyncData)
-
-		//Check OpenEthereum is refreshing its currentBlock quicker than the blockUpdateTimeout
-		asyncData.healthchecks["blockRefreshCheck"] = openEthereumBlockUpdateCheck(config, &asyncData)
-
-		//Check if OpenEthereum is within blockSyncDifference from the estimated block
-		asyncData.healthchecks["blockSyncCheck"] = openEthereumBlockSyncCheck(config, &asyncData)
 
-		//Check if the OpenEthereum API is accepting pings
-		asyncData.healthchecks["tcpDialCheck"] = openEthereumTCPDialCheck(config, &asyncData)
-
-	} else {
-		//Check the healthcheck endpoint for OpenEthereum
-		asyncData.healthchecks["checkOpenethereum"] = checkEndpoint(config, &config.openethereumHealthcheckRPC, &config.openethereumHealthcheckRPCPort)
+	//Schedule the L1 node checks of the configured provider
+	if !config.disableL1Check {
+		asyncData.l1Checks = l1HealthProviders[config.l1HealthProvider](config, &asyncData)
 	}
 
 	//Check the primary endpoint
@@ -417,8 +420,23 @@ func updateConfig(config *configStruct, logMessage Log) {
 	if logMessage.Var == "disablePrimaryCheck" {
 		config.disablePrimaryCheck = logMessage.ValBool
 	}
-	if logMessage.Var == "disableOpenEthereumCheck" {
-		config.disableOpenEthereumCheck = logMessage.ValBool
+	if logMessage.Var == "disableL1Check" || logMessage.Var == "disableOpenEthereumCheck" {
+		config.disableL1Check = logMessage.ValBool
+	}
+	if logMessage.Var == "l1HealthProvider" {
+		//Ignore unknown providers so the default remains in use
+		if _, ok := l1HealthProviders[logMessage.ValStr]; ok {
+			config.l1HealthProvider = logMessage.ValStr
+		}
+	}
+	if logMessage.Var == "l1API" {
+		config.l1API = logMessage.ValStr
+	}
+	if logMessage.Var == "l1ChainID" {
+		config.l1ChainID = logMessage.ValBigInt
+	}
+	if logMessage.Var == "l1BlockMaxAge" {
+		config.l1BlockMaxAge = logMessage.ValTime
 	}
 }
 
@@ -789,37 +807,9 @@ func nodeReadinessChecks(health healthcheck.Handler, config *configStruct, httpM
 		"inbox-reader-status",
 		asyncData.healthchecks["inboxReaderStatus"])
 
-	//OpenEthereum healthchecks
-	//Add healthchecks to the readiness check if they are not disabled
-	if !config.disableOpenEthereumCheck {
-		if config.openethereumAPI != "" {
-			health.AddReadinessCheck(
-				"openethereum-api-status",
-				asyncData.healthchecks["tcpDialCheck"])
-			health.AddReadinessCheck(
-				"openethereum-sync-response-status",
-				asyncData.healthchecks["ethSyncCheck"])
-
-			health.AddReadinessCheck(
-				"openethereum-netpeers-response-status",
-				asyncData.healthchecks["parityNetPeersCheck"])
-
-			health.AddReadinessCheck(
-				"openethereum-sync-status",
-				asyncData.healthchecks["blockSyncCheck"])
-
-			health.AddReadinessCheck(
-				"openethereum-peer-status",
-				asyncData.healthchecks["minimumPeersCheck"])
-
-			health.AddReadinessCheck(
-				"openethereum-block-refresh-status",
-				asyncData.healthchecks["blockRefreshCheck"])
-		} else {
-			health.AddReadinessCheck(
-				"openethereum-status",
-				asyncData.healthchecks["checkOpenethereum"])
-		}
+	//L1 node healthchecks supplied by the configured provider
+	for _, l1Check := range asyncData.l1Checks {
+		health.AddReadinessCheck(l1Check.name, l1Check.check)
 	}
 
 	//Create an endpoint to serve the readiness check
@@ -834,8 +824,7 @@ func waitConfig(config *configStruct) {
 	//Loop while the configuration variables are not set
 	for {
 		if config.init {
-			if config.disableOpenEthereumCheck || config.healthcheckRPC == "" ||
-				config.openethereumAPI != "" || config.openethereumHealthcheckRPC != "" {
+			if config.disableL1Check || config.healthcheckRPC == "" || l1ConfigLoaded(config) {
 				return
 			}
 		}
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EthAPI is the eth_signTransaction/eth_sign interface of web3signer
+	EthAPI = "eth"
+	// AccountAPI is the account_signTransaction/account_signData interface
+	// of Clef
+	AccountAPI = "account"
+)
+
+// txArgs is the transaction format accepted by both signing APIs
+type txArgs struct {
+	From       ethcommon.Address  `json:"from"`
+	To         *ethcommon.Address `json:"to"`
+	Gas        hexutil.Uint64     `json:"gas"`
+	GasPrice   *hexutil.Big       `json:"gasPrice"`
+	Value      *hexutil.Big       `json:"value"`
+	Nonce      hexutil.Uint64     `json:"nonce"`
+	Data       hexutil.Bytes      `json:"data"`
+	AccessList *types.AccessList  `json:"accessList,omitempty"`
+	ChainID    *hexutil.Big       `json:"chainId,omitempty"`
+
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
+}
+
+// signTransactionResult is what Clef returns, web3signer only returns the raw
+// transaction
+type signTransactionResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// RemoteSigner signs using an external JSON-RPC signer such as Clef or
+// web3signer, which holds the key
+type RemoteSigner struct {
+	client  *rpc.Client
+	api     string
+	address ethcommon.Address
+	Timeout time.Duration
+}
+
+// NewRemoteSigner connects to the signer at url. If address is the zero
+// address, the first account the signer lists is used.
+func NewRemoteSigner(ctx context.Context, url string, address ethcommon.Address, api string) (*RemoteSigner, error) {
+	if api != EthAPI && api != AccountAPI {
+		return nil, errors.Errorf("unknown remote signer api %v, must be %v or %v", api, EthAPI, AccountAPI)
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to remote signer")
+	}
+	s := &RemoteSigner{
+		client:  client,
+		api:     api,
+		address: address,
+		Timeout: 30 * time.Second,
+	}
+	if address == (ethcommon.Address{}) {
+		var accounts []ethcommon.Address
+		method := "eth_accounts"
+		if api == AccountAPI {
+			method = "account_list"
+		}
+		if err := client.CallContext(ctx, &accounts, method); err != nil {
+			client.Close()
+			return nil, errors.Wrap(err, "error listing remote signer accounts")
+		}
+		if len(accounts) == 0 {
+			client.Close()
+			return nil, errors.New("remote signer has no accounts")
+		}
+		s.address = accounts[0]
+	}
+	return s, nil
+}
+
+func (s *RemoteSigner) Address() ethcommon.Address {
+	return s.address
+}
+
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+func (s *RemoteSigner) SignTransaction(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	args := txArgs{
+		From:     s.address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+		ChainID:  (*hexutil.Big)(chainId),
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args.GasPrice = nil
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	var res json.RawMessage
+	if err := s.client.CallContext(ctx, &res, s.api+"_signTransaction", args); err != nil {
+		return nil, errors.Wrap(err, "error signing transaction with remote signer")
+	}
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(res, &raw); err != nil {
+		var result signTransactionResult
+		if err := json.Unmarshal(res, &result); err != nil {
+			return nil, errors.Wrap(err, "unexpected remote signer response")
+		}
+		raw = result.Raw
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, errors.Wrap(err, "error decoding remotely signed transaction")
+	}
+
+	// Make sure the signer signed what we asked it to
+	if signed.Type() != tx.Type() {
+		return nil, errors.Errorf("remote signer returned transaction of type %v instead of %v", signed.Type(), tx.Type())
+	}
+	if signed.ChainId().Cmp(chainId) != 0 {
+		return nil, errors.Errorf("remote signer signed for chain %v instead of %v", signed.ChainId(), chainId)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid remotely signed transaction")
+	}
+	if sender != s.address {
+		return nil, errors.Errorf("remote signer signed with %v instead of %v", sender, s.address)
+	}
+	if signed.Nonce() != tx.Nonce() || signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 ||
+		signed.GasTipCap().Cmp(tx.GasTipCap()) != 0 || signed.Gas() != tx.Gas() ||
+		signed.Value().Cmp(tx.Value()) != 0 || !equalTo(signed.To(), tx.To()) || string(signed.Data()) != string(tx.Data()) ||
+		!equalAccessList(signed.AccessList(), tx.AccessList()) {
+		return nil, errors.New("remote signer returned a different transaction")
+	}
+	return signed, nil
+}
+
+func (s *RemoteSigner) SignMessage(ctx context.Context, data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	var sig hexutil.Bytes
+	var err error
+	if s.api == AccountAPI {
+		err = s.client.CallContext(ctx, &sig, "account_signData", accounts.MimetypeTextPlain, s.address, hexutil.Bytes(data))
+	} else {
+		err = s.client.CallContext(ctx, &sig, "eth_sign", s.address, hexutil.Bytes(data))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing message with remote signer")
+	}
+	if len(sig) != 65 {
+		return nil, errors.Errorf("remote signer returned signature of length %v", len(sig))
+	}
+	// Remote signers use the 27/28 recovery id from eth_sign
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	return sig, nil
+}
+
+func equalTo(a, b *ethcommon.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalAccessList(a, b types.AccessList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || len(a[i].StorageKeys) != len(b[i].StorageKeys) {
+			return false
+		}
+		for j := range a[i].StorageKeys {
+			if a[i].StorageKeys[j] != b[i].StorageKeys[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
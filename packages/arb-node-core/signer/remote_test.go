@@ -0,0 +1,151 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package signer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	server, err := NewTestSignerServer(key)
+	test.FailIfError(t, err)
+	defer server.Close()
+
+	chainId := big.NewInt(1337)
+	to := ethcommon.HexToAddress("0x1234")
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    5,
+		GasPrice: big.NewInt(1000),
+		Gas:      21000,
+		To:       &to,
+		Value:    big.NewInt(7),
+		Data:     []byte{1, 2, 3},
+	})
+
+	for _, api := range []string{EthAPI, AccountAPI} {
+		remote, err := NewRemoteSigner(ctx, server.URL(), ethcommon.Address{}, api)
+		test.FailIfError(t, err)
+		if remote.Address() != address {
+			t.Fatal(api, "picked wrong account", remote.Address())
+		}
+
+		auth := TransactOpts(remote, chainId)
+		signed, err := auth.Signer(address, tx)
+		test.FailIfError(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+		test.FailIfError(t, err)
+		if sender != address || signed.Nonce() != 5 || signed.Value().Cmp(big.NewInt(7)) != 0 {
+			t.Error(api, "signed wrong transaction")
+		}
+
+		message := []byte("feed message")
+		sig, err := DataSigner(remote)(message)
+		test.FailIfError(t, err)
+		pubkey, err := crypto.SigToPub(crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n12feed message")), sig)
+		test.FailIfError(t, err)
+		if crypto.PubkeyToAddress(*pubkey) != address {
+			t.Error(api, "message signed by wrong key")
+		}
+
+		other, err := NewRemoteSigner(ctx, server.URL(), to, api)
+		test.FailIfError(t, err)
+		if _, err := other.SignTransaction(ctx, tx, chainId); err == nil {
+			t.Error(api, "signed for unknown account")
+		}
+		other.Close()
+
+		accessListTx := types.NewTx(&types.AccessListTx{
+			ChainID:    chainId,
+			Nonce:      6,
+			GasPrice:   big.NewInt(1000),
+			Gas:        30000,
+			To:         &to,
+			Value:      big.NewInt(7),
+			AccessList: types.AccessList{{Address: to, StorageKeys: []ethcommon.Hash{{1}}}},
+		})
+		signed, err = remote.SignTransaction(ctx, accessListTx, chainId)
+		test.FailIfError(t, err)
+		if signed.Type() != types.AccessListTxType || len(signed.AccessList()) != 1 {
+			t.Error(api, "signed access list transaction incorrectly")
+		}
+
+		dynamicFeeTx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainId,
+			Nonce:      7,
+			GasTipCap:  big.NewInt(100),
+			GasFeeCap:  big.NewInt(2000),
+			Gas:        30000,
+			To:         &to,
+			Value:      big.NewInt(7),
+			AccessList: types.AccessList{{Address: to, StorageKeys: []ethcommon.Hash{{2}}}},
+		})
+		signed, err = remote.SignTransaction(ctx, dynamicFeeTx, chainId)
+		test.FailIfError(t, err)
+		sender, err = types.Sender(types.LatestSignerForChainID(chainId), signed)
+		test.FailIfError(t, err)
+		if signed.Type() != types.DynamicFeeTxType || sender != address ||
+			signed.GasTipCap().Cmp(big.NewInt(100)) != 0 || signed.GasFeeCap().Cmp(big.NewInt(2000)) != 0 ||
+			len(signed.AccessList()) != 1 {
+			t.Error(api, "signed dynamic fee transaction incorrectly")
+		}
+
+		server.tamper = func(args *txArgs) {
+			args.AccessList = nil
+		}
+		if _, err := remote.SignTransaction(ctx, accessListTx, chainId); err == nil {
+			t.Error(api, "accepted transaction of the wrong type")
+		}
+		server.tamper = func(args *txArgs) {
+			args.ChainID = (*hexutil.Big)(big.NewInt(1))
+		}
+		if _, err := remote.SignTransaction(ctx, tx, chainId); err == nil {
+			t.Error(api, "accepted transaction for the wrong chain")
+		}
+		server.tamper = func(args *txArgs) {
+			args.AccessList = &types.AccessList{{Address: to}}
+		}
+		if _, err := remote.SignTransaction(ctx, accessListTx, chainId); err == nil {
+			t.Error(api, "accepted transaction with a different access list")
+		}
+		server.tamper = func(args *txArgs) {
+			args.MaxFeePerGas = (*hexutil.Big)(big.NewInt(3000))
+		}
+		if _, err := remote.SignTransaction(ctx, dynamicFeeTx, chainId); err == nil {
+			t.Error(api, "accepted transaction with a different fee cap")
+		}
+		server.tamper = func(args *txArgs) {
+			args.MaxFeePerGas = nil
+		}
+		if _, err := remote.SignTransaction(ctx, dynamicFeeTx, chainId); err == nil {
+			t.Error(api, "accepted transaction of the wrong type")
+		}
+		server.tamper = nil
+		remote.Close()
+	}
+}
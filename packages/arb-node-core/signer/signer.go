@@ -0,0 +1,84 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package signer abstracts over where the keys used to sign L1 transactions
+// and feed messages are kept, so that they don't need to be on the node's disk
+package signer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Signer signs transactions and messages for a single account
+type Signer interface {
+	Address() ethcommon.Address
+	SignTransaction(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+	// SignMessage signs data with the "\x19Ethereum Signed Message" prefix,
+	// as eth_sign does. The recovery id of the signature is 0 or 1.
+	SignMessage(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// TransactOpts returns transaction options which sign using the signer
+func TransactOpts(signer Signer, chainId *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: signer.Address(),
+		Signer: func(address ethcommon.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTransaction(context.Background(), tx, chainId)
+		},
+		Context: context.Background(),
+	}
+}
+
+// DataSigner returns a function signing feed messages with the signer
+func DataSigner(signer Signer) func([]byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		return signer.SignMessage(context.Background(), data)
+	}
+}
+
+// KeystoreSigner signs with an unlocked account from a local keystore
+type KeystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+func NewKeystoreSigner(ks *keystore.KeyStore, account accounts.Account) *KeystoreSigner {
+	return &KeystoreSigner{ks: ks, account: account}
+}
+
+func (s *KeystoreSigner) Address() ethcommon.Address {
+	return s.account.Address
+}
+
+func (s *KeystoreSigner) SignTransaction(_ context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	signed, err := s.ks.SignTx(s.account, tx, chainId)
+	return signed, errors.WithStack(err)
+}
+
+func (s *KeystoreSigner) SignMessage(_ context.Context, data []byte) ([]byte, error) {
+	sig, err := s.ks.SignHash(s.account, accounts.TextHash(data))
+	return sig, errors.WithStack(err)
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package signer
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// TestSignerServer is a stand-in for an external signer holding a single
+// key, serving both the web3signer and Clef interfaces. It signs everything
+// it's asked to and is only meant for tests.
+type TestSignerServer struct {
+	key      *ecdsa.PrivateKey
+	address  ethcommon.Address
+	server   *rpc.Server
+	listener net.Listener
+
+	// tamper modifies requests before they're signed, to test that signers
+	// which don't sign what they're asked to are caught
+	tamper func(args *txArgs)
+}
+
+func NewTestSignerServer(key *ecdsa.PrivateKey) (*TestSignerServer, error) {
+	s := &TestSignerServer{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		server:  rpc.NewServer(),
+	}
+	if err := s.server.RegisterName(EthAPI, &testEthAPI{s: s}); err != nil {
+		return nil, err
+	}
+	if err := s.server.RegisterName(AccountAPI, &testAccountAPI{s: s}); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s.listener = listener
+	go func() {
+		_ = http.Serve(listener, s.server)
+	}()
+	return s, nil
+}
+
+func (s *TestSignerServer) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+func (s *TestSignerServer) Close() {
+	_ = s.listener.Close()
+	s.server.Stop()
+}
+
+func (s *TestSignerServer) signTransaction(args txArgs) (hexutil.Bytes, error) {
+	if args.From != s.address {
+		return nil, errors.Errorf("unknown account %v", args.From)
+	}
+	if s.tamper != nil {
+		s.tamper(&args)
+	}
+	value := (*big.Int)(args.Value)
+	if value == nil {
+		value = new(big.Int)
+	}
+	var data types.TxData
+	if args.MaxFeePerGas != nil {
+		var accessList types.AccessList
+		if args.AccessList != nil {
+			accessList = *args.AccessList
+		}
+		data = &types.DynamicFeeTx{
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(args.Nonce),
+			GasTipCap:  (*big.Int)(args.MaxPriorityFeePerGas),
+			GasFeeCap:  (*big.Int)(args.MaxFeePerGas),
+			Gas:        uint64(args.Gas),
+			To:         args.To,
+			Value:      value,
+			Data:       args.Data,
+			AccessList: accessList,
+		}
+	} else if args.AccessList != nil {
+		data = &types.AccessListTx{
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(args.Nonce),
+			GasPrice:   (*big.Int)(args.GasPrice),
+			Gas:        uint64(args.Gas),
+			To:         args.To,
+			Value:      value,
+			Data:       args.Data,
+			AccessList: *args.AccessList,
+		}
+	} else {
+		data = &types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: (*big.Int)(args.GasPrice),
+			Gas:      uint64(args.Gas),
+			To:       args.To,
+			Value:    value,
+			Data:     args.Data,
+		}
+	}
+	signed, err := types.SignNewTx(s.key, types.LatestSignerForChainID((*big.Int)(args.ChainID)), data)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+func (s *TestSignerServer) signMessage(address ethcommon.Address, data []byte) (hexutil.Bytes, error) {
+	if address != s.address {
+		return nil, errors.Errorf("unknown account %v", address)
+	}
+	sig, err := crypto.Sign(accounts.TextHash(data), s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+type testEthAPI struct {
+	s *TestSignerServer
+}
+
+func (api *testEthAPI) Accounts() []ethcommon.Address {
+	return []ethcommon.Address{api.s.address}
+}
+
+func (api *testEthAPI) SignTransaction(args txArgs) (hexutil.Bytes, error) {
+	return api.s.signTransaction(args)
+}
+
+func (api *testEthAPI) Sign(address ethcommon.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	return api.s.signMessage(address, data)
+}
+
+type testAccountAPI struct {
+	s *TestSignerServer
+}
+
+func (api *testAccountAPI) List() []ethcommon.Address {
+	return []ethcommon.Address{api.s.address}
+}
+
+func (api *testAccountAPI) SignTransaction(args txArgs) (*signTransactionResult, error) {
+	raw, err := api.s.signTransaction(args)
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw}, nil
+}
+
+func (api *testAccountAPI) SignData(contentType string, address ethcommon.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if contentType != accounts.MimetypeTextPlain {
+		return nil, errors.Errorf("unsupported content type %v", contentType)
+	}
+	return api.s.signMessage(address, data)
+}
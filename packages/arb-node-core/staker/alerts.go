@@ -0,0 +1,156 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package staker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+type AlertKind string
+
+const (
+	// InvalidNodeAlert is raised for a node whose assertion doesn't match
+	// local execution
+	InvalidNodeAlert AlertKind = "invalid"
+	// ConflictingNodeAlert is raised for a node which makes a different
+	// assertion from the same starting state as an earlier node
+	ConflictingNodeAlert AlertKind = "conflict"
+)
+
+// Divergence describes the first point at which a node's claimed execution
+// state differs from local execution
+type Divergence struct {
+	TotalGasConsumed *big.Int `json:"totalGasConsumed"`
+	Field            string   `json:"field"`
+	Expected         string   `json:"expected"`
+	Claimed          string   `json:"claimed"`
+}
+
+type Alert struct {
+	Kind          AlertKind           `json:"kind"`
+	Node          *big.Int            `json:"node"`
+	NodeHash      ethcommon.Hash      `json:"nodeHash"`
+	ProposedBlock *big.Int            `json:"proposedBlock"`
+	Stakers       []ethcommon.Address `json:"stakers"`
+	// Set for conflicting nodes, the earlier node making a different assertion
+	ConflictingNode *big.Int    `json:"conflictingNode,omitempty"`
+	Divergence      *Divergence `json:"divergence,omitempty"`
+	Time            time.Time   `json:"time"`
+}
+
+// AlertSink is notified whenever the watchtower finds a problem with a node
+type AlertSink interface {
+	SendAlert(ctx context.Context, alert *Alert) error
+}
+
+// WebhookAlertSink POSTs each alert as JSON to a URL
+type WebhookAlertSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookAlertSink(url string) *WebhookAlertSink {
+	return &WebhookAlertSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookAlertSink) SendAlert(ctx context.Context, alert *Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error sending alert webhook")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("alert webhook returned status %v", resp.Status)
+	}
+	return nil
+}
+
+// LogFileAlertSink appends each alert as a line of JSON to a file
+type LogFileAlertSink struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func NewLogFileAlertSink(path string) *LogFileAlertSink {
+	return &LogFileAlertSink{path: path}
+}
+
+func (s *LogFileAlertSink) SendAlert(_ context.Context, alert *Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "error opening alert log")
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return errors.Wrap(err, "error writing alert log")
+	}
+	return errors.WithStack(file.Close())
+}
+
+// ExitAlertSink signals on Done with its exit code after the first alert so
+// that the process can exit and be noticed by whatever supervises it
+type ExitAlertSink struct {
+	code int
+	done chan int
+	once sync.Once
+}
+
+func NewExitAlertSink(code int) *ExitAlertSink {
+	return &ExitAlertSink{
+		code: code,
+		done: make(chan int, 1),
+	}
+}
+
+func (s *ExitAlertSink) SendAlert(context.Context, *Alert) error {
+	s.once.Do(func() {
+		s.done <- s.code
+	})
+	return nil
+}
+
+func (s *ExitAlertSink) Done() <-chan int {
+	return s.done
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package staker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+)
+
+func testAlert() *Alert {
+	return &Alert{
+		Kind:          InvalidNodeAlert,
+		Node:          big.NewInt(7),
+		NodeHash:      common.RandHash().ToEthHash(),
+		ProposedBlock: big.NewInt(100),
+		Stakers:       []ethcommon.Address{common.RandAddress().ToEthAddress()},
+		Divergence: &Divergence{
+			TotalGasConsumed: big.NewInt(5000),
+			Field:            "sendAcc",
+		},
+	}
+}
+
+func TestAlertSinks(t *testing.T) {
+	ctx := context.Background()
+	alert := testAlert()
+
+	received := make(chan *Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var posted Alert
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			t.Error(err)
+		}
+		received <- &posted
+	}))
+	defer server.Close()
+	test.FailIfError(t, NewWebhookAlertSink(server.URL).SendAlert(ctx, alert))
+	posted := <-received
+	if posted.Node.Cmp(alert.Node) != 0 || posted.Stakers[0] != alert.Stakers[0] || posted.Divergence.Field != "sendAcc" {
+		t.Error("webhook received wrong alert", posted)
+	}
+
+	dir, err := ioutil.TempDir("", "alerts")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(dir)
+	logPath := filepath.Join(dir, "alerts.log")
+	logSink := NewLogFileAlertSink(logPath)
+	test.FailIfError(t, logSink.SendAlert(ctx, alert))
+	test.FailIfError(t, logSink.SendAlert(ctx, alert))
+	file, err := os.Open(logPath)
+	test.FailIfError(t, err)
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var logged Alert
+		test.FailIfError(t, json.Unmarshal(scanner.Bytes(), &logged))
+		if logged.NodeHash != alert.NodeHash {
+			t.Error("logged wrong alert")
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Error("expected 2 logged alerts but found", lines)
+	}
+
+	exitSink := NewExitAlertSink(3)
+	test.FailIfError(t, exitSink.SendAlert(ctx, alert))
+	test.FailIfError(t, exitSink.SendAlert(ctx, alert))
+	if code := <-exitSink.Done(); code != 3 {
+		t.Error("wrong exit code", code)
+	}
+}
+
+func TestCompareExecutionStates(t *testing.T) {
+	expected := &core.ExecutionState{
+		MachineHash:       common.RandHash(),
+		TotalMessagesRead: big.NewInt(10),
+		TotalGasConsumed:  big.NewInt(1000),
+		TotalSendCount:    big.NewInt(2),
+		TotalLogCount:     big.NewInt(3),
+		SendAcc:           common.RandHash(),
+		LogAcc:            common.RandHash(),
+	}
+	claimed := *expected
+	claimed.TotalLogCount = big.NewInt(4)
+	claimed.SendAcc = common.RandHash()
+
+	divergence := compareExecutionStates(expected, &claimed)
+	if divergence.Field != "sendAcc" {
+		t.Error("wrong divergence field", divergence.Field)
+	}
+	if divergence.Expected != expected.SendAcc.String() || divergence.Claimed != claimed.SendAcc.String() {
+		t.Error("wrong divergence values", divergence)
+	}
+	if divergence.TotalGasConsumed.Cmp(big.NewInt(1000)) != 0 {
+		t.Error("wrong divergence gas", divergence.TotalGasConsumed)
+	}
+}
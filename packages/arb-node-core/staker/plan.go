@@ -0,0 +1,90 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package staker
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/challenge"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+// walletAction is a transaction the staker decided to send through its
+// wallet, which can either be sent or described without sending it
+type walletAction interface {
+	execute(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*types.Transaction, error)
+	plan(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*ethbridge.Plan, error)
+}
+
+type returnOldDepositsAction []common.Address
+
+func (a returnOldDepositsAction) execute(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*types.Transaction, error) {
+	return wallet.ReturnOldDeposits(ctx, a)
+}
+
+func (a returnOldDepositsAction) plan(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*ethbridge.Plan, error) {
+	return wallet.PlanReturnOldDeposits(ctx, a)
+}
+
+type timeoutChallengesAction []common.Address
+
+func (a timeoutChallengesAction) execute(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*types.Transaction, error) {
+	return wallet.TimeoutChallenges(ctx, a)
+}
+
+func (a timeoutChallengesAction) plan(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*ethbridge.Plan, error) {
+	return wallet.PlanTimeoutChallenges(ctx, a)
+}
+
+// executeTransactionsAction sends the calls collected in the builder
+type executeTransactionsAction struct {
+	builder *ethbridge.BuilderBackend
+	// Challenger that added its pending move to the builder in the same
+	// round, if any
+	challenger *challenge.Challenger
+}
+
+func (a executeTransactionsAction) execute(ctx context.Context, wallet *ethbridge.ValidatorWallet) (*types.Transaction, error) {
+	tx, err := wallet.ExecuteTransactions(ctx, a.builder)
+	if err != nil || tx == nil || a.challenger == nil {
+		return tx, err
+	}
+	if err := a.challenger.MoveSent(tx.Hash()); err != nil {
+		logger.Warn().Err(err).Msg("error journaling challenge transaction")
+	}
+	return tx, nil
+}
+
+func (a executeTransactionsAction) plan(_ context.Context, wallet *ethbridge.ValidatorWallet) (*ethbridge.Plan, error) {
+	return wallet.PlanTransactions(a.builder), nil
+}
+
+// Plan runs the same decision logic as Act and returns the calls it would
+// make without sending them
+func (s *Staker) Plan(ctx context.Context) (*ethbridge.Plan, error) {
+	action, err := s.decide(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if action == nil {
+		return &ethbridge.Plan{Wallet: s.wallet.Address().ToEthAddress()}, nil
+	}
+	return action.plan(ctx, s.wallet)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package staker
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+var (
+	StakeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "arbitrum",
+		Subsystem: "validator",
+		Name:      "stake",
+		Help:      "Amount staked by the validator wallet in ETH",
+	})
+	RequiredStakeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "arbitrum",
+		Subsystem: "validator",
+		Name:      "required_stake",
+		Help:      "Current required stake of the rollup in ETH",
+	})
+	WithdrawableFundsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "arbitrum",
+		Subsystem: "validator",
+		Name:      "withdrawable_funds",
+		Help:      "Funds the rollup holds for the validator wallet in ETH",
+	})
+	StakeChangeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "arbitrum",
+		Subsystem: "validator",
+		Name:      "stake_changes",
+		Help:      "Stake management calls added to validator transactions",
+	}, []string{"action"})
+)
+
+// StakePolicy controls how the staker manages its stake once it's placed
+type StakePolicy struct {
+	// Add to the stake when the required stake rises above it
+	TopUp bool
+	// Reduce the stake to the required stake when it falls
+	WithdrawExcess bool
+	// Where to send funds the rollup holds for the wallet, such as returned
+	// deposits and withdrawn stake. They're left in the rollup if nil.
+	ColdAddress *common.Address
+	// Maximum amount the wallet may have staked, nil for no limit
+	MaxCommitment *big.Int
+}
+
+// allows returns whether the policy permits having the given amount staked
+func (p StakePolicy) allows(amount *big.Int) bool {
+	return p.MaxCommitment == nil || amount.Cmp(p.MaxCommitment) <= 0
+}
+
+type stakeState struct {
+	required     *big.Int
+	withdrawable *big.Int
+}
+
+func weiToEth(wei *big.Int) float64 {
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
+	return eth
+}
+
+func (s *Staker) lookupStakeState(ctx context.Context, info *ethbridge.StakerInfo) (*stakeState, error) {
+	required, err := s.rollup.CurrentRequiredStake(ctx)
+	if err != nil {
+		return nil, err
+	}
+	withdrawable, err := s.rollup.WithdrawableFunds(ctx, s.wallet.Address())
+	if err != nil {
+		return nil, err
+	}
+	staked := big.NewInt(0)
+	if info != nil {
+		staked = info.AmountStaked
+	}
+	StakeGauge.Set(weiToEth(staked))
+	RequiredStakeGauge.Set(weiToEth(required))
+	WithdrawableFundsGauge.Set(weiToEth(withdrawable))
+	return &stakeState{
+		required:     required,
+		withdrawable: withdrawable,
+	}, nil
+}
+
+// topUpStake adds to the stake if the required stake has risen above it, so
+// that the staker can keep creating nodes
+func (s *Staker) topUpStake(ctx context.Context, info *ethbridge.StakerInfo, state *stakeState) error {
+	if !s.StakePolicy.TopUp || info == nil || info.CurrentChallenge != nil || info.AmountStaked.Cmp(state.required) >= 0 {
+		return nil
+	}
+	if !s.StakePolicy.allows(state.required) {
+		logger.Warn().
+			Str("required", state.required.String()).
+			Str("max", s.StakePolicy.MaxCommitment.String()).
+			Msg("Required stake is above the maximum commitment, not topping up stake")
+		return nil
+	}
+	amount := new(big.Int).Sub(state.required, info.AmountStaked)
+	logger.Info().
+		Str("amount", amount.String()).
+		Str("required", state.required.String()).
+		Msg("Topping up stake")
+	StakeChangeCounter.WithLabelValues("top_up").Inc()
+	return s.rollup.AddToDeposit(ctx, s.wallet.Address(), amount)
+}
+
+// releaseFunds reduces the stake to the required amount and sends anything the
+// rollup holds for the wallet to the cold address, as the policy allows. It
+// returns whether any calls were added.
+func (s *Staker) releaseFunds(ctx context.Context, info *ethbridge.StakerInfo, state *stakeState) (bool, error) {
+	reduced := false
+	if s.StakePolicy.WithdrawExcess && info != nil && info.CurrentChallenge == nil && info.AmountStaked.Cmp(state.required) > 0 {
+		logger.Info().
+			Str("amount", new(big.Int).Sub(info.AmountStaked, state.required).String()).
+			Str("required", state.required.String()).
+			Msg("Withdrawing excess stake")
+		StakeChangeCounter.WithLabelValues("reduce").Inc()
+		if err := s.rollup.ReduceDeposit(ctx, state.required); err != nil {
+			return false, err
+		}
+		reduced = true
+	}
+	// Reducing the deposit adds to the withdrawable funds
+	if s.StakePolicy.ColdAddress == nil || (state.withdrawable.Sign() == 0 && !reduced) {
+		return reduced, nil
+	}
+	logger.Info().
+		Str("withdrawable", state.withdrawable.String()).
+		Str("destination", s.StakePolicy.ColdAddress.String()).
+		Msg("Withdrawing funds to cold address")
+	StakeChangeCounter.WithLabelValues("withdraw").Inc()
+	if err := s.rollup.WithdrawStakerFunds(ctx, *s.StakePolicy.ColdAddress); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package staker
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-avm-cpp/cmachine"
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/arbos"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridgecontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/monitor"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+func TestStakePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	arbosPath, err := arbos.Path()
+	test.FailIfError(t, err)
+	mach, err := cmachine.New(arbosPath)
+	test.FailIfError(t, err)
+
+	clnt, pks := test.SimulatedBackend(t)
+	auth := test.SimulatedTransactor(t, pks[0])
+	ownerAuth := test.SimulatedTransactor(t, pks[2])
+	client := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
+
+	baseStake := big.NewInt(100)
+	rollupAddr := deployRollup(
+		t,
+		auth,
+		client,
+		mach.Hash(),
+		big.NewInt(100),
+		big.NewInt(0),
+		big.NewInt(10000000),
+		baseStake,
+		common.Address{},
+		common.NewAddressFromEth(ownerAuth.From),
+		common.NewAddressFromEth(ownerAuth.From),
+		big.NewInt(60),
+		big.NewInt(900),
+		nil,
+	)
+	validatorUtilsAddr, _, _, err := ethbridgecontracts.DeployValidatorUtils(auth, client)
+	test.FailIfError(t, err)
+	validatorWalletFactory, _, _, err := ethbridgecontracts.DeployValidatorWalletCreator(auth, client)
+	test.FailIfError(t, err)
+	valAuth, err := ethbridge.NewTransactAuth(ctx, client, auth, "")
+	test.FailIfError(t, err)
+	validatorAddress, err := ethbridge.CreateValidatorWallet(ctx, validatorWalletFactory, 0, valAuth, client)
+	test.FailIfError(t, err)
+	client.Commit()
+
+	rollupAdmin, err := ethbridgecontracts.NewRollupAdminFacet(rollupAddr, client)
+	test.FailIfError(t, err)
+	_, err = rollupAdmin.SetValidator(ownerAuth, []ethcommon.Address{validatorAddress}, []bool{true})
+	test.FailIfError(t, err)
+	client.Commit()
+
+	mon, shutdown := monitor.PrepareArbCore(t)
+	defer shutdown()
+
+	val, err := ethbridge.NewValidator(validatorAddress, rollupAddr, client, valAuth)
+	test.FailIfError(t, err)
+	staker, _, err := NewStaker(ctx, mon.Core, client, val, 0, common.NewAddressFromEth(validatorUtilsAddr), MakeNodesStrategy)
+	test.FailIfError(t, err)
+
+	execute := func() {
+		t.Helper()
+		_, err := staker.wallet.ExecuteTransactions(ctx, staker.builder)
+		test.FailIfError(t, err)
+		client.Commit()
+	}
+	stakerInfo := func() *ethbridge.StakerInfo {
+		t.Helper()
+		info, err := staker.rollup.StakerInfo(ctx, staker.wallet.Address())
+		test.FailIfError(t, err)
+		if info == nil {
+			t.Fatal("validator isn't staked")
+		}
+		return info
+	}
+
+	// The maximum commitment stops the staker from staking at all
+	staker.StakePolicy.MaxCommitment = big.NewInt(50)
+	created, err := staker.newStake(ctx)
+	test.FailIfError(t, err)
+	if created || staker.builder.TransactionCount() != 0 {
+		t.Fatal("staked more than the maximum commitment")
+	}
+
+	staker.StakePolicy.MaxCommitment = big.NewInt(200)
+	created, err = staker.newStake(ctx)
+	test.FailIfError(t, err)
+	if !created {
+		t.Fatal("didn't stake within the maximum commitment")
+	}
+	execute()
+	info := stakerInfo()
+	if info.AmountStaked.Cmp(baseStake) != 0 {
+		t.Fatal("staked", info.AmountStaked, "instead of", baseStake)
+	}
+
+	// Topping up is opt in
+	raised := &stakeState{required: big.NewInt(150), withdrawable: big.NewInt(0)}
+	test.FailIfError(t, staker.topUpStake(ctx, info, raised))
+	if staker.builder.TransactionCount() != 0 {
+		t.Fatal("topped up stake without the policy allowing it")
+	}
+
+	staker.StakePolicy.TopUp = true
+	test.FailIfError(t, staker.topUpStake(ctx, info, raised))
+	if staker.builder.TransactionCount() != 1 {
+		t.Fatal("didn't top up stake")
+	}
+	execute()
+	info = stakerInfo()
+	if info.AmountStaked.Cmp(raised.required) != 0 {
+		t.Fatal("topped up stake to", info.AmountStaked, "instead of", raised.required)
+	}
+
+	// The maximum commitment caps topping up too
+	test.FailIfError(t, staker.topUpStake(ctx, info, &stakeState{required: big.NewInt(300), withdrawable: big.NewInt(0)}))
+	if staker.builder.TransactionCount() != 0 {
+		t.Fatal("topped up stake past the maximum commitment")
+	}
+
+	// Withdrawing excess stake is opt in
+	lowered := &stakeState{required: baseStake, withdrawable: big.NewInt(0)}
+	released, err := staker.releaseFunds(ctx, info, lowered)
+	test.FailIfError(t, err)
+	if released || staker.builder.TransactionCount() != 0 {
+		t.Fatal("reduced stake without the policy allowing it")
+	}
+
+	staker.StakePolicy.WithdrawExcess = true
+	released, err = staker.releaseFunds(ctx, info, lowered)
+	test.FailIfError(t, err)
+	if !released {
+		t.Fatal("didn't reduce stake")
+	}
+	execute()
+	info = stakerInfo()
+	if info.AmountStaked.Cmp(baseStake) != 0 {
+		t.Fatal("reduced stake to", info.AmountStaked, "instead of", baseStake)
+	}
+	withdrawable, err := staker.rollup.WithdrawableFunds(ctx, staker.wallet.Address())
+	test.FailIfError(t, err)
+	if withdrawable.Cmp(big.NewInt(50)) != 0 {
+		t.Error("reducing stake left", withdrawable, "withdrawable instead of 50")
+	}
+}
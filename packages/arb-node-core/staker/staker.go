@@ -20,6 +20,7 @@ import (
 	"context"
 	"math/big"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
@@ -49,6 +50,19 @@ type Staker struct {
 	activeChallenge *challenge.Challenger
 	strategy        Strategy
 	fromBlock       int64
+	// Log the transactions Act decides on instead of sending them
+	DryRun bool
+	// Records challenge moves so they can be resumed after a restart, may be nil
+	ChallengeJournal *challenge.Journal
+	StakePolicy      StakePolicy
+	// Whether challenges which ended while the validator wasn't running have
+	// been finished in the journal
+	staleChallengesFinished bool
+
+	statusMutex       sync.Mutex
+	effectiveStrategy *Strategy
+	lastPlan          *ethbridge.Plan
+	lastAct           *ActResult
 }
 
 func NewStaker(
@@ -82,12 +96,13 @@ func (s *Staker) RunInBackground(ctx context.Context) chan bool {
 			tx, err := s.Act(ctx)
 			if err == nil && tx != nil {
 				// Note: methodName isn't accurate, it's just used for logging
-				_, err = ethbridge.WaitForReceiptWithResults(ctx, s.client, s.wallet.From().ToEthAddress(), tx, "for staking")
+				_, err = s.wallet.WaitForReceipt(ctx, tx, "for staking")
 				err = errors.Wrap(err, "error waiting for tx receipt")
 				if err == nil {
 					logger.Info().Str("hash", tx.Hash().String()).Msg("Successfully executed transaction")
 				}
 			}
+			s.recordAct(tx, err)
 			if err != nil {
 				logger.Warn().Err(err).Send()
 				<-time.After(backoff)
@@ -108,6 +123,25 @@ func (s *Staker) RunInBackground(ctx context.Context) chan bool {
 }
 
 func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
+	action, err := s.decide(ctx)
+	if err != nil || action == nil {
+		return nil, err
+	}
+	if s.DryRun {
+		plan, err := action.plan(ctx, s.wallet)
+		if err != nil {
+			return nil, err
+		}
+		s.setLastPlan(plan)
+		logger.Info().Interface("plan", plan).Msg("Dry run, not sending transaction")
+		return nil, nil
+	}
+	return action.execute(ctx, s.wallet)
+}
+
+// decide picks the next transaction to send through the wallet, or nil if
+// there is nothing useful to do
+func (s *Staker) decide(ctx context.Context) (walletAction, error) {
 	s.builder.ClearTransactions()
 	rawInfo, err := s.rollup.StakerInfo(ctx, s.wallet.Address())
 	if err != nil {
@@ -138,6 +172,12 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			effectiveStrategy = StakeLatestStrategy
 		}
 	}
+	s.setEffectiveStrategy(effectiveStrategy)
+
+	stake, err := s.lookupStakeState(ctx, rawInfo)
+	if err != nil {
+		return nil, err
+	}
 
 	// Resolve nodes if either we're on the make nodes strategy,
 	// or we're on the stake latest strategy but don't have a stake
@@ -150,13 +190,13 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 		}
 	}
 	if shouldResolveNodes {
-		tx, err := s.removeOldStakers(ctx)
-		if err != nil || tx != nil {
-			return tx, err
+		action, err := s.removeOldStakers(ctx)
+		if err != nil || action != nil {
+			return action, err
 		}
-		tx, err = s.resolveTimedOutChallenges(ctx)
-		if err != nil || tx != nil {
-			return tx, err
+		action, err = s.resolveTimedOutChallenges(ctx)
+		if err != nil || action != nil {
+			return action, err
 		}
 		if err := s.resolveNextNode(ctx, rawInfo, s.fromBlock); err != nil {
 			return nil, err
@@ -167,16 +207,25 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 	// as that might affect the current required stake.
 	creatingNewStake := rawInfo == nil && s.builder.TransactionCount() == 0
 	if creatingNewStake {
-		if err := s.newStake(ctx); err != nil {
+		creatingNewStake, err = s.newStake(ctx)
+		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Challenger whose move, if it made one, is among this round's calls
+	var challenger *challenge.Challenger
 	if rawInfo != nil {
 		if err = s.handleConflict(ctx, rawInfo); err != nil {
 			return nil, err
 		}
+		challenger = s.activeChallenge
 	}
+	txCountBeforeTopUp := s.builder.TransactionCount()
+	if err := s.topUpStake(ctx, rawInfo, stake); err != nil {
+		return nil, err
+	}
+	topUpCount := s.builder.TransactionCount() - txCountBeforeTopUp
 	if rawInfo != nil || creatingNewStake {
 		// Advance stake up to 20 times in one transaction
 		for i := 0; info.CanProgress && i < 20; i++ {
@@ -185,7 +234,7 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			}
 		}
 	}
-	if rawInfo != nil && s.builder.TransactionCount() == 0 {
+	if rawInfo != nil && s.builder.TransactionCount() == topUpCount {
 		if err := s.createConflict(ctx, rawInfo); err != nil {
 			return nil, err
 		}
@@ -196,16 +245,36 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 		txCount--
 	}
 	if txCount == 0 {
-		return nil, nil
+		// Nothing else to do, so release any funds the policy doesn't
+		// need committed
+		s.builder.ClearTransactions()
+		released, err := s.releaseFunds(ctx, rawInfo, stake)
+		if err != nil || !released {
+			return nil, err
+		}
+		return executeTransactionsAction{builder: s.builder}, nil
 	}
 	if creatingNewStake {
 		logger.Info().Msg("Staking to execute transactions")
 	}
-	return s.wallet.ExecuteTransactions(ctx, s.builder)
+	return executeTransactionsAction{builder: s.builder, challenger: challenger}, nil
 }
 
 func (s *Staker) handleConflict(ctx context.Context, info *ethbridge.StakerInfo) error {
+	if s.ChallengeJournal != nil && !s.staleChallengesFinished {
+		if err := s.ChallengeJournal.FinishStaleChallenges(info.CurrentChallenge); err != nil {
+			logger.Warn().Err(err).Msg("error journaling end of stale challenges")
+		} else {
+			s.staleChallengesFinished = true
+		}
+	}
+
 	if info.CurrentChallenge == nil {
+		if s.activeChallenge != nil && s.ChallengeJournal != nil {
+			if err := s.ChallengeJournal.FinishChallenge(s.activeChallenge.ChallengeAddress()); err != nil {
+				logger.Warn().Err(err).Msg("error journaling end of challenge")
+			}
+		}
 		s.activeChallenge = nil
 		return nil
 	}
@@ -228,25 +297,33 @@ func (s *Staker) handleConflict(ctx context.Context, info *ethbridge.StakerInfo)
 			return err
 		}
 
-		s.activeChallenge = challenge.NewChallenger(challengeCon, s.sequencerInbox, s.lookup, nodeInfo, s.wallet.Address())
+		s.activeChallenge = challenge.NewChallenger(challengeCon, s.sequencerInbox, s.lookup, nodeInfo, s.wallet.Address(), s.ChallengeJournal)
 	}
 
 	return s.activeChallenge.HandleConflict(ctx)
 }
 
-func (s *Staker) newStake(ctx context.Context) error {
+// newStake returns whether it added a call to create a stake
+func (s *Staker) newStake(ctx context.Context) (bool, error) {
 	info, err := s.rollup.StakerInfo(ctx, s.wallet.Address())
 	if err != nil {
-		return err
+		return false, err
 	}
 	if info != nil {
-		return nil
+		return false, nil
 	}
 	stakeAmount, err := s.rollup.CurrentRequiredStake(ctx)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if !s.StakePolicy.allows(stakeAmount) {
+		logger.Warn().
+			Str("required", stakeAmount.String()).
+			Str("max", s.StakePolicy.MaxCommitment.String()).
+			Msg("Required stake is above the maximum commitment, not staking")
+		return false, nil
 	}
-	return s.rollup.NewStake(ctx, stakeAmount)
+	return true, s.rollup.NewStake(ctx, stakeAmount)
 }
 
 func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiveStrategy Strategy) error {
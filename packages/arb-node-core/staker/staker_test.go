@@ -149,10 +149,10 @@ func runStakersTest(t *testing.T, faultConfig challenge.FaultConfig, maxGasPerNo
 	var extraConfig []byte
 
 	clnt, pks := test.SimulatedBackend(t)
-	auth := bind.NewKeyedTransactor(pks[0])
-	auth2 := bind.NewKeyedTransactor(pks[1])
-	seqAuth := bind.NewKeyedTransactor(pks[2])
-	ownerAuth := bind.NewKeyedTransactor(pks[2])
+	auth := test.SimulatedTransactor(t, pks[0])
+	auth2 := test.SimulatedTransactor(t, pks[1])
+	seqAuth := test.SimulatedTransactor(t, pks[2])
+	ownerAuth := test.SimulatedTransactor(t, pks[2])
 	sequencer := common.NewAddressFromEth(seqAuth.From)
 	client := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
 
@@ -276,7 +276,7 @@ func runStakersTest(t *testing.T, faultConfig challenge.FaultConfig, maxGasPerNo
 	const largeChannelBuffer = 200
 	healthChan := make(chan nodehealth.Log, largeChannelBuffer)
 	healthChan <- nodehealth.Log{Config: true, Var: "disablePrimaryCheck", ValBool: false}
-	healthChan <- nodehealth.Log{Config: true, Var: "disableOpenEthereumCheck", ValBool: true}
+	healthChan <- nodehealth.Log{Config: true, Var: "disableL1Check", ValBool: true}
 	healthChan <- nodehealth.Log{Config: true, Var: "healthcheckMetrics", ValBool: false}
 	healthChan <- nodehealth.Log{Config: true, Var: "healthcheckRPC", ValStr: "0.0.0.0:8080"}
 	nodehealth.Init(healthChan)
@@ -289,7 +289,7 @@ func runStakersTest(t *testing.T, faultConfig challenge.FaultConfig, maxGasPerNo
 	// Make a dummy feed for now
 	var sequencerFeed chan broadcaster.BroadcastFeedMessage
 
-	_, err = mon.StartInboxReader(ctx, client, common.NewAddressFromEth(rollupAddr), 0, common.NewAddressFromEth(bridgeUtilsAddr), healthChan, sequencerFeed)
+	_, err = mon.StartInboxReader(ctx, client, common.NewAddressFromEth(rollupAddr), 0, common.NewAddressFromEth(bridgeUtilsAddr), monitor.InboxReaderConfig{}, healthChan, sequencerFeed)
 	test.FailIfError(t, err)
 
 	for i := 1; i <= 10; i++ {
@@ -393,6 +393,18 @@ func runStakersTest(t *testing.T, faultConfig challenge.FaultConfig, maxGasPerNo
 		t.Fatal("Staker didn't stake on node")
 	}
 
+	status, err := staker.Status(ctx)
+	test.FailIfError(t, err)
+	if !status.Staked || status.LatestStakedNode.Cmp(stakerInfo.LatestStakedNode) != 0 {
+		t.Error("Status reported wrong stake", status.Staked, status.LatestStakedNode)
+	}
+	if status.ActiveChallenge != nil {
+		t.Error("Status reported active challenge", status.ActiveChallenge.Address)
+	}
+	if status.EffectiveStrategy != MakeNodesStrategy.String() {
+		t.Error("Status reported wrong effective strategy", status.EffectiveStrategy)
+	}
+
 	faultyStakerInfo, err := staker.rollup.StakerInfo(ctx, common.NewAddressFromEth(validatorAddress2))
 	test.FailIfError(t, err)
 
@@ -400,6 +412,7 @@ func runStakersTest(t *testing.T, faultConfig challenge.FaultConfig, maxGasPerNo
 		if faultyStakerInfo != nil {
 			t.Fatal("Faulty staker is still staked")
 		}
+		checkFundsReleased(ctx, t, staker, client, status.WithdrawableFunds)
 	} else {
 		if faultyStakerInfo == nil {
 			t.Fatal("Other staker lost stake")
@@ -407,6 +420,28 @@ func runStakersTest(t *testing.T, faultConfig challenge.FaultConfig, maxGasPerNo
 	}
 }
 
+// checkFundsReleased makes sure the staker sends the winnings of a challenge
+// to its cold address once it's set
+func checkFundsReleased(ctx context.Context, t *testing.T, staker *Staker, client *ethutils.SimulatedEthClient, withdrawable *big.Int) {
+	t.Helper()
+	if withdrawable.Sign() <= 0 {
+		t.Fatal("Staker didn't receive any funds from the challenge")
+	}
+	coldAddress := common.RandAddress()
+	staker.StakePolicy.ColdAddress = &coldAddress
+	for i := 0; i < 10; i++ {
+		_, err := staker.Act(ctx)
+		test.FailIfError(t, err)
+		client.Commit()
+		balance, err := client.BalanceAt(ctx, coldAddress.ToEthAddress(), nil)
+		test.FailIfError(t, err)
+		if balance.Cmp(withdrawable) == 0 {
+			return
+		}
+	}
+	t.Fatal("Staker didn't withdraw its funds to the cold address")
+}
+
 func calculateGasToFirstInbox(t *testing.T) *big.Int {
 	mon, shutdown := monitor.PrepareArbCore(t)
 	defer shutdown()
@@ -0,0 +1,261 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package staker
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/challenge"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case WatchtowerStrategy:
+		return "Watchtower"
+	case DefensiveStrategy:
+		return "Defensive"
+	case StakeLatestStrategy:
+		return "StakeLatest"
+	case MakeNodesStrategy:
+		return "MakeNodes"
+	default:
+		return "Unknown"
+	}
+}
+
+// ActResult records the outcome of a single round of Act
+type ActResult struct {
+	Time        time.Time       `json:"time"`
+	Transaction *ethcommon.Hash `json:"transaction,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	// The transaction that would have been sent in dry run mode
+	Plan *ethbridge.Plan `json:"plan,omitempty"`
+}
+
+type ChallengeStatus struct {
+	Address ethcommon.Address `json:"address"`
+	Turn    string            `json:"turn"`
+	OurTurn bool              `json:"ourTurn"`
+}
+
+// ExecutionStatus compares local execution to the latest node on chain
+type ExecutionStatus struct {
+	MessagesRead               *big.Int `json:"messagesRead"`
+	TotalGasConsumed           *big.Int `json:"totalGasConsumed"`
+	LatestNodeCreated          *big.Int `json:"latestNodeCreated"`
+	LatestNodeMessagesRead     *big.Int `json:"latestNodeMessagesRead,omitempty"`
+	LatestNodeTotalGasConsumed *big.Int `json:"latestNodeTotalGasConsumed,omitempty"`
+}
+
+type Status struct {
+	Wallet   ethcommon.Address `json:"wallet"`
+	Strategy string            `json:"strategy"`
+	DryRun   bool              `json:"dryRun"`
+	// Strategy used by the last round of Act, which may be more aggressive
+	// than the configured one if a fork was detected
+	EffectiveStrategy    string           `json:"effectiveStrategy,omitempty"`
+	Staked               bool             `json:"staked"`
+	LatestStakedNode     *big.Int         `json:"latestStakedNode"`
+	LatestStakedNodeHash ethcommon.Hash   `json:"latestStakedNodeHash"`
+	AmountStaked         *big.Int         `json:"amountStaked"`
+	CurrentRequiredStake *big.Int         `json:"currentRequiredStake"`
+	WithdrawableFunds    *big.Int         `json:"withdrawableFunds"`
+	ActiveChallenge      *ChallengeStatus `json:"activeChallenge,omitempty"`
+	LastAct              *ActResult       `json:"lastAct,omitempty"`
+	Execution            ExecutionStatus  `json:"execution"`
+}
+
+func (s *Staker) setEffectiveStrategy(strategy Strategy) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+	s.effectiveStrategy = &strategy
+}
+
+func (s *Staker) setLastPlan(plan *ethbridge.Plan) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+	s.lastPlan = plan
+}
+
+func (s *Staker) recordAct(tx *types.Transaction, err error) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+	result := &ActResult{
+		Time: time.Now(),
+		Plan: s.lastPlan,
+	}
+	if tx != nil {
+		hash := tx.Hash()
+		result.Transaction = &hash
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	s.lastAct = result
+	s.lastPlan = nil
+}
+
+// Status reports what the staker currently believes about its stake and the
+// chain, along with the result of its last action
+func (s *Staker) Status(ctx context.Context) (*Status, error) {
+	s.statusMutex.Lock()
+	status := &Status{
+		Wallet:   s.wallet.Address().ToEthAddress(),
+		Strategy: s.strategy.String(),
+		DryRun:   s.DryRun,
+		LastAct:  s.lastAct,
+	}
+	if s.effectiveStrategy != nil {
+		status.EffectiveStrategy = s.effectiveStrategy.String()
+	}
+	s.statusMutex.Unlock()
+
+	info, err := s.rollup.StakerInfo(ctx, s.wallet.Address())
+	if err != nil {
+		return nil, err
+	}
+	latestStakedNode, latestStakedNodeHash, err := s.validatorUtils.LatestStaked(ctx, s.wallet.Address())
+	if err != nil {
+		return nil, err
+	}
+	status.LatestStakedNode = latestStakedNode
+	status.LatestStakedNodeHash = latestStakedNodeHash
+	status.CurrentRequiredStake, err = s.rollup.CurrentRequiredStake(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.WithdrawableFunds, err = s.rollup.WithdrawableFunds(ctx, s.wallet.Address())
+	if err != nil {
+		return nil, err
+	}
+	status.AmountStaked = big.NewInt(0)
+	if info != nil {
+		status.Staked = true
+		status.AmountStaked = info.AmountStaked
+		if info.CurrentChallenge != nil {
+			status.ActiveChallenge, err = s.challengeStatus(ctx, info.CurrentChallenge.ToEthAddress())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	status.Execution.MessagesRead = s.lookup.MachineMessagesRead()
+	status.Execution.TotalGasConsumed, err = s.lookup.GetLastMachineTotalGas()
+	if err != nil {
+		return nil, err
+	}
+	status.Execution.LatestNodeCreated, err = s.rollup.LatestNodeCreated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Execution.LatestNodeCreated.Sign() > 0 {
+		nodeInfo, err := s.rollup.RollupWatcher.LookupNode(ctx, status.Execution.LatestNodeCreated)
+		if err != nil {
+			return nil, err
+		}
+		status.Execution.LatestNodeMessagesRead = nodeInfo.Assertion.After.TotalMessagesRead
+		status.Execution.LatestNodeTotalGasConsumed = nodeInfo.Assertion.After.TotalGasConsumed
+	}
+	return status, nil
+}
+
+func (s *Staker) challengeStatus(ctx context.Context, address ethcommon.Address) (*ChallengeStatus, error) {
+	watcher, err := ethbridge.NewChallengeWatcher(address, s.fromBlock, s.client)
+	if err != nil {
+		return nil, err
+	}
+	turn, err := watcher.Turn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responder, err := watcher.CurrentResponder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &ChallengeStatus{
+		Address: address,
+		OurTurn: turn != ethbridge.NONE && responder == s.wallet.Address(),
+	}
+	switch turn {
+	case ethbridge.ASSERTER_TURN:
+		status.Turn = "asserter"
+	case ethbridge.CHALLENGER_TURN:
+		status.Turn = "challenger"
+	default:
+		status.Turn = "none"
+	}
+	return status, nil
+}
+
+// StatusAPI implements the validator RPC namespace
+type StatusAPI struct {
+	staker *Staker
+}
+
+func NewStatusAPI(staker *Staker) *StatusAPI {
+	return &StatusAPI{staker: staker}
+}
+
+func (a *StatusAPI) Status(ctx context.Context) (*Status, error) {
+	return a.staker.Status(ctx)
+}
+
+// Challenges returns the journaled history of the validator's challenges
+func (a *StatusAPI) Challenges() ([]*challenge.Record, error) {
+	if a.staker.ChallengeJournal == nil {
+		return nil, errors.New("challenge journal not enabled")
+	}
+	return a.staker.ChallengeJournal.Records()
+}
+
+// NewStatusHandler serves the validator RPC namespace, as well as the status
+// as plain JSON on GET /status for dashboards
+func NewStatusHandler(staker *Staker) (http.Handler, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("validator", NewStatusAPI(staker)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		status, err := staker.Status(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			logger.Warn().Err(err).Msg("error writing validator status")
+		}
+	})
+	mux.Handle("/", server)
+	return mux, nil
+}
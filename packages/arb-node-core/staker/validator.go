@@ -8,7 +8,6 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ethereum/go-ethereum/common/math"
-	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/challenge"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
@@ -88,7 +87,7 @@ func NewValidator(
 	}, nil
 }
 
-func (v *Validator) removeOldStakers(ctx context.Context) (*types.Transaction, error) {
+func (v *Validator) removeOldStakers(ctx context.Context) (walletAction, error) {
 	stakersToEliminate, err := v.validatorUtils.RefundableStakers(ctx)
 	if err != nil {
 		return nil, err
@@ -97,10 +96,10 @@ func (v *Validator) removeOldStakers(ctx context.Context) (*types.Transaction, e
 		return nil, nil
 	}
 	logger.Info().Int("count", len(stakersToEliminate)).Msg("Removing old stakers")
-	return v.wallet.ReturnOldDeposits(ctx, stakersToEliminate)
+	return returnOldDepositsAction(stakersToEliminate), nil
 }
 
-func (v *Validator) resolveTimedOutChallenges(ctx context.Context) (*types.Transaction, error) {
+func (v *Validator) resolveTimedOutChallenges(ctx context.Context) (walletAction, error) {
 	challengesToEliminate, err := v.validatorUtils.TimedOutChallenges(ctx, 10)
 	if err != nil {
 		return nil, err
@@ -109,7 +108,7 @@ func (v *Validator) resolveTimedOutChallenges(ctx context.Context) (*types.Trans
 		return nil, nil
 	}
 	logger.Info().Int("count", len(challengesToEliminate)).Msg("Timing out challenges")
-	return v.wallet.TimeoutChallenges(ctx, challengesToEliminate)
+	return timeoutChallengesAction(challengesToEliminate), nil
 }
 
 func (v *Validator) resolveNextNode(ctx context.Context, info *ethbridge.StakerInfo, fromBlock int64) error {
@@ -288,20 +287,9 @@ func (v *Validator) generateNodeAction(ctx context.Context, stakerInfo *OurStake
 			break
 		}
 		if correctNode == nil {
-			var batchItemEndAcc common.Hash
-			if nd.Assertion.After.TotalMessagesRead.Cmp(nd.AfterInboxBatchEndCount) == 0 {
-				batchItemEndAcc = nd.AfterInboxBatchAcc
-			} else if nd.Assertion.After.TotalMessagesRead.Cmp(big.NewInt(0)) > 0 {
-				var haveBatchEndAcc common.Hash
-				index1 := new(big.Int).Sub(nd.Assertion.After.TotalMessagesRead, big.NewInt(1))
-				index2 := new(big.Int).Sub(nd.AfterInboxBatchEndCount, big.NewInt(1))
-				batchItemEndAcc, haveBatchEndAcc, err = v.lookup.GetInboxAccPair(index1, index2)
-				if err != nil {
-					return nil, false, err
-				}
-				if haveBatchEndAcc != nd.AfterInboxBatchAcc {
-					return nil, false, errors.New("inbox reorg detected by batch end acc mismatch")
-				}
+			batchItemEndAcc, err := nodeBatchItemEndAcc(v.lookup, nd)
+			if err != nil {
+				return nil, false, err
 			}
 			valid, err := core.IsAssertionValid(nd.Assertion, execTracker, batchItemEndAcc)
 			if err != nil {
@@ -401,6 +389,27 @@ func (v *Validator) generateNodeAction(ctx context.Context, stakerInfo *OurStake
 	return action, wrongNodesExist, nil
 }
 
+// nodeBatchItemEndAcc returns the inbox accumulator after the last message
+// read by the node, checking that the local inbox agrees with the node's batch
+func nodeBatchItemEndAcc(lookup core.ArbCoreLookup, nd *core.NodeInfo) (common.Hash, error) {
+	if nd.Assertion.After.TotalMessagesRead.Cmp(nd.AfterInboxBatchEndCount) == 0 {
+		return nd.AfterInboxBatchAcc, nil
+	}
+	if nd.Assertion.After.TotalMessagesRead.Cmp(big.NewInt(0)) <= 0 {
+		return common.Hash{}, nil
+	}
+	index1 := new(big.Int).Sub(nd.Assertion.After.TotalMessagesRead, big.NewInt(1))
+	index2 := new(big.Int).Sub(nd.AfterInboxBatchEndCount, big.NewInt(1))
+	batchItemEndAcc, haveBatchEndAcc, err := lookup.GetInboxAccPair(index1, index2)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if haveBatchEndAcc != nd.AfterInboxBatchAcc {
+		return common.Hash{}, errors.New("inbox reorg detected by batch end acc mismatch")
+	}
+	return batchItemEndAcc, nil
+}
+
 func (v *Validator) generateBatchEndProof(count *big.Int) ([]byte, error) {
 	if count.Cmp(big.NewInt(0)) == 0 {
 		return []byte{}, nil
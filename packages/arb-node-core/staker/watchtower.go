@@ -0,0 +1,305 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package staker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"runtime"
+	"time"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/ethbridge"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
+)
+
+type checkedNode struct {
+	number        *big.Int
+	executionHash common.Hash
+}
+
+// Watchtower checks every node created on the rollup against local execution
+// without staking, raising alerts for invalid or conflicting nodes. Unlike
+// the staker it needs no wallet.
+type Watchtower struct {
+	rollup         *ethbridge.RollupWatcher
+	validatorUtils *ethbridge.ValidatorUtils
+	lookup         core.ArbCoreLookup
+	sinks          []AlertSink
+	Interval       time.Duration
+
+	// Next node number to check
+	nextNode *big.Int
+	// Valid nodes checked so far, keyed by the cut hash of their starting
+	// state, used to spot nodes making a conflicting assertion
+	nodesByStart map[common.Hash]checkedNode
+}
+
+func NewWatchtower(
+	ctx context.Context,
+	lookup core.ArbCoreLookup,
+	client ethutils.EthClient,
+	rollupAddress common.Address,
+	fromBlock int64,
+	validatorUtilsAddress common.Address,
+	sinks []AlertSink,
+) (*Watchtower, error) {
+	rollup, err := ethbridge.NewRollupWatcher(rollupAddress.ToEthAddress(), fromBlock, client)
+	if err != nil {
+		return nil, err
+	}
+	validatorUtils, err := ethbridge.NewValidatorUtils(
+		validatorUtilsAddress.ToEthAddress(),
+		rollupAddress.ToEthAddress(),
+		client,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Confirmed nodes can no longer be disputed so there's nothing to check
+	latestConfirmed, err := rollup.LatestConfirmedNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Watchtower{
+		rollup:         rollup,
+		validatorUtils: validatorUtils,
+		lookup:         lookup,
+		sinks:          sinks,
+		Interval:       time.Minute,
+		nextNode:       new(big.Int).Add(latestConfirmed, big.NewInt(1)),
+		nodesByStart:   make(map[common.Hash]checkedNode),
+	}, nil
+}
+
+func (w *Watchtower) GetInitialMachineHash(ctx context.Context) ([32]byte, error) {
+	creationEvent, err := w.rollup.LookupCreation(ctx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return creationEvent.MachineHash, nil
+}
+
+func (w *Watchtower) RunInBackground(ctx context.Context) chan bool {
+	done := make(chan bool)
+	go func() {
+		defer func() {
+			done <- true
+		}()
+		for {
+			if err := w.Check(ctx); err != nil {
+				logger.Warn().Err(err).Msg("error checking rollup nodes")
+			}
+			// Force a GC run to clean up any execution cursors while we wait
+			delay := time.After(w.Interval)
+			runtime.GC()
+			select {
+			case <-ctx.Done():
+				return
+			case <-delay:
+			}
+		}
+	}()
+	return done
+}
+
+// Check examines every node created since the last check
+func (w *Watchtower) Check(ctx context.Context) error {
+	latestNode, err := w.rollup.LatestNodeCreated(ctx)
+	if err != nil {
+		return err
+	}
+	for w.nextNode.Cmp(latestNode) <= 0 {
+		nd, err := w.rollup.LookupNode(ctx, w.nextNode)
+		if err != nil {
+			return err
+		}
+		checked, err := w.checkNode(ctx, nd)
+		if err != nil || !checked {
+			return err
+		}
+		w.nextNode = new(big.Int).Add(w.nextNode, big.NewInt(1))
+	}
+
+	latestConfirmed, err := w.rollup.LatestConfirmedNode(ctx)
+	if err != nil {
+		return err
+	}
+	for start, checked := range w.nodesByStart {
+		if checked.number.Cmp(latestConfirmed) < 0 {
+			delete(w.nodesByStart, start)
+		}
+	}
+	return nil
+}
+
+// checkNode returns false if the node can't be checked until more messages
+// have been processed locally
+func (w *Watchtower) checkNode(ctx context.Context, nd *core.NodeInfo) (bool, error) {
+	nodeNum := (*big.Int)(nd.NodeNum)
+	coreMessageCount := w.lookup.MachineMessagesRead()
+	if coreMessageCount.Cmp(nd.Assertion.After.TotalMessagesRead) < 0 {
+		logger.Info().
+			Str("localcount", coreMessageCount.String()).
+			Str("target", nd.Assertion.After.TotalMessagesRead.String()).
+			Str("node", nodeNum.String()).
+			Msg("catching up to chain")
+		return false, nil
+	}
+
+	divergence, err := w.findDivergence(nd)
+	if err != nil {
+		return false, err
+	}
+	if divergence != nil {
+		logger.Warn().
+			Str("node", nodeNum.String()).
+			Str("field", divergence.Field).
+			Str("gas", divergence.TotalGasConsumed.String()).
+			Msg("Found node with incorrect assertion")
+		return true, w.alert(ctx, nd, &Alert{
+			Kind:       InvalidNodeAlert,
+			Divergence: divergence,
+		})
+	}
+
+	start := nd.Assertion.Before.CutHash()
+	executionHash := nd.Assertion.ExecutionHash()
+	if existing, ok := w.nodesByStart[start]; ok && existing.executionHash != executionHash {
+		// Both can't be valid since they start from the same state but differ
+		logger.Warn().
+			Str("node", nodeNum.String()).
+			Str("otherNode", existing.number.String()).
+			Msg("Found conflicting node")
+		return true, w.alert(ctx, nd, &Alert{
+			Kind:            ConflictingNodeAlert,
+			ConflictingNode: existing.number,
+		})
+	}
+	w.nodesByStart[start] = checkedNode{number: nodeNum, executionHash: executionHash}
+	logger.Info().Str("node", nodeNum.String()).Msg("Found correct node")
+	return true, nil
+}
+
+// findDivergence returns where the node's assertion first differs from local
+// execution, or nil if the assertion is valid
+func (w *Watchtower) findDivergence(nd *core.NodeInfo) (*Divergence, error) {
+	before := nd.Assertion.Before
+	cursor, err := w.lookup.GetExecutionCursor(before.TotalGasConsumed)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.TotalGasConsumed().Cmp(before.TotalGasConsumed) != 0 || cursor.MachineHash() != before.MachineHash {
+		// The node builds on a state that never existed
+		return &Divergence{
+			TotalGasConsumed: before.TotalGasConsumed,
+			Field:            "beforeMachineHash",
+			Expected:         cursor.MachineHash().String(),
+			Claimed:          before.MachineHash.String(),
+		}, nil
+	}
+
+	batchItemEndAcc, err := nodeBatchItemEndAcc(w.lookup, nd)
+	if err != nil {
+		return nil, err
+	}
+	after := nd.Assertion.After
+	execTracker := core.NewExecutionTrackerWithInitialCursor(w.lookup, false, []*big.Int{after.TotalGasConsumed}, cursor, false)
+	valid, err := core.IsAssertionValid(nd.Assertion, execTracker, batchItemEndAcc)
+	if err != nil || valid {
+		return nil, err
+	}
+	localState, _, err := execTracker.GetExecutionState(after.TotalGasConsumed)
+	if err != nil {
+		return nil, err
+	}
+	return compareExecutionStates(localState, after), nil
+}
+
+// compareExecutionStates returns the first field where the claimed state
+// differs from the expected one, in the order they're affected by execution
+func compareExecutionStates(expected, claimed *core.ExecutionState) *Divergence {
+	divergence := func(field string, expectedVal, claimedVal fmt.Stringer) *Divergence {
+		return &Divergence{
+			TotalGasConsumed: claimed.TotalGasConsumed,
+			Field:            field,
+			Expected:         expectedVal.String(),
+			Claimed:          claimedVal.String(),
+		}
+	}
+	switch {
+	case expected.TotalGasConsumed.Cmp(claimed.TotalGasConsumed) != 0:
+		return divergence("totalGasConsumed", expected.TotalGasConsumed, claimed.TotalGasConsumed)
+	case expected.TotalMessagesRead.Cmp(claimed.TotalMessagesRead) != 0:
+		return divergence("totalMessagesRead", expected.TotalMessagesRead, claimed.TotalMessagesRead)
+	case expected.MachineHash != claimed.MachineHash:
+		return divergence("machineHash", expected.MachineHash, claimed.MachineHash)
+	case expected.TotalSendCount.Cmp(claimed.TotalSendCount) != 0:
+		return divergence("totalSendCount", expected.TotalSendCount, claimed.TotalSendCount)
+	case expected.SendAcc != claimed.SendAcc:
+		return divergence("sendAcc", expected.SendAcc, claimed.SendAcc)
+	case expected.TotalLogCount.Cmp(claimed.TotalLogCount) != 0:
+		return divergence("totalLogCount", expected.TotalLogCount, claimed.TotalLogCount)
+	case expected.LogAcc != claimed.LogAcc:
+		return divergence("logAcc", expected.LogAcc, claimed.LogAcc)
+	default:
+		return &Divergence{TotalGasConsumed: claimed.TotalGasConsumed, Field: "unknown"}
+	}
+}
+
+// stakersOnNode returns the current stakers who are staked on the node
+func (w *Watchtower) stakersOnNode(ctx context.Context, node *big.Int) ([]common.Address, error) {
+	stakers, err := w.validatorUtils.GetStakers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var staked []common.Address
+	for _, staker := range stakers {
+		nodes, err := w.validatorUtils.StakedNodes(ctx, staker)
+		if err != nil {
+			return nil, err
+		}
+		for _, stakedNode := range nodes {
+			if stakedNode.Cmp(node) == 0 {
+				staked = append(staked, staker)
+				break
+			}
+		}
+	}
+	return staked, nil
+}
+
+func (w *Watchtower) alert(ctx context.Context, nd *core.NodeInfo, alert *Alert) error {
+	alert.Node = nd.NodeNum
+	alert.NodeHash = nd.NodeHash.ToEthHash()
+	alert.ProposedBlock = nd.BlockProposed.Height.AsInt()
+	alert.Time = time.Now()
+	stakers, err := w.stakersOnNode(ctx, nd.NodeNum)
+	if err != nil {
+		// Still worth sending the alert without them
+		logger.Warn().Err(err).Msg("error looking up stakers on node")
+	}
+	alert.Stakers = common.AddressArrayToEth(stakers)
+
+	for _, sink := range w.sinks {
+		if err := sink.SendAlert(ctx, alert); err != nil {
+			logger.Error().Err(err).Str("node", alert.Node.String()).Msg("error sending alert")
+		}
+	}
+	return nil
+}
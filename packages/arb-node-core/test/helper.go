@@ -21,10 +21,12 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	ethcore "github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pkg/errors"
 )
 
@@ -47,6 +49,15 @@ func SimulatedBackend(t *testing.T) (*backends.SimulatedBackend, []*ecdsa.Privat
 	return client, pks
 }
 
+// SimulatedTransactor returns transaction options signing with key for the
+// simulated backend's chain, which includes dynamic fee transactions
+func SimulatedTransactor(t *testing.T, key *ecdsa.PrivateKey) *bind.TransactOpts {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, params.AllEthashProtocolChanges.ChainID)
+	FailIfError(t, err)
+	return auth
+}
+
 func FailIfError(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
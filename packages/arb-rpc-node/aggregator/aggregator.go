@@ -72,6 +72,16 @@ func (m *Server) SendTransaction(ctx context.Context, tx *types.Transaction) err
 	return m.batch.SendTransaction(ctx, tx)
 }
 
+// TxPool returns the local transaction pool or nil if the batcher doesn't
+// keep one
+func (m *Server) TxPool() batcher.TxPool {
+	pool, ok := m.batch.(batcher.TxPool)
+	if !ok {
+		return nil
+	}
+	return pool
+}
+
 func (m *Server) GetBlockCount() (uint64, error) {
 	latest, err := m.db.BlockCount()
 	if err != nil {
@@ -23,7 +23,6 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 
 	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
@@ -37,7 +36,7 @@ import (
 func TestCreate2(t *testing.T) {
 	backend, pks := test.SimulatedBackend(t)
 	client := &ethutils.SimulatedEthClient{SimulatedBackend: backend}
-	auth := bind.NewKeyedTransactor(pks[0])
+	auth := test.SimulatedTransactor(t, pks[0])
 
 	factoryConnAddress, _, cf, err := arbostestcontracts.DeployCloneFactory(auth, client)
 	failIfError(t, err)
@@ -23,7 +23,6 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -133,7 +132,7 @@ func TestFees(t *testing.T) {
 	var conDeployedLength int
 	{
 		client, keys := test.SimulatedBackend(t)
-		auth := bind.NewKeyedTransactor(keys[0])
+		auth := test.SimulatedTransactor(t, keys[0])
 		addr, _, _, err := arbostestcontracts.DeployGasUsed(auth, client, false)
 		failIfError(t, err)
 		client.Commit()
@@ -87,6 +87,28 @@ type TransactionBatcher interface {
 	Start(context.Context)
 }
 
+// TxPool is implemented by batchers which keep a local pool of transactions
+// waiting to be batched
+type TxPool interface {
+	TxPoolContent() (pending map[ethcommon.Address][]*types.Transaction, queued map[ethcommon.Address][]*types.Transaction)
+}
+
+// PendingTx is a transaction waiting in a pool along with its sender
+type PendingTx struct {
+	Sender ethcommon.Address
+	Tx     *types.Transaction
+}
+
+// PendingBlockPool is implemented by pools which include all of their
+// pending transactions together in the next block
+type PendingBlockPool interface {
+	TxPool
+
+	// PendingBlock returns the pending transactions in the order they'll be
+	// included and the timestamp of the block they'll be included in
+	PendingBlock() ([]PendingTx, uint64)
+}
+
 type pendingSentBatch struct {
 	txHash common.Hash
 	txes   []*types.Transaction
@@ -98,6 +120,7 @@ type Batcher struct {
 
 	sync.Mutex
 	queuedTxes         *txQueues
+	journal            *txJournal
 	pendingBatch       batch
 	pendingSentBatches *list.List
 	newTxFeed          event.Feed
@@ -110,6 +133,7 @@ func NewStatefulBatcher(
 	receiptFetcher ethutils.ReceiptFetcher,
 	globalInbox l2TxSender,
 	maxBatchTime time.Duration,
+	poolConfig TxPoolConfig,
 ) (*Batcher, error) {
 	signer := types.NewEIP155Signer(chainId)
 	batch, err := newStatefulBatch(db, maxBatchSize, signer)
@@ -122,6 +146,7 @@ func NewStatefulBatcher(
 		receiptFetcher,
 		globalInbox,
 		maxBatchTime,
+		poolConfig,
 		batch,
 	), nil
 }
@@ -133,6 +158,7 @@ func NewStatelessBatcher(
 	receiptFetcher ethutils.ReceiptFetcher,
 	globalInbox l2TxSender,
 	maxBatchTime time.Duration,
+	poolConfig TxPoolConfig,
 ) *Batcher {
 	signer := types.NewEIP155Signer(chainId)
 	return newBatcher(
@@ -141,6 +167,7 @@ func NewStatelessBatcher(
 		receiptFetcher,
 		globalInbox,
 		maxBatchTime,
+		poolConfig,
 		newStatelessBatch(db, maxBatchSize, signer),
 	)
 }
@@ -151,16 +178,24 @@ func newBatcher(
 	receiptFetcher ethutils.ReceiptFetcher,
 	globalInbox l2TxSender,
 	maxBatchTime time.Duration,
+	poolConfig TxPoolConfig,
 	pendingBatch batch,
 ) *Batcher {
+	poolConfig = poolConfig.sanitize()
 	server := &Batcher{
 		signer:             types.NewEIP155Signer(chainId),
 		sender:             globalInbox.Sender(),
-		queuedTxes:         newTxQueues(),
+		queuedTxes:         newTxQueues(poolConfig),
 		pendingBatch:       pendingBatch,
 		pendingSentBatches: list.New(),
 	}
 
+	if poolConfig.Journal != "" {
+		server.journal = newTxJournal(poolConfig.Journal)
+		server.loadJournal()
+	}
+	go server.maintainPool(ctx, poolConfig)
+
 	go func() {
 		lastBatch := time.Now()
 		checkForFinish := true
@@ -216,6 +251,69 @@ func newBatcher(
 	return server
 }
 
+// loadJournal re-adds the transactions that were queued before a restart
+func (m *Batcher) loadJournal() {
+	txes, err := m.journal.load()
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to load transaction journal")
+	}
+	m.Lock()
+	defer m.Unlock()
+	dropped := 0
+	for _, tx := range txes {
+		sender, err := types.Sender(m.signer, tx)
+		if err == nil {
+			err = m.addTransaction(tx, sender)
+		}
+		if err != nil {
+			dropped++
+		}
+	}
+	logger.Info().Int("loaded", len(txes)-dropped).Int("dropped", dropped).Msg("loaded transaction journal")
+	if err := m.journal.rotate(m.queuedTxes.all()); err != nil {
+		logger.Error().Err(err).Msg("failed to rotate transaction journal")
+	}
+}
+
+// maintainPool drops expired transactions and periodically rewrites the
+// journal to remove transactions that are no longer queued
+func (m *Batcher) maintainPool(ctx context.Context, config TxPoolConfig) {
+	expireTicker := time.NewTicker(time.Minute)
+	defer expireTicker.Stop()
+	journalTicker := time.NewTicker(config.Rejournal)
+	defer journalTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if m.journal != nil {
+				m.Lock()
+				if err := m.journal.close(); err != nil {
+					logger.Warn().Err(err).Msg("failed to close transaction journal")
+				}
+				m.Unlock()
+			}
+			return
+		case <-expireTicker.C:
+			m.Lock()
+			removed := m.queuedTxes.expire(time.Now())
+			m.Unlock()
+			if removed > 0 {
+				logger.Info().Int("count", removed).Msg("dropped expired transactions")
+			}
+		case <-journalTicker.C:
+			if m.journal == nil {
+				continue
+			}
+			m.Lock()
+			err := m.journal.rotate(m.queuedTxes.all())
+			m.Unlock()
+			if err != nil {
+				logger.Error().Err(err).Msg("failed to rotate transaction journal")
+			}
+		}
+	}
+}
+
 func (m *Batcher) handleNextTx() bool {
 	tx, accountIndex, cont := popRandomTx(m.pendingBatch, m.queuedTxes)
 	if tx != nil {
@@ -341,17 +439,13 @@ func (m *Batcher) SendTransaction(_ context.Context, tx *types.Transaction) erro
 	m.Lock()
 	defer m.Unlock()
 
-	action, err := m.pendingBatch.validateTx(tx)
-	if action == REMOVE {
-		return err
-	}
-
-	if err := m.pendingBatch.updateCurrentSnap(m.pendingSentBatches); err != nil {
+	if err := m.addTransaction(tx, sender); err != nil {
 		return err
 	}
-
-	if err := m.queuedTxes.addTransaction(tx, sender); err != nil {
-		return err
+	if m.journal != nil {
+		if err := m.journal.insert(tx); err != nil {
+			logger.Warn().Err(err).Msg("failed to journal transaction")
+		}
 	}
 
 	m.newTxFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{tx}})
@@ -373,6 +467,50 @@ func (m *Batcher) SendTransaction(_ context.Context, tx *types.Transaction) erro
 	return nil
 }
 
+// addTransaction expects the mutex to be held
+func (m *Batcher) addTransaction(tx *types.Transaction, sender ethcommon.Address) error {
+	action, err := m.pendingBatch.validateTx(tx)
+	if action == REMOVE {
+		return err
+	}
+
+	if err := m.pendingBatch.updateCurrentSnap(m.pendingSentBatches); err != nil {
+		return err
+	}
+
+	var accountNonce *uint64
+	if _, ok := m.queuedTxes.queues[sender]; !ok {
+		accountNonce, err = m.accountNonce(sender)
+		if err != nil {
+			return err
+		}
+	}
+	return m.queuedTxes.addTransaction(tx, sender, accountNonce)
+}
+
+// accountNonce returns the next nonce of the account according to the latest
+// snapshot, or nil if the batcher doesn't track state
+func (m *Batcher) accountNonce(account ethcommon.Address) (*uint64, error) {
+	snap := m.pendingBatch.getLatestSnap()
+	if snap == nil {
+		return nil, nil
+	}
+	nonce, err := snap.GetTransactionCount(common.NewAddressFromEth(account))
+	if err != nil {
+		return nil, err
+	}
+	next := nonce.Uint64()
+	return &next, nil
+}
+
+// TxPoolContent returns the queued transactions of each account split into
+// those that can be included next and those waiting on a nonce gap
+func (m *Batcher) TxPoolContent() (map[ethcommon.Address][]*types.Transaction, map[ethcommon.Address][]*types.Transaction) {
+	m.Lock()
+	defer m.Unlock()
+	return m.queuedTxes.content()
+}
+
 func (m *Batcher) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return m.newTxFeed.Subscribe(ch)
 }
@@ -142,6 +142,7 @@ func TestStatelessBatcher(t *testing.T) {
 		mock,
 		mock,
 		time.Millisecond*200,
+		DefaultTxPoolConfig,
 	)
 
 	for _, tx := range txes {
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package batcher
+
+import (
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/pkg/errors"
+)
+
+// txJournal is an append only log of the transactions added to the pool so
+// that they survive a restart. It's periodically rewritten with only the
+// transactions that are still queued.
+type txJournal struct {
+	path   string
+	writer *os.File
+}
+
+func newTxJournal(path string) *txJournal {
+	return &txJournal{path: path}
+}
+
+// load reads all transactions from the journal
+func (j *txJournal) load() ([]*types.Transaction, error) {
+	input, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open transaction journal")
+	}
+	defer input.Close()
+
+	var txes []*types.Transaction
+	stream := rlp.NewStream(input, 0)
+	for {
+		tx := new(types.Transaction)
+		if err := stream.Decode(tx); err != nil {
+			if err == io.EOF {
+				return txes, nil
+			}
+			// A partially written transaction can be left at the end if the
+			// node was killed, so keep everything read before it
+			logger.Warn().Err(err).Int("loaded", len(txes)).Msg("error reading transaction journal")
+			return txes, nil
+		}
+		txes = append(txes, tx)
+	}
+}
+
+func (j *txJournal) insert(tx *types.Transaction) error {
+	if j.writer == nil {
+		return errors.New("transaction journal not open")
+	}
+	return errors.WithStack(rlp.Encode(j.writer, tx))
+}
+
+// rotate replaces the journal with one containing only the given transactions
+func (j *txJournal) rotate(txes []*types.Transaction) error {
+	if j.writer != nil {
+		if err := j.writer.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+		j.writer = nil
+	}
+	replacement, err := os.OpenFile(j.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, tx := range txes {
+		if err := rlp.Encode(replacement, tx); err != nil {
+			replacement.Close()
+			return errors.WithStack(err)
+		}
+	}
+	if err := replacement.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(j.path+".new", j.path); err != nil {
+		return errors.WithStack(err)
+	}
+	sink, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	j.writer = sink
+	return nil
+}
+
+func (j *txJournal) close() error {
+	if j.writer == nil {
+		return nil
+	}
+	err := j.writer.Close()
+	j.writer = nil
+	return errors.WithStack(err)
+}
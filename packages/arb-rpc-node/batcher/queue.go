@@ -18,10 +18,15 @@ package batcher
 
 import (
 	"container/heap"
+	"math/big"
+	"math/rand"
+	"sort"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/pkg/errors"
-	"math/rand"
 )
 
 // An TxHeap is a min-heap of transactions sorted by nonce.
@@ -43,75 +48,230 @@ func (h *TxHeap) Pop() interface{} {
 	return x
 }
 
+type TxPoolConfig struct {
+	// File queued transactions are persisted to, disabled if empty
+	Journal string
+	// How often the journal is rewritten to drop included transactions
+	Rejournal time.Duration
+	// Maximum number of queued transactions per account
+	AccountLimit int
+	// Maximum number of queued transactions across all accounts
+	GlobalLimit int
+	// How long a transaction can stay queued before being dropped
+	Lifetime time.Duration
+	// Percentage a replacement transaction must increase the gas price by
+	PriceBump uint64
+}
+
+var DefaultTxPoolConfig = TxPoolConfig{
+	Rejournal:    time.Minute * 10,
+	AccountLimit: 64,
+	GlobalLimit:  4096,
+	Lifetime:     time.Hour * 3,
+	PriceBump:    10,
+}
+
+// sanitize replaces unset limits with their defaults
+func (c TxPoolConfig) sanitize() TxPoolConfig {
+	if c.Rejournal <= 0 {
+		c.Rejournal = DefaultTxPoolConfig.Rejournal
+	}
+	if c.AccountLimit <= 0 {
+		c.AccountLimit = DefaultTxPoolConfig.AccountLimit
+	}
+	if c.GlobalLimit <= 0 {
+		c.GlobalLimit = DefaultTxPoolConfig.GlobalLimit
+	}
+	if c.Lifetime <= 0 {
+		c.Lifetime = DefaultTxPoolConfig.Lifetime
+	}
+	return c
+}
+
+var (
+	errAccountLimit = errors.New("too many queued transactions from account")
+	errPoolFull     = errors.New("transaction pool is full")
+)
+
 type txQueue struct {
 	txes        TxHeap
 	txesByNonce map[uint64]*types.Transaction
+	addedAt     map[uint64]time.Time
 	maxNonce    uint64
+	// Nonce following the last transaction included from this queue, or nil
+	// if it isn't known
+	nextNonce *uint64
 }
 
-func newTxQueue() *txQueue {
+func newTxQueue(nextNonce *uint64) *txQueue {
 	return &txQueue{
 		txes:        nil,
 		txesByNonce: make(map[uint64]*types.Transaction),
+		addedAt:     make(map[uint64]time.Time),
 		maxNonce:    0,
+		nextNonce:   nextNonce,
 	}
 }
 
-func (q *txQueue) addTransaction(tx *types.Transaction) error {
-	if _, ok := q.txesByNonce[tx.Nonce()]; ok {
-		return errors.New("transaction replacement not supported")
+// addTransaction adds the transaction to the queue, returning the transaction
+// it replaced if there was already one with the same nonce
+func (q *txQueue) addTransaction(tx *types.Transaction, config TxPoolConfig) (*types.Transaction, error) {
+	if old, ok := q.txesByNonce[tx.Nonce()]; ok {
+		minPrice := new(big.Int).Mul(old.GasPrice(), new(big.Int).SetUint64(100+config.PriceBump))
+		minPrice.Div(minPrice, big.NewInt(100))
+		if tx.GasPrice().Cmp(minPrice) < 0 || tx.GasPrice().Cmp(old.GasPrice()) <= 0 {
+			return nil, errors.WithStack(core.ErrReplaceUnderpriced)
+		}
+		for i, queued := range q.txes {
+			if queued.Nonce() == tx.Nonce() {
+				// Nonce is unchanged so the heap order is still valid
+				q.txes[i] = tx
+				break
+			}
+		}
+		q.txesByNonce[tx.Nonce()] = tx
+		q.addedAt[tx.Nonce()] = time.Now()
+		return old, nil
+	}
+
+	if len(q.txes) >= config.AccountLimit {
+		return nil, errAccountLimit
 	}
 
 	q.txesByNonce[tx.Nonce()] = tx
+	q.addedAt[tx.Nonce()] = time.Now()
 	heap.Push(&q.txes, tx)
 
 	if tx.Nonce() > q.maxNonce {
 		q.maxNonce = tx.Nonce()
 	}
-	return nil
+	return nil, nil
 }
 
 func (q *txQueue) Empty() bool {
 	return len(q.txes) == 0
 }
 
+// Peek returns the lowest nonce transaction unless it comes after a gap in
+// nonces, in which case it must wait for the missing transactions
 func (q *txQueue) Peek() *types.Transaction {
 	if q == nil || len(q.txes) == 0 {
 		return nil
 	}
+	if q.nextNonce != nil && q.txes[0].Nonce() > *q.nextNonce {
+		return nil
+	}
 	return q.txes[0]
 }
 
 func (q *txQueue) Pop() *types.Transaction {
 	tx := heap.Pop(&q.txes).(*types.Transaction)
 	delete(q.txesByNonce, tx.Nonce())
+	delete(q.addedAt, tx.Nonce())
 	return tx
 }
 
+// expire drops transactions which were added before the cutoff and returns
+// how many were removed
+func (q *txQueue) expire(cutoff time.Time) int {
+	var remaining TxHeap
+	removed := 0
+	for _, tx := range q.txes {
+		if q.addedAt[tx.Nonce()].Before(cutoff) {
+			delete(q.txesByNonce, tx.Nonce())
+			delete(q.addedAt, tx.Nonce())
+			removed++
+			continue
+		}
+		remaining = append(remaining, tx)
+	}
+	if removed > 0 {
+		heap.Init(&remaining)
+		q.txes = remaining
+	}
+	return removed
+}
+
+// split returns the transactions which can be included in order and the ones
+// waiting on a nonce gap, both sorted by nonce
+func (q *txQueue) split() ([]*types.Transaction, []*types.Transaction) {
+	sorted := make([]*types.Transaction, len(q.txes))
+	copy(sorted, q.txes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Nonce() < sorted[j].Nonce() })
+	if len(sorted) == 0 {
+		return nil, nil
+	}
+	next := sorted[0].Nonce()
+	if q.nextNonce != nil {
+		next = *q.nextNonce
+	}
+	i := 0
+	for ; i < len(sorted); i++ {
+		if sorted[i].Nonce() > next {
+			break
+		}
+		next = sorted[i].Nonce() + 1
+	}
+	return sorted[:i], sorted[i:]
+}
+
 type txQueues struct {
+	config   TxPoolConfig
 	queues   map[common.Address]*txQueue
 	accounts []common.Address
+	count    int
 }
 
-func newTxQueues() *txQueues {
+func newTxQueues(config TxPoolConfig) *txQueues {
 	return &txQueues{
+		config:   config,
 		queues:   make(map[common.Address]*txQueue),
 		accounts: nil,
 	}
 }
 
-func (q *txQueues) addTransaction(tx *types.Transaction, sender common.Address) error {
+// addTransaction queues the transaction. If the sender doesn't have a queue
+// yet, the new one expects accountNonce to be the next nonce unless it is nil.
+func (q *txQueues) addTransaction(tx *types.Transaction, sender common.Address, accountNonce *uint64) error {
 	queue, ok := q.queues[sender]
-	if !ok {
-		queue = newTxQueue()
+	if ok {
+		if _, replacing := queue.txesByNonce[tx.Nonce()]; !replacing && q.count >= q.config.GlobalLimit {
+			return errPoolFull
+		}
+	} else {
+		if q.count >= q.config.GlobalLimit {
+			return errPoolFull
+		}
+		queue = newTxQueue(accountNonce)
 		q.queues[sender] = queue
 		q.accounts = append(q.accounts, sender)
 	}
-	return queue.addTransaction(tx)
+	replaced, err := queue.addTransaction(tx, q.config)
+	if err != nil {
+		if queue.Empty() {
+			q.removeAccount(sender)
+		}
+		return err
+	}
+	if replaced == nil {
+		q.count++
+	}
+	return nil
 }
 
 func (q *txQueues) removeTxFromAccountAtIndex(i int) {
 	q.queues[q.accounts[i]].Pop()
+	q.count--
+}
+
+// takeTxFromAccountAtIndex removes the next transaction from the account
+// because it was included in a batch
+func (q *txQueues) takeTxFromAccountAtIndex(i int) {
+	queue := q.queues[q.accounts[i]]
+	tx := queue.Pop()
+	nextNonce := tx.Nonce() + 1
+	queue.nextNonce = &nextNonce
+	q.count--
 }
 
 func (q *txQueues) maybeRemoveAccountAtIndex(i int) {
@@ -123,6 +283,54 @@ func (q *txQueues) maybeRemoveAccountAtIndex(i int) {
 	}
 }
 
+func (q *txQueues) removeAccount(account common.Address) {
+	for i, a := range q.accounts {
+		if a == account {
+			q.maybeRemoveAccountAtIndex(i)
+			return
+		}
+	}
+}
+
+// expire drops all transactions that have been queued for longer than the
+// configured lifetime
+func (q *txQueues) expire(now time.Time) int {
+	cutoff := now.Add(-q.config.Lifetime)
+	removed := 0
+	for i := len(q.accounts) - 1; i >= 0; i-- {
+		removed += q.queues[q.accounts[i]].expire(cutoff)
+		q.maybeRemoveAccountAtIndex(i)
+	}
+	q.count -= removed
+	return removed
+}
+
+// all returns every queued transaction
+func (q *txQueues) all() []*types.Transaction {
+	txes := make([]*types.Transaction, 0, q.count)
+	for _, queue := range q.queues {
+		txes = append(txes, queue.txes...)
+	}
+	return txes
+}
+
+// content returns the queued transactions of each account split into those
+// that can be included and those waiting on a nonce gap
+func (q *txQueues) content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	pending := make(map[common.Address][]*types.Transaction)
+	queued := make(map[common.Address][]*types.Transaction)
+	for account, queue := range q.queues {
+		executable, waiting := queue.split()
+		if len(executable) > 0 {
+			pending[account] = executable
+		}
+		if len(waiting) > 0 {
+			queued[account] = waiting
+		}
+	}
+	return pending, queued
+}
+
 func popRandomTx(b batch, queuedTxes *txQueues) (*types.Transaction, int, bool) {
 	queuedCount := int32(len(queuedTxes.accounts))
 	if queuedCount == 0 {
@@ -167,7 +375,7 @@ func popRandomTx(b batch, queuedTxes *txQueues) (*types.Transaction, int, bool)
 		case FULL:
 			return nil, 0, true
 		case ACCEPT:
-			queuedTxes.removeTxFromAccountAtIndex(index)
+			queuedTxes.takeTxFromAccountAtIndex(index)
 			return tx, index, true
 		}
 	}
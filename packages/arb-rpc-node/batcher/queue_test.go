@@ -0,0 +1,175 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package batcher
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+)
+
+func poolTx(nonce uint64, gasPrice int64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(gasPrice), nil)
+}
+
+func TestTxQueuesReplacement(t *testing.T) {
+	sender := common.Address{1}
+	queues := newTxQueues(DefaultTxPoolConfig)
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 100), sender, nil))
+
+	err := queues.addTransaction(poolTx(0, 105), sender, nil)
+	if errors.Cause(err) != core.ErrReplaceUnderpriced {
+		t.Error("expected underpriced replacement to fail but got", err)
+	}
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 110), sender, nil))
+	if queues.count != 1 {
+		t.Error("replacement shouldn't change the pool size", queues.count)
+	}
+	if queues.queues[sender].Peek().GasPrice().Cmp(big.NewInt(110)) != 0 {
+		t.Error("replacement wasn't queued")
+	}
+}
+
+func TestTxQueuesLimits(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.AccountLimit = 2
+	config.GlobalLimit = 3
+	queues := newTxQueues(config)
+
+	first := common.Address{1}
+	second := common.Address{2}
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 100), first, nil))
+	test.FailIfError(t, queues.addTransaction(poolTx(1, 100), first, nil))
+	if err := queues.addTransaction(poolTx(2, 100), first, nil); err != errAccountLimit {
+		t.Error("expected account limit error but got", err)
+	}
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 100), second, nil))
+	if err := queues.addTransaction(poolTx(1, 100), second, nil); err != errPoolFull {
+		t.Error("expected pool full error but got", err)
+	}
+	// Replacements are still allowed in a full pool
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 200), second, nil))
+}
+
+func TestTxQueuesNonceGap(t *testing.T) {
+	sender := common.Address{1}
+	queues := newTxQueues(DefaultTxPoolConfig)
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 100), sender, nil))
+	test.FailIfError(t, queues.addTransaction(poolTx(2, 100), sender, nil))
+
+	queues.takeTxFromAccountAtIndex(0)
+	if queues.queues[sender].Peek() != nil {
+		t.Error("transaction after a nonce gap shouldn't be ready")
+	}
+	pending, queued := queues.content()
+	if len(pending[sender]) != 0 || len(queued[sender]) != 1 {
+		t.Error("wrong pool content", len(pending[sender]), len(queued[sender]))
+	}
+
+	test.FailIfError(t, queues.addTransaction(poolTx(1, 100), sender, nil))
+	if tx := queues.queues[sender].Peek(); tx == nil || tx.Nonce() != 1 {
+		t.Error("filled gap should be ready")
+	}
+	pending, queued = queues.content()
+	if len(pending[sender]) != 2 || len(queued[sender]) != 0 {
+		t.Error("wrong pool content", len(pending[sender]), len(queued[sender]))
+	}
+}
+
+func TestTxQueuesAccountNonce(t *testing.T) {
+	sender := common.Address{1}
+	queues := newTxQueues(DefaultTxPoolConfig)
+	accountNonce := uint64(1)
+	test.FailIfError(t, queues.addTransaction(poolTx(2, 100), sender, &accountNonce))
+	if queues.queues[sender].Peek() != nil {
+		t.Error("transaction after the account nonce shouldn't be ready")
+	}
+	test.FailIfError(t, queues.addTransaction(poolTx(1, 100), sender, nil))
+	queues.takeTxFromAccountAtIndex(0)
+	queues.takeTxFromAccountAtIndex(0)
+	queues.maybeRemoveAccountAtIndex(0)
+	if len(queues.accounts) != 0 {
+		t.Fatal("empty account wasn't removed")
+	}
+
+	// A queue recreated after being emptied still respects the account nonce
+	accountNonce = 3
+	test.FailIfError(t, queues.addTransaction(poolTx(4, 100), sender, &accountNonce))
+	pending, queued := queues.content()
+	if len(pending[sender]) != 0 || len(queued[sender]) != 1 {
+		t.Error("wrong pool content", len(pending[sender]), len(queued[sender]))
+	}
+}
+
+func TestTxQueuesExpire(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.Lifetime = time.Hour
+	queues := newTxQueues(config)
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 100), common.Address{1}, nil))
+	test.FailIfError(t, queues.addTransaction(poolTx(0, 100), common.Address{2}, nil))
+
+	if removed := queues.expire(time.Now()); removed != 0 {
+		t.Error("removed transactions too early", removed)
+	}
+	if removed := queues.expire(time.Now().Add(time.Hour * 2)); removed != 2 {
+		t.Error("expected both transactions to expire but removed", removed)
+	}
+	if queues.count != 0 || len(queues.accounts) != 0 {
+		t.Error("expired accounts weren't removed")
+	}
+}
+
+func TestTxJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txpool")
+	test.FailIfError(t, err)
+	defer os.RemoveAll(dir)
+
+	journal := newTxJournal(filepath.Join(dir, "txpool.rlp"))
+	txes, err := journal.load()
+	test.FailIfError(t, err)
+	if len(txes) != 0 {
+		t.Fatal("missing journal should be empty")
+	}
+
+	test.FailIfError(t, journal.rotate([]*types.Transaction{poolTx(0, 100)}))
+	test.FailIfError(t, journal.insert(poolTx(1, 100)))
+	test.FailIfError(t, journal.close())
+
+	txes, err = journal.load()
+	test.FailIfError(t, err)
+	if len(txes) != 2 || txes[0].Nonce() != 0 || txes[1].Nonce() != 1 {
+		t.Fatal("journal didn't round trip", len(txes))
+	}
+
+	test.FailIfError(t, journal.rotate(txes[1:]))
+	test.FailIfError(t, journal.close())
+	txes, err = journal.load()
+	test.FailIfError(t, err)
+	if len(txes) != 1 || txes[0].Nonce() != 1 {
+		t.Fatal("rotation didn't drop transactions", len(txes))
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/offchainlabs/arbitrum/packages/arb-util/broadcaster"
@@ -70,6 +71,9 @@ type SequencerBatcher struct {
 	createBatchBlockInterval        *big.Int
 	LockoutManager                  SequencerLockoutManager
 
+	// Prevents batches from being published concurrently with the same nonce
+	publishMutex sync.Mutex
+
 	sequencer common.Address
 	signer    types.Signer
 	txQueue   chan txQueueItem
@@ -104,7 +108,7 @@ func NewSequencerBatcher(
 	auth *bind.TransactOpts,
 	dataSigner func([]byte) ([]byte, error),
 	broadcaster *broadcaster.Broadcaster,
-	gasPriceUrl string,
+	authConfig ethbridge.TransactAuthConfig,
 ) (*SequencerBatcher, error) {
 	chainTime, err := getChainTime(ctx, client)
 	if err != nil {
@@ -120,7 +124,7 @@ func NewSequencerBatcher(
 		return nil, errors.New("Transaction auth isn't for sequencer")
 	}
 
-	transactAuth, err := ethbridge.NewTransactAuth(ctx, client, auth, gasPriceUrl)
+	transactAuth, err := ethbridge.NewTransactAuthAdvanced(ctx, client, auth, authConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -652,7 +656,7 @@ func (b *SequencerBatcher) publishBatch(ctx context.Context, dontPublishBlockNum
 	prevMsgCount.Set(newMsgCount)
 
 	go (func() {
-		receipt, err := ethbridge.WaitForReceiptWithResults(ctx, b.client, b.sequencer.ToEthAddress(), tx, "addSequencerL2BatchFromOrigin")
+		receipt, err := b.auth.WaitForReceipt(ctx, tx, "addSequencerL2BatchFromOrigin")
 		if err != nil {
 			logger.Warn().Err(err).Msg("error waiting for batch receipt")
 			return
@@ -756,20 +760,7 @@ func (b *SequencerBatcher) Start(ctx context.Context) {
 			b.inboxReader.MessageDeliveryMutex.Unlock()
 		}
 		if creatingBatch || firstBoot {
-			prevMsgCount, err := b.sequencerInbox.MessageCount(&bind.CallOpts{Context: ctx})
-			if err != nil {
-				logger.Error().Err(err).Msg("error getting on-chain message count")
-				continue
-			}
-			// Gets the nonce at the latest block's state, *not* the pending state
-			nonceInt, err := b.client.NonceAt(ctx, b.sequencer.ToEthAddress(), nil)
-			if err != nil {
-				logger.Error().Err(err).Msg("error getting latest sequencer nonce")
-				continue
-			}
-			nonce := new(big.Int).SetUint64(nonceInt)
-			// Updates both prevMsgCount and nonce on success
-			complete, err := b.publishBatch(ctx, dontPublishBlockNum, prevMsgCount, nonce)
+			complete, err := b.publishPendingBatch(ctx, dontPublishBlockNum, false)
 			if err != nil {
 				logger.Error().Err(err).Msg("error creating batch")
 			} else if complete {
@@ -783,3 +774,35 @@ func (b *SequencerBatcher) Start(ctx context.Context) {
 		firstBoot = false
 	}
 }
+
+// publishPendingBatch publishes batches until either all pending messages are
+// published or continueWhileIncomplete is false. It returns whether all
+// messages were published.
+func (b *SequencerBatcher) publishPendingBatch(ctx context.Context, dontPublishBlockNum *big.Int, continueWhileIncomplete bool) (bool, error) {
+	b.publishMutex.Lock()
+	defer b.publishMutex.Unlock()
+	prevMsgCount, err := b.sequencerInbox.MessageCount(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return false, errors.Wrap(err, "error getting on-chain message count")
+	}
+	// Gets the nonce at the latest block's state, *not* the pending state
+	nonceInt, err := b.client.NonceAt(ctx, b.sequencer.ToEthAddress(), nil)
+	if err != nil {
+		return false, errors.Wrap(err, "error getting latest sequencer nonce")
+	}
+	nonce := new(big.Int).SetUint64(nonceInt)
+	for {
+		// Updates both prevMsgCount and nonce on success
+		complete, err := b.publishBatch(ctx, dontPublishBlockNum, prevMsgCount, nonce)
+		if err != nil || complete || !continueWhileIncomplete {
+			return complete, err
+		}
+	}
+}
+
+// PublishPendingBatch submits every sequenced message that isn't yet in a
+// batch on L1, splitting them across several batches if necessary
+func (b *SequencerBatcher) PublishPendingBatch(ctx context.Context) error {
+	_, err := b.publishPendingBatch(ctx, nil, true)
+	return err
+}
@@ -18,7 +18,7 @@ import (
 
 func TestSequencerGasUsage(t *testing.T) {
 	clnt, pks := test.SimulatedBackend(t)
-	auth := bind.NewKeyedTransactor(pks[0])
+	auth := test.SimulatedTransactor(t, pks[0])
 
 	sequencer := common.NewAddressFromEth(auth.From)
 	delayedInboxAddr, _, delayedBridge, err := ethbridgecontracts.DeployBridge(auth, clnt)
@@ -155,7 +155,7 @@ func TestSequencerBatcher(t *testing.T) {
 	extraConfig := init.ExtraConfig
 
 	clnt, pks := test.SimulatedBackend(t)
-	auth := bind.NewKeyedTransactor(pks[0])
+	auth := test.SimulatedTransactor(t, pks[0])
 	sequencer := common.NewAddressFromEth(auth.From)
 	client := &ethutils.SimulatedEthClient{SimulatedBackend: clnt}
 
@@ -211,10 +211,10 @@ func TestSequencerBatcher(t *testing.T) {
 	}
 	time.Sleep(time.Second)
 
-	_, err = seqMon.StartInboxReader(ctx, client, common.NewAddressFromEth(rollupAddr), 0, common.NewAddressFromEth(bridgeUtilsAddr), nil, dummySequencerFeed)
+	_, err = seqMon.StartInboxReader(ctx, client, common.NewAddressFromEth(rollupAddr), 0, common.NewAddressFromEth(bridgeUtilsAddr), monitor.InboxReaderConfig{}, nil, dummySequencerFeed)
 	test.FailIfError(t, err)
 
-	_, err = otherMon.StartInboxReader(ctx, client, common.NewAddressFromEth(rollupAddr), 0, common.NewAddressFromEth(bridgeUtilsAddr), nil, dummySequencerFeed)
+	_, err = otherMon.StartInboxReader(ctx, client, common.NewAddressFromEth(rollupAddr), 0, common.NewAddressFromEth(bridgeUtilsAddr), monitor.InboxReaderConfig{}, nil, dummySequencerFeed)
 	test.FailIfError(t, err)
 
 	batcher, err := NewSequencerBatcher(
@@ -229,7 +229,7 @@ func TestSequencerBatcher(t *testing.T) {
 		auth,
 		dummyDataSigner,
 		nil,
-		"",
+		ethbridge.TransactAuthConfig{},
 	)
 	test.FailIfError(t, err)
 	batcher.logBatchGasCosts = true
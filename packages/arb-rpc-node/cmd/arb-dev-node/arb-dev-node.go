@@ -49,6 +49,7 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/aggregator"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/dev"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/rpc"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/txdb"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
@@ -92,6 +93,9 @@ func startup() error {
 
 	enablePProf := fs.Bool("pprof", false, "enable profiling server")
 	saveMessages := fs.String("save", "", "save messages")
+	loadMessages := fs.String("load", "", "replay messages saved with --save instead of setting up a new chain")
+	forkDir := fs.String("fork", "", "start from a copy of this arb-node database, which must not be in use, instead of setting up a new chain")
+	forkBlock := fs.Int64("fork-block", -1, "L2 block to fork from, or the latest block if negative")
 	walletcount := fs.Int("walletcount", 10, "number of wallets to fund")
 	walletbalance := fs.Int64("walletbalance", 100, "amount of funds in each wallet (Eth)")
 	arbosPath := fs.String("arbos", "", "ArbOS version")
@@ -101,6 +105,8 @@ func startup() error {
 	initialL1Height := fs.Uint64("l1height", 0, "initial l1 height")
 	rollupStr := fs.String("rollup", "", "address of rollup contract")
 	chainId64 := fs.Uint64("chainId", 68799, "chain id of chain")
+	automine := fs.Bool("automine", true, "mine a block for each transaction as soon as it is received")
+	miningInterval := fs.Duration("mining-interval", 0, "mine a block at this interval, disabled if 0")
 	mnemonic := fs.String(
 		"mnemonic",
 		"jar deny prosper gasp flush glass core corn alarm treat leg smart",
@@ -175,16 +181,38 @@ func startup() error {
 		}
 		agg = common.NewAddressFromEth(accounts[1].Address)
 	}
-	backend, db, cancelDevNode, devNodeErrChan, err := dev.NewDevNode(
-		ctx,
-		*dbDir,
-		*arbosPath,
-		chainId,
-		agg,
-		*initialL1Height,
-	)
-	if err != nil {
-		return err
+	if *forkDir != "" && *loadMessages != "" {
+		return errors.New("--fork and --load can't be used together")
+	}
+	var backend *dev.Backend
+	var db *txdb.TxDB
+	var cancelDevNode func()
+	var devNodeErrChan <-chan error
+	if *forkDir != "" {
+		backend, db, cancelDevNode, devNodeErrChan, err = dev.NewForkedDevNode(
+			ctx,
+			*forkDir,
+			*dbDir,
+			*arbosPath,
+			agg,
+			*forkBlock,
+		)
+		if err != nil {
+			return err
+		}
+		chainId = backend.ChainID()
+	} else {
+		backend, db, cancelDevNode, devNodeErrChan, err = dev.NewDevNode(
+			ctx,
+			*dbDir,
+			*arbosPath,
+			chainId,
+			agg,
+			*initialL1Height,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	cancel := func() {
@@ -200,7 +228,19 @@ func startup() error {
 	}
 	defer cancel()
 
-	if deleteDir {
+	// A loaded chain already includes its setup
+	setupChain := deleteDir && *loadMessages == "" && *forkDir == ""
+	if *loadMessages != "" {
+		data, err := ioutil.ReadFile(*loadMessages)
+		if err != nil {
+			return errors.Wrap(err, "error reading saved messages")
+		}
+		if _, err := backend.LoadMessages(data); err != nil {
+			return errors.Wrap(err, "error loading saved messages")
+		}
+	}
+
+	if setupChain {
 		owner := common.NewAddressFromEth(accounts[0].Address)
 		config := protocol.ChainParams{
 			StakeRequirement:          big.NewInt(10),
@@ -253,20 +293,22 @@ func startup() error {
 		return errors.New("invalid value for deposit amount")
 	}
 	depositSize = depositSize.Mul(depositSize, big.NewInt(*walletbalance))
-	for _, account := range accounts {
-		deposit := message.EthDepositTx{
-			L2Message: message.NewSafeL2Message(message.ContractTransaction{
-				BasicTx: message.BasicTx{
-					MaxGas:      big.NewInt(1000000),
-					GasPriceBid: big.NewInt(0),
-					DestAddress: common.NewAddressFromEth(account.Address),
-					Payment:     depositSize,
-					Data:        nil,
-				},
-			}),
-		}
-		if _, err := backend.AddInboxMessage(deposit, common.RandAddress()); err != nil {
-			return err
+	if *loadMessages == "" {
+		for _, account := range accounts {
+			deposit := message.EthDepositTx{
+				L2Message: message.NewSafeL2Message(message.ContractTransaction{
+					BasicTx: message.BasicTx{
+						MaxGas:      big.NewInt(1000000),
+						GasPriceBid: big.NewInt(0),
+						DestAddress: common.NewAddressFromEth(account.Address),
+						Payment:     depositSize,
+						Data:        nil,
+					},
+				}),
+			}
+			if _, err := backend.AddInboxMessage(deposit, common.RandAddress()); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -287,7 +329,7 @@ func startup() error {
 
 	srv := aggregator.NewServer(backend, rollupAddress, chainId, db)
 
-	if deleteDir {
+	if setupChain {
 		client := web3.NewEthClient(srv, true, metrics.NewMetricsConfig(nil))
 		arbOwner, err := arboscontracts.NewArbOwner(arbos.ARB_OWNER_ADDRESS, client)
 		if err != nil {
@@ -318,6 +360,11 @@ func startup() error {
 		}
 	}
 
+	if err := backend.SetAutomine(*automine); err != nil {
+		return err
+	}
+	backend.SetIntervalMining(*miningInterval)
+
 	fmt.Println("Arbitrum Dev Chain")
 	fmt.Println("")
 	fmt.Println("Available Accounts")
@@ -358,6 +405,8 @@ func startup() error {
 
 	plugins := make(map[string]interface{})
 	plugins["evm"] = dev.NewEVM(backend)
+	plugins["hardhat"] = dev.NewHardhat(backend)
+	plugins["arbdev"] = dev.NewArbDev(backend)
 
 	web3Server, err := web3.GenerateWeb3Server(srv, privateKeys, true, plugins, metrics.NewMetricsConfig(nil))
 	if err != nil {
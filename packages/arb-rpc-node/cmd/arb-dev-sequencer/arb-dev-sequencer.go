@@ -230,7 +230,7 @@ func startup() error {
 	dummySequencerFeed := make(chan broadcaster.BroadcastFeedMessage)
 	var inboxReader *monitor.InboxReader
 	for {
-		inboxReader, err = mon.StartInboxReader(ctx, ethclint, rollupAddress, 0, bridgeUtilsAddress, nil, dummySequencerFeed)
+		inboxReader, err = mon.StartInboxReader(ctx, ethclint, rollupAddress, 0, bridgeUtilsAddress, monitor.InboxReaderConfig{}, nil, dummySequencerFeed)
 		if err == nil {
 			break
 		}
@@ -314,7 +314,7 @@ func startup() error {
 		batcherMode,
 		signer,
 		settings,
-		"",
+		ethbridge.TransactAuthConfig{},
 	)
 	if err != nil {
 		return err
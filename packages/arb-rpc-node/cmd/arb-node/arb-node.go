@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 
 	"github.com/pkg/errors"
 
@@ -41,6 +42,7 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/aggregator"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/batcher"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/rpc"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/traceindex"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/txdb"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/broadcastclient"
@@ -92,8 +94,7 @@ func startup() error {
 	config, wallet, l1Client, l1ChainId, err := configuration.ParseNode(ctx)
 	if err != nil || len(config.Persistent.GlobalConfig) == 0 || len(config.L1.URL) == 0 ||
 		len(config.Rollup.Address) == 0 || len(config.BridgeUtilsAddress) == 0 ||
-		((config.Node.Type != "sequencer") && len(config.Node.Sequencer.Lockout.Redis) != 0) ||
-		((len(config.Node.Sequencer.Lockout.Redis) == 0) != (len(config.Node.Sequencer.Lockout.SelfRPCURL) == 0)) {
+		!validLockoutConfig(config.Node.Type, config.Node.Sequencer.Lockout) {
 		printSampleUsage()
 		if err != nil && !strings.Contains(err.Error(), "help requested") {
 			fmt.Printf("%s\n", err.Error())
@@ -142,6 +143,22 @@ func startup() error {
 		fmt.Printf("Unrecognized node type %s", config.Node.Type)
 	}
 
+	if config.Healthcheck.L1Node {
+		if err := nodehealth.CheckL1HealthProvider(config.Healthcheck.L1Provider); err != nil {
+			badConfig = true
+			fmt.Println("Invalid --healthcheck.l1-provider:", err)
+		}
+	}
+
+	inboxReaderConfig := monitor.InboxReaderConfig{
+		ConfirmationDepth: config.InboxReader.ConfirmationDepth,
+		Finality:          config.InboxReader.Finality,
+	}
+	if err := inboxReaderConfig.Validate(); err != nil {
+		badConfig = true
+		fmt.Println(err)
+	}
+
 	if badConfig {
 		return nil
 	}
@@ -180,22 +197,46 @@ func startup() error {
 
 	healthChan <- nodehealth.Log{Config: true, Var: "healthcheckMetrics", ValBool: config.Healthcheck.Metrics}
 	healthChan <- nodehealth.Log{Config: true, Var: "disablePrimaryCheck", ValBool: !config.Healthcheck.Sequencer}
-	healthChan <- nodehealth.Log{Config: true, Var: "disableOpenEthereumCheck", ValBool: !config.Healthcheck.L1Node}
+	healthChan <- nodehealth.Log{Config: true, Var: "disableL1Check", ValBool: !config.Healthcheck.L1Node}
+	healthChan <- nodehealth.Log{Config: true, Var: "l1HealthProvider", ValStr: config.Healthcheck.L1Provider}
+	healthChan <- nodehealth.Log{Config: true, Var: "l1ChainID", ValBigInt: l1ChainId}
+	healthChan <- nodehealth.Log{Config: true, Var: "l1BlockMaxAge", ValTime: config.Healthcheck.L1MaxBlockAge}
+	healthChan <- nodehealth.Log{Config: true, Var: "peerMinimum", ValInt: int64(config.Healthcheck.L1MinPeers)}
 	healthChan <- nodehealth.Log{Config: true, Var: "healthcheckRPC", ValStr: config.Healthcheck.Addr + ":" + config.Healthcheck.Port}
 
 	if config.Node.Type == "forwarder" {
 		healthChan <- nodehealth.Log{Config: true, Var: "primaryHealthcheckRPC", ValStr: config.Node.Forwarder.Target}
 	}
-	healthChan <- nodehealth.Log{Config: true, Var: "openethereumHealthcheckRPC", ValStr: config.L1.URL}
+	if config.Healthcheck.L1Provider == nodehealth.OpenEthereumL1Provider {
+		healthChan <- nodehealth.Log{Config: true, Var: "openethereumHealthcheckRPC", ValStr: config.L1.URL}
+	} else {
+		healthChan <- nodehealth.Log{Config: true, Var: "l1API", ValStr: config.L1.URL}
+	}
 	nodehealth.Init(healthChan)
 
 	var sequencerFeed chan broadcaster.BroadcastFeedMessage
 	if len(config.Feed.Input.URLs) == 0 {
 		logger.Warn().Msg("Missing --feed.url so not subscribing to feed")
 	} else {
+		sequencerAddress, err := config.Feed.Input.GetSequencerAddress()
+		if err != nil {
+			return err
+		}
+		if sequencerAddress == nil {
+			logger.Warn().Msg("Missing --feed.input.sequencer-address so not verifying feed signatures")
+		}
+		// Ask the feed to replay everything after the messages we already have
+		var lastInboxSeqNum *big.Int
+		messageCount, err := mon.Core.GetMessageCount()
+		if err != nil {
+			return err
+		}
+		if messageCount.Sign() > 0 {
+			lastInboxSeqNum = new(big.Int).Sub(messageCount, big.NewInt(1))
+		}
 		sequencerFeed = make(chan broadcaster.BroadcastFeedMessage, 1)
 		for _, url := range config.Feed.Input.URLs {
-			broadcastClient := broadcastclient.NewBroadcastClient(url, nil, config.Feed.Input.Timeout)
+			broadcastClient := broadcastclient.NewBroadcastClient(url, lastInboxSeqNum, config.Feed.Input.Timeout, sequencerAddress)
 			for {
 				err = broadcastClient.ConnectWithChannel(ctx, sequencerFeed)
 				if err == nil {
@@ -214,7 +255,7 @@ func startup() error {
 	}
 	var inboxReader *monitor.InboxReader
 	for {
-		inboxReader, err = mon.StartInboxReader(ctx, l1Client, common.HexToAddress(config.Rollup.Address), config.Rollup.FromBlock, common.HexToAddress(config.BridgeUtilsAddress), healthChan, sequencerFeed)
+		inboxReader, err = mon.StartInboxReader(ctx, l1Client, common.HexToAddress(config.Rollup.Address), config.Rollup.FromBlock, common.HexToAddress(config.BridgeUtilsAddress), inboxReaderConfig, healthChan, sequencerFeed)
 		if err == nil {
 			break
 		}
@@ -238,9 +279,9 @@ func startup() error {
 		batcherMode = rpc.ForwarderBatcherMode{NodeURL: config.Node.Forwarder.Target}
 	} else {
 		var auth *bind.TransactOpts
-		auth, dataSigner, err = cmdhelp.GetKeystore(config.Persistent.Chain, wallet, config.GasPrice, l1ChainId)
+		auth, dataSigner, err = cmdhelp.GetSigner(ctx, config.Persistent.Chain, wallet, config.GasPrice, l1ChainId)
 		if err != nil {
-			return errors.Wrap(err, "error running GetKeystore")
+			return errors.Wrap(err, "error loading wallet")
 		}
 
 		logger.Info().Hex("from", auth.From.Bytes()).Msg("Arbitrum node submitting batches")
@@ -264,10 +305,20 @@ func startup() error {
 			}
 		} else {
 			inboxAddress := common.HexToAddress(config.Node.Aggregator.InboxAddress)
+			poolConfig := config.Node.Aggregator.TxPool
+			txPool := batcher.TxPoolConfig{
+				AccountLimit: poolConfig.AccountLimit,
+				GlobalLimit:  poolConfig.GlobalLimit,
+				Lifetime:     time.Duration(poolConfig.Lifetime) * time.Second,
+				PriceBump:    poolConfig.PriceBump,
+			}
+			if poolConfig.Journal {
+				txPool.Journal = config.GetTxPoolJournalPath()
+			}
 			if config.Node.Aggregator.Stateful {
-				batcherMode = rpc.StatefulBatcherMode{Auth: auth, InboxAddress: inboxAddress}
+				batcherMode = rpc.StatefulBatcherMode{Auth: auth, InboxAddress: inboxAddress, TxPool: txPool}
 			} else {
-				batcherMode = rpc.StatelessBatcherMode{Auth: auth, InboxAddress: inboxAddress}
+				batcherMode = rpc.StatelessBatcherMode{Auth: auth, InboxAddress: inboxAddress, TxPool: txPool}
 			}
 		}
 	}
@@ -297,10 +348,10 @@ func startup() error {
 			batcherMode,
 			dataSigner,
 			config.Feed.Output,
-			config.GasPriceUrl,
+			cmdhelp.GetTransactAuthConfig(config),
 		)
 		lockoutConf := config.Node.Sequencer.Lockout
-		if err == nil && lockoutConf.Redis != "" {
+		if err == nil && lockoutConf.SelfRPCURL != "" {
 			batch, err = rpc.SetupLockout(ctx, batch.(*batcher.SequencerBatcher), mon.Core, inboxReader, lockoutConf, errChan)
 		}
 		if err == nil {
@@ -320,7 +371,32 @@ func startup() error {
 	metricsConfig.RegisterStaticMetrics()
 
 	srv := aggregator.NewServer(batch, rollupAddress, l2ChainId, db)
-	web3Server, err := web3.GenerateWeb3Server(srv, nil, false, nil, metricsConfig)
+	plugins := make(map[string]interface{})
+	if config.Node.TraceIndex {
+		traceStore, err := rawdb.NewLevelDBDatabase(config.GetTraceIndexDatabasePath(), 16, 16, "trace_index", false)
+		if err != nil {
+			return errors.Wrap(err, "error opening trace index database")
+		}
+		defer traceStore.Close()
+		index, err := traceindex.New(db, traceStore, traceindex.FilterLimits{
+			MaxBlockRange: config.Node.TraceFilter.MaxBlockRange,
+			MaxResults:    config.Node.TraceFilter.MaxResults,
+		})
+		if err != nil {
+			return errors.Wrap(err, "error opening trace index")
+		}
+		traceIndexErrChan := index.Start(ctx)
+		go func() {
+			if err := <-traceIndexErrChan; err != nil {
+				errChan <- errors.Wrap(err, "error indexing traces")
+			}
+		}()
+		plugins["trace"] = web3.NewTrace(srv, index, metricsConfig)
+	}
+	if lockoutBatcher, ok := batch.(*rpc.LockoutBatcher); ok && config.Node.Sequencer.Lockout.HandoverRPC {
+		plugins["arbadmin"] = rpc.NewAdmin(lockoutBatcher)
+	}
+	web3Server, err := web3.GenerateWeb3Server(srv, nil, false, plugins, metricsConfig)
 	if err != nil {
 		return err
 	}
@@ -340,3 +416,24 @@ func startup() error {
 		return nil
 	}
 }
+
+// validLockoutConfig checks that the sequencer lockout is either disabled or
+// fully configured for its backend
+func validLockoutConfig(nodeType string, lockout configuration.Lockout) bool {
+	if len(lockout.SelfRPCURL) == 0 {
+		return len(lockout.Redis) == 0 && len(lockout.Etcd) == 0
+	}
+	if nodeType != "sequencer" {
+		return false
+	}
+	switch lockout.Backend {
+	case "redis":
+		return len(lockout.Redis) != 0
+	case "etcd":
+		return len(lockout.Etcd) != 0
+	case "memory":
+		return true
+	default:
+		return false
+	}
+}
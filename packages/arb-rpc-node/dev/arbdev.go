@@ -0,0 +1,167 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/inbox"
+)
+
+// ArbDev implements the arbdev_* methods for controlling the dev node
+type ArbDev struct {
+	backend *Backend
+}
+
+func NewArbDev(backend *Backend) *ArbDev {
+	return &ArbDev{backend: backend}
+}
+
+// LoadMessages replaces the chain with the messages in a test vector saved
+// with --save and returns the number of messages loaded
+func (a *ArbDev) LoadMessages(vector json.RawMessage) (hexutil.Uint64, error) {
+	count, err := a.backend.LoadMessages(vector)
+	return hexutil.Uint64(count), err
+}
+
+// L1MessageArgs are the fields shared by all simulated L1 messages. From is
+// the L1 sender, which is random if unset, and L1Block is the L1 block the
+// message is included in, which defaults to the next block.
+type L1MessageArgs struct {
+	From    *ethcommon.Address `json:"from"`
+	L1Block *hexutil.Uint64    `json:"l1Block"`
+}
+
+func (a L1MessageArgs) sender() common.Address {
+	if a.From == nil {
+		return common.RandAddress()
+	}
+	return common.NewAddressFromEth(*a.From)
+}
+
+func (a L1MessageArgs) l1Block() *uint64 {
+	if a.L1Block == nil {
+		return nil
+	}
+	block := uint64(*a.L1Block)
+	return &block
+}
+
+type DepositArgs struct {
+	L1MessageArgs
+	To    ethcommon.Address `json:"to"`
+	Value *hexutil.Big      `json:"value"`
+}
+
+// Deposit simulates an eth deposit of value to to and returns its request ID
+func (a *ArbDev) Deposit(args DepositArgs) (ethcommon.Hash, error) {
+	deposit := message.EthDepositTx{
+		L2Message: message.NewSafeL2Message(message.ContractTransaction{
+			BasicTx: message.BasicTx{
+				MaxGas:      big.NewInt(1000000),
+				GasPriceBid: big.NewInt(0),
+				DestAddress: common.NewAddressFromEth(args.To),
+				Payment:     bigOrZero(args.Value),
+				Data:        nil,
+			},
+		}),
+	}
+	requestId, err := a.backend.AddL1Message(deposit, args.sender(), args.l1Block())
+	return requestId.ToEthHash(), err
+}
+
+// RetryableArgs describe a retryable ticket. CreditBack and Beneficiary
+// default to the sender and Deposit defaults to the amount needed to cover the
+// call value, submission cost and gas.
+type RetryableArgs struct {
+	L1MessageArgs
+	To                ethcommon.Address  `json:"to"`
+	Value             *hexutil.Big       `json:"value"`
+	Deposit           *hexutil.Big       `json:"deposit"`
+	MaxSubmissionCost *hexutil.Big       `json:"maxSubmissionCost"`
+	CreditBack        *ethcommon.Address `json:"creditBack"`
+	Beneficiary       *ethcommon.Address `json:"beneficiary"`
+	MaxGas            *hexutil.Big       `json:"maxGas"`
+	GasPriceBid       *hexutil.Big       `json:"gasPriceBid"`
+	Data              hexutil.Bytes      `json:"data"`
+}
+
+// CreateRetryable simulates the creation of a retryable ticket and returns the
+// request ID of the submission
+func (a *ArbDev) CreateRetryable(args RetryableArgs) (ethcommon.Hash, error) {
+	sender := args.sender()
+	tx := message.RetryableTx{
+		Destination:       common.NewAddressFromEth(args.To),
+		Value:             bigOrZero(args.Value),
+		MaxSubmissionCost: bigOrZero(args.MaxSubmissionCost),
+		CreditBack:        sender,
+		Beneficiary:       sender,
+		MaxGas:            bigOrZero(args.MaxGas),
+		GasPriceBid:       bigOrZero(args.GasPriceBid),
+		Data:              args.Data,
+	}
+	if args.CreditBack != nil {
+		tx.CreditBack = common.NewAddressFromEth(*args.CreditBack)
+	}
+	if args.Beneficiary != nil {
+		tx.Beneficiary = common.NewAddressFromEth(*args.Beneficiary)
+	}
+	if args.Deposit != nil {
+		tx.Deposit = args.Deposit.ToInt()
+	} else {
+		tx.Deposit = new(big.Int).Mul(tx.MaxGas, tx.GasPriceBid)
+		tx.Deposit.Add(tx.Deposit, tx.Value)
+		tx.Deposit.Add(tx.Deposit, tx.MaxSubmissionCost)
+	}
+	requestId, err := a.backend.AddL1Message(tx, sender, args.l1Block())
+	return requestId.ToEthHash(), err
+}
+
+type SendL1MessageArgs struct {
+	L1MessageArgs
+	Kind hexutil.Uint64 `json:"kind"`
+	Data hexutil.Bytes  `json:"data"`
+}
+
+// SendL1Message simulates an L1 message of any kind accepted by ArbOS and
+// returns its request ID
+func (a *ArbDev) SendL1Message(args SendL1MessageArgs) (ethcommon.Hash, error) {
+	if args.Kind > math.MaxUint8 {
+		return ethcommon.Hash{}, errors.Errorf("invalid message kind %v", args.Kind)
+	}
+	msg, err := message.NestedMessage(args.Data, inbox.Type(args.Kind))
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	requestId, err := a.backend.AddL1Message(msg, args.sender(), args.l1Block())
+	return requestId.ToEthHash(), err
+}
+
+func bigOrZero(val *hexutil.Big) *big.Int {
+	if val == nil {
+		return big.NewInt(0)
+	}
+	return val.ToInt()
+}
@@ -0,0 +1,168 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
+)
+
+func TestLoadMessages(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, db, _, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	account := common.RandAddress()
+	_, err := NewHardhat(backend).SetBalance(account.ToEthAddress(), hexutil.Big(*big.NewInt(12345)))
+	test.FailIfError(t, err)
+
+	saved, err := backend.ExportData()
+	test.FailIfError(t, err)
+	blockCount, err := db.BlockCount()
+	test.FailIfError(t, err)
+
+	loadedBackend, loadedDb, _, cancelLoadedNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelLoadedNode()
+
+	count, err := NewArbDev(loadedBackend).LoadMessages(saved)
+	test.FailIfError(t, err)
+	messageCount, err := backend.arbcore.GetMessageCount()
+	test.FailIfError(t, err)
+	if uint64(count) != messageCount.Uint64() {
+		t.Errorf("loaded %v messages instead of %v", count, messageCount)
+	}
+
+	loadedBlockCount, err := loadedDb.BlockCount()
+	test.FailIfError(t, err)
+	if loadedBlockCount != blockCount {
+		t.Errorf("loaded %v blocks instead of %v", loadedBlockCount, blockCount)
+	}
+	snap, err := loadedDb.LatestSnapshot()
+	test.FailIfError(t, err)
+	balance, err := snap.GetBalance(account)
+	test.FailIfError(t, err)
+	if balance.Cmp(big.NewInt(12345)) != 0 {
+		t.Errorf("loaded balance %v instead of 12345", balance)
+	}
+
+	// The loaded chain continues on from the saved chain
+	test.FailIfError(t, loadedBackend.Mine())
+	loadedBlockCount, err = loadedDb.BlockCount()
+	test.FailIfError(t, err)
+	if loadedBlockCount != blockCount+1 {
+		t.Errorf("expected %v blocks after mining but got %v", blockCount+1, loadedBlockCount)
+	}
+}
+
+func TestL1Messages(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, _, srv, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	ctx := context.Background()
+	client := web3.NewEthClient(srv, true, metrics.NewMetricsConfig(nil))
+	arbDev := NewArbDev(backend)
+	sender := common.RandAddress().ToEthAddress()
+	dest := common.RandAddress().ToEthAddress()
+
+	l1Block := hexutil.Uint64(backend.l1Emulator.LatestHeight() + 10)
+	depositId, err := arbDev.Deposit(DepositArgs{
+		L1MessageArgs: L1MessageArgs{From: &sender, L1Block: &l1Block},
+		To:            dest,
+		Value:         (*hexutil.Big)(big.NewInt(1000)),
+	})
+	test.FailIfError(t, err)
+	res, err := backend.db.GetRequest(common.NewHashFromEth(depositId))
+	test.FailIfError(t, err)
+	if res == nil {
+		t.Fatal("deposit result not found")
+	}
+	if res.IncomingRequest.L1BlockNumber.Uint64() != uint64(l1Block) {
+		t.Errorf("deposit in L1 block %v instead of %v", res.IncomingRequest.L1BlockNumber, l1Block)
+	}
+	balance, err := client.BalanceAt(ctx, dest, nil)
+	test.FailIfError(t, err)
+	if balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("deposited balance %v instead of 1000", balance)
+	}
+
+	if _, err := arbDev.Deposit(DepositArgs{L1MessageArgs: L1MessageArgs{L1Block: &l1Block}, To: dest}); err == nil {
+		t.Error("added message to an old L1 block")
+	}
+
+	retryableId, err := arbDev.CreateRetryable(RetryableArgs{
+		L1MessageArgs:     L1MessageArgs{From: &sender},
+		To:                dest,
+		Value:             (*hexutil.Big)(big.NewInt(20)),
+		MaxSubmissionCost: (*hexutil.Big)(big.NewInt(30)),
+	})
+	test.FailIfError(t, err)
+	receipt, err := client.TransactionReceipt(ctx, retryableId)
+	test.FailIfError(t, err)
+	if receipt == nil || receipt.Status != 1 {
+		t.Error("retryable creation failed")
+	}
+
+	tx := message.ContractTransaction{
+		BasicTx: message.BasicTx{
+			MaxGas:      big.NewInt(1000000),
+			GasPriceBid: big.NewInt(0),
+			DestAddress: common.RandAddress(),
+			Payment:     big.NewInt(10),
+			Data:        nil,
+		},
+	}
+	l2Msg := message.NewSafeL2Message(tx)
+	msgId, err := arbDev.SendL1Message(SendL1MessageArgs{
+		L1MessageArgs: L1MessageArgs{From: &dest},
+		Kind:          hexutil.Uint64(message.L2Type),
+		Data:          l2Msg.AsData(),
+	})
+	test.FailIfError(t, err)
+	receipt, err = client.TransactionReceipt(ctx, msgId)
+	test.FailIfError(t, err)
+	if receipt == nil || receipt.Status != 1 {
+		t.Error("L1 message failed")
+	}
+	balance, err = client.BalanceAt(ctx, dest, nil)
+	test.FailIfError(t, err)
+	if balance.Cmp(big.NewInt(990)) != 0 {
+		t.Errorf("balance %v instead of 990 after L1 message", balance)
+	}
+}
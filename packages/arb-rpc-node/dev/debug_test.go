@@ -0,0 +1,135 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/arbostestcontracts"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
+)
+
+func TestDebugTrace(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, _, srv, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	metricsConfig := metrics.NewMetricsConfig(nil)
+	client := web3.NewEthClient(srv, true, metricsConfig)
+	debug := web3.NewDebug(web3.NewServer(srv, true, metricsConfig))
+
+	senderKey, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	auth, err := bind.NewKeyedTransactorWithChainID(senderKey, backend.chainID)
+	test.FailIfError(t, err)
+	simpleAddr, _, simple, err := arbostestcontracts.DeploySimple(auth, client)
+	test.FailIfError(t, err)
+	simpleABI, err := abi.JSON(strings.NewReader(arbostestcontracts.SimpleABI))
+	test.FailIfError(t, err)
+
+	// trace creates a contract and catches a failed contract creation
+	tx, err := simple.Trace(auth, big.NewInt(0))
+	test.FailIfError(t, err)
+	frame, err := debug.TraceTransaction(tx.Hash().Bytes(), nil)
+	test.FailIfError(t, err)
+	if frame.Type != "CALL" || frame.To == nil || *frame.To != simpleAddr || frame.From != auth.From {
+		t.Errorf("unexpected top level call %v from %v to %v", frame.Type, frame.From.Hex(), frame.To)
+	}
+	if frame.Error != "" {
+		t.Error("successful transaction traced with error", frame.Error)
+	}
+	if len(frame.Calls) < 2 {
+		t.Fatalf("expected at least 2 subcalls but got %v", len(frame.Calls))
+	}
+	if frame.Calls[0].Type != "CREATE" || frame.Calls[0].Error != "" {
+		t.Errorf("unexpected first subcall %v with error %v", frame.Calls[0].Type, frame.Calls[0].Error)
+	}
+	if frame.Calls[1].Type != "CREATE" || frame.Calls[1].Error != "execution reverted" {
+		t.Errorf("unexpected second subcall %v with error %v", frame.Calls[1].Type, frame.Calls[1].Error)
+	}
+
+	// The dev node drops reverted transactions so send one from L1 instead
+	revertsData := simpleABI.Methods["reverts"].ID
+	revertedTx := message.ContractTransaction{
+		BasicTx: message.BasicTx{
+			MaxGas:      big.NewInt(1000000),
+			GasPriceBid: big.NewInt(0),
+			DestAddress: common.NewAddressFromEth(simpleAddr),
+			Payment:     big.NewInt(0),
+			Data:        revertsData,
+		},
+	}
+	revertedId, err := NewArbDev(backend).SendL1Message(SendL1MessageArgs{
+		L1MessageArgs: L1MessageArgs{From: &auth.From},
+		Kind:          hexutil.Uint64(message.L2Type),
+		Data:          message.NewSafeL2Message(revertedTx).AsData(),
+	})
+	test.FailIfError(t, err)
+	frame, err = debug.TraceTransaction(revertedId.Bytes(), nil)
+	test.FailIfError(t, err)
+	if frame.Error != "execution reverted" {
+		t.Errorf("reverted transaction traced with error %q", frame.Error)
+	}
+	if len(frame.Output) == 0 {
+		t.Error("reverted transaction trace missing revert reason")
+	}
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	existsData := hexutil.Bytes(simpleABI.Methods["exists"].ID)
+	frame, err = debug.TraceCall(web3.CallTxArgs{From: &auth.From, To: &simpleAddr, Data: &existsData}, latest, nil)
+	test.FailIfError(t, err)
+	if frame.Error != "" || new(big.Int).SetBytes(frame.Output).Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("unexpected call result %v with error %v", frame.Output, frame.Error)
+	}
+
+	revertsArg := hexutil.Bytes(revertsData)
+	frame, err = debug.TraceCall(web3.CallTxArgs{From: &auth.From, To: &simpleAddr, Data: &revertsArg}, latest, nil)
+	test.FailIfError(t, err)
+	if frame.Error != "execution reverted" {
+		t.Errorf("reverted call traced with error %q", frame.Error)
+	}
+
+	tracer := "prestateTracer"
+	if _, err := debug.TraceTransaction(tx.Hash().Bytes(), &web3.TraceConfig{Tracer: &tracer}); err == nil {
+		t.Error("traced transaction with unknown tracer")
+	}
+	if _, err := debug.TraceCall(web3.CallTxArgs{To: &simpleAddr, Data: &existsData}, latest, &web3.TraceConfig{Tracer: &tracer}); err == nil {
+		t.Error("traced call with unknown tracer")
+	}
+	if _, err := debug.TraceTransaction(common.RandHash().Bytes(), nil); err == nil {
+		t.Error("traced unknown transaction")
+	}
+}
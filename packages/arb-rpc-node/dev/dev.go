@@ -40,6 +40,7 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/monitor"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/aggregator"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/batcher"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/snapshot"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/txdb"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
@@ -120,8 +121,29 @@ func (s *EVM) Mine(timestamp *hexutil.Uint64) error {
 	if timestamp != nil {
 		s.backend.l1Emulator.SetTime(int64(*timestamp))
 	}
-	_, err := s.backend.AddInboxMessage(message.NewSafeL2Message(message.HeartbeatMessage{}), common.Address{})
-	return err
+	return s.backend.Mine()
+}
+
+func (s *EVM) SetAutomine(enabled bool) (bool, error) {
+	if err := s.backend.SetAutomine(enabled); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetIntervalMining mines a block every interval milliseconds, or disables
+// interval mining if interval is 0
+func (s *EVM) SetIntervalMining(interval int64) (bool, error) {
+	if interval < 0 {
+		return false, errors.New("mining interval can't be negative")
+	}
+	s.backend.SetIntervalMining(time.Duration(interval) * time.Millisecond)
+	return true, nil
+}
+
+func (s *EVM) SetNextBlockTimestamp(timestamp int64) (string, error) {
+	s.backend.l1Emulator.SetNextTimestamp(timestamp)
+	return strconv.FormatInt(timestamp, 10), nil
 }
 
 func (s *EVM) IncreaseTime(amount int64) (string, error) {
@@ -150,6 +172,10 @@ func NewBackendCore(ctx context.Context, arbcore core.ArbCore, chainID *big.Int)
 	}, nil
 }
 
+func (b *BackendCore) ChainID() *big.Int {
+	return b.chainID
+}
+
 func (b *BackendCore) addInboxMessage(msg message.Message, sender common.Address, gasPrice *big.Int, block L1BlockInfo) (common.Hash, error) {
 	chainTime := inbox.ChainTime{
 		BlockNum:  block.blockId.Height,
@@ -171,7 +197,7 @@ func (b *BackendCore) addInboxMessage(msg message.Message, sender common.Address
 	}
 	seqBatchItem := inbox.NewSequencerItem(b.delayedCount, inboxMessage, prevHash)
 	nextBlockMessage := inbox.InboxMessage{
-		Kind:        6,
+		Kind:        message.EndOfBlockType,
 		Sender:      common.Address{},
 		InboxSeqNum: new(big.Int).Add(msgCount, big.NewInt(1)),
 		GasPrice:    big.NewInt(0),
@@ -186,13 +212,22 @@ func (b *BackendCore) addInboxMessage(msg message.Message, sender common.Address
 	if err != nil {
 		return common.Hash{}, err
 	}
+	if err := b.waitForExecution(); err != nil {
+		return common.Hash{}, err
+	}
+	return requestId, nil
+}
+
+// waitForExecution waits until the machine has executed all delivered
+// messages and all of its logs have been consumed
+func (b *BackendCore) waitForExecution() error {
 	for {
 		if b.arbcore.MachineIdle() {
 			break
 		}
 		select {
 		case <-b.ctx.Done():
-			return [32]byte{}, errors.New("dev node canceled")
+			return errors.New("dev node canceled")
 		case <-time.After(time.Millisecond * 200):
 		}
 
@@ -200,23 +235,22 @@ func (b *BackendCore) addInboxMessage(msg message.Message, sender common.Address
 	for {
 		cursorPos, err := b.arbcore.LogsCursorPosition(big.NewInt(0))
 		if err != nil {
-			return common.Hash{}, err
+			return err
 		}
 		coreLogs, err := b.arbcore.GetLogCount()
 		if err != nil {
-			return common.Hash{}, err
+			return err
 		}
 		if cursorPos.Cmp(coreLogs) == 0 {
 			break
 		}
 		select {
 		case <-b.ctx.Done():
-			return [32]byte{}, errors.New("dev node canceled")
+			return errors.New("dev node canceled")
 		case <-time.After(time.Millisecond * 200):
 		}
 	}
-
-	return requestId, nil
+	return nil
 }
 
 type Backend struct {
@@ -230,6 +264,16 @@ type Backend struct {
 	chainAggregator   common.Address
 	l1GasPrice        *big.Int
 
+	// Difference between the L2 block count and the emulated L1 height, which
+	// is only nonzero for forked chains
+	blockCountOffset int64
+
+	// When automine is disabled transactions are queued until the next block
+	// is mined
+	automine       bool
+	pendingTxes    []*types.Transaction
+	intervalCancel context.CancelFunc
+
 	newTxFeed event.Feed
 }
 
@@ -243,6 +287,7 @@ func NewBackend(ctx context.Context, core *BackendCore, db *txdb.TxDB, l1 *L1Emu
 		currentAggregator: aggregator,
 		chainAggregator:   aggregator,
 		l1GasPrice:        l1GasPrice,
+		automine:          true,
 	}
 }
 
@@ -260,6 +305,64 @@ func (b *Backend) ExportData() ([]byte, error) {
 	return inbox.TestVectorJSON(messages, nil, nil)
 }
 
+// LoadMessages replaces the chain with the inbox messages in a test vector
+// saved by ExportData and returns the number of messages loaded
+func (b *Backend) LoadMessages(data []byte) (uint64, error) {
+	messages, _, _, err := inbox.LoadTestVector(data)
+	if err != nil {
+		return 0, errors.Wrap(err, "error parsing saved messages")
+	}
+	if len(messages) == 0 {
+		return 0, errors.New("no messages to load")
+	}
+
+	items := make([]inbox.SequencerBatchItem, 0, len(messages))
+	var prevAcc common.Hash
+	l1Height := big.NewInt(0)
+	timestamp := big.NewInt(0)
+	for i, msg := range messages {
+		if msg.InboxSeqNum.Cmp(big.NewInt(int64(i))) != 0 {
+			return 0, errors.Errorf("saved message %v has sequence number %v", i, msg.InboxSeqNum)
+		}
+		item := inbox.NewSequencerItem(b.delayedCount, msg, prevAcc)
+		items = append(items, item)
+		prevAcc = item.Accumulator
+		if msg.ChainTime.BlockNum.AsInt().Cmp(l1Height) > 0 {
+			l1Height = msg.ChainTime.BlockNum.AsInt()
+		}
+		if msg.ChainTime.Timestamp.Cmp(timestamp) > 0 {
+			timestamp = msg.ChainTime.Timestamp
+		}
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	messageCount, err := b.arbcore.GetMessageCount()
+	if err != nil {
+		return 0, err
+	}
+	if messageCount.Sign() > 0 {
+		if err := core.ReorgAndWait(b.arbcore, big.NewInt(0)); err != nil {
+			return 0, err
+		}
+	}
+	b.pendingTxes = nil
+
+	if err := core.DeliverMessagesAndWait(b.arbcore, big.NewInt(0), common.Hash{}, items, nil, nil); err != nil {
+		return 0, err
+	}
+	if err := b.waitForExecution(); err != nil {
+		return 0, err
+	}
+	b.l1Emulator.Load(l1Height.Uint64(), timestamp.Int64())
+	b.blockCountOffset = 0
+	if err := b.waitForBlockCount(l1Height.Uint64()); err != nil {
+		return 0, err
+	}
+	logger.Info().Int("count", len(messages)).Uint64("l1Height", l1Height.Uint64()).Msg("loaded saved messages")
+	return uint64(len(messages)), nil
+}
+
 func (b *Backend) Reorg(messageCount, blockCount uint64) error {
 	b.Lock()
 	defer b.Unlock()
@@ -275,7 +378,10 @@ func (b *Backend) reorg(messageCount, blockCount uint64) error {
 	return b.waitForBlockCount(blockCount)
 }
 
-func (b *Backend) waitForBlockCount(blockCount uint64) error {
+// waitForBlockCount waits until the L2 chain has caught up with the given
+// emulated L1 height
+func (b *Backend) waitForBlockCount(l1Height uint64) error {
+	blockCount := uint64(int64(l1Height) + b.blockCountOffset)
 	for {
 		blocks, err := b.db.BlockCount()
 		if err != nil {
@@ -289,9 +395,125 @@ func (b *Backend) waitForBlockCount(blockCount uint64) error {
 	return nil
 }
 
-func (b *Backend) PendingTransactionCount(_ context.Context, _ common.Address) *uint64 {
+func (b *Backend) PendingTransactionCount(_ context.Context, account common.Address) *uint64 {
+	b.Lock()
+	defer b.Unlock()
+	var count *uint64
+	for _, tx := range b.pendingTxes {
+		sender, err := types.Sender(b.signer, tx)
+		if err != nil || sender != account.ToEthAddress() {
+			continue
+		}
+		nonce := tx.Nonce() + 1
+		if count == nil || nonce > *count {
+			count = &nonce
+		}
+	}
+	return count
+}
+
+// TxPoolContent returns the transactions queued for the next block
+func (b *Backend) TxPoolContent() (map[ethcommon.Address][]*types.Transaction, map[ethcommon.Address][]*types.Transaction) {
+	b.Lock()
+	defer b.Unlock()
+	pending := make(map[ethcommon.Address][]*types.Transaction)
+	for _, tx := range b.pendingTxes {
+		sender, err := types.Sender(b.signer, tx)
+		if err != nil {
+			continue
+		}
+		pending[sender] = append(pending[sender], tx)
+	}
+	return pending, make(map[ethcommon.Address][]*types.Transaction)
+}
+
+// PendingBlock returns the queued transactions in the order they'll be mined
+// and the timestamp of the next block
+func (b *Backend) PendingBlock() ([]batcher.PendingTx, uint64) {
+	b.Lock()
+	defer b.Unlock()
+	pending := make([]batcher.PendingTx, 0, len(b.pendingTxes))
+	for _, tx := range b.pendingTxes {
+		sender, err := types.Sender(b.signer, tx)
+		if err != nil {
+			continue
+		}
+		pending = append(pending, batcher.PendingTx{Sender: sender, Tx: tx})
+	}
+	return pending, uint64(b.l1Emulator.NextTimestamp())
+}
+
+// SetAutomine controls whether each transaction is mined in its own block as
+// soon as it is received. Enabling it mines any queued transactions.
+func (b *Backend) SetAutomine(enabled bool) error {
+	b.Lock()
+	defer b.Unlock()
+	b.automine = enabled
+	if enabled && len(b.pendingTxes) > 0 {
+		return b.mine()
+	}
+	return nil
+}
+
+// SetIntervalMining mines a block every interval, or stops interval mining
+// if interval is 0
+func (b *Backend) SetIntervalMining(interval time.Duration) {
 	b.Lock()
 	defer b.Unlock()
+	if b.intervalCancel != nil {
+		b.intervalCancel()
+		b.intervalCancel = nil
+	}
+	if interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(b.ctx)
+	b.intervalCancel = cancel
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := b.Mine(); err != nil {
+					logger.Error().Err(err).Msg("error mining block")
+				}
+			}
+		}
+	}()
+}
+
+// Mine adds a block containing all queued transactions, or an empty block if
+// there are none
+func (b *Backend) Mine() error {
+	b.Lock()
+	defer b.Unlock()
+	return b.mine()
+}
+
+func (b *Backend) mine() error {
+	if len(b.pendingTxes) == 0 {
+		_, err := b.addBlock(message.NewSafeL2Message(message.HeartbeatMessage{}), common.Address{}, big.NewInt(0))
+		return err
+	}
+
+	txes := make([]message.AbstractL2Message, 0, len(b.pendingTxes))
+	for _, tx := range b.pendingTxes {
+		txes = append(txes, message.NewCompressedECDSAFromEth(tx))
+	}
+	batch, err := message.NewTransactionBatchFromMessages(txes)
+	if err != nil {
+		return err
+	}
+	// Transactions which fail are included in the block like on L1 rather
+	// than being rolled back as with automine
+	if _, err := b.addBlock(message.NewSafeL2Message(batch), b.currentAggregator, b.l1GasPrice); err != nil {
+		return err
+	}
+	logger.Info().Int("count", len(b.pendingTxes)).Msg("mined queued transactions")
+	b.pendingTxes = nil
 	return nil
 }
 
@@ -319,45 +541,99 @@ func (b *Backend) SendTransaction(_ context.Context, tx *types.Transaction) erro
 		Hex("hash", tx.Hash().Bytes()).
 		Msg("sent transaction")
 
+	if !b.automine {
+		b.pendingTxes = append(b.pendingTxes, tx)
+		b.newTxFeed.Send(core2.NewTxsEvent{Txs: []*types.Transaction{tx}})
+		return nil
+	}
+
+	txHash := common.NewHashFromEth(tx.Hash())
+	_, err = b.deliverRequest(message.NewSafeL2Message(arbMsg), b.currentAggregator, b.l1GasPrice, &txHash)
+	return err
+}
+
+// deliverRequest adds msg in a new block and checks the result of the request
+// it creates, which is looked up by txHash if given or by the message's
+// request ID otherwise. If the request fails its block is replaced with an
+// empty one.
+func (b *Backend) deliverRequest(msg message.Message, sender common.Address, gasPrice *big.Int, txHash *common.Hash) (common.Hash, error) {
 	startHeight := b.l1Emulator.LatestHeight()
 	startCount, err := b.arbcore.GetMessageCount()
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
 
-	block := b.l1Emulator.GenerateBlock()
-	if _, err := b.addInboxMessage(message.NewSafeL2Message(arbMsg), b.currentAggregator, b.l1GasPrice, block); err != nil {
-		return err
+	requestId, err := b.addBlock(msg, sender, gasPrice)
+	if err != nil {
+		return common.Hash{}, err
 	}
-	if err := b.waitForBlockCount(block.blockId.Height.AsInt().Uint64()); err != nil {
-		return err
+	if txHash != nil {
+		requestId = *txHash
 	}
-	txHash := common.NewHashFromEth(tx.Hash())
-	res, err := b.db.GetRequest(txHash)
+	res, err := b.db.GetRequest(requestId)
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
 	if res == nil {
-		return errors.New("tx res not found")
+		return common.Hash{}, errors.New("tx res not found")
 	}
 
 	if res.ResultCode != evm.ReturnCode {
 		logger.Warn().Int("code", int(res.ResultCode)).Msg("transaction failed")
 		// If transaction failed, rollback the block
 		if err := b.reorg(startCount.Uint64(), startHeight); err != nil {
-			return err
+			return common.Hash{}, err
 		}
 
 		// Insert an empty block instead
 		block := b.l1Emulator.GenerateBlock()
 		if _, err := b.addInboxMessage(message.NewSafeL2Message(message.HeartbeatMessage{}), b.currentAggregator, b.l1GasPrice, block); err != nil {
-			return err
+			return common.Hash{}, err
 		}
 
-		return evm.HandleCallError(res, true)
+		return common.Hash{}, evm.HandleCallError(res, true)
 	}
 
-	return nil
+	return requestId, nil
+}
+
+// addBlock adds msg in a new block and waits for the block to be processed
+func (b *Backend) addBlock(msg message.Message, sender common.Address, gasPrice *big.Int) (common.Hash, error) {
+	block := b.l1Emulator.GenerateBlock()
+	requestId, err := b.addInboxMessage(msg, sender, gasPrice, block)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := b.waitForBlockCount(block.blockId.Height.AsInt().Uint64()); err != nil {
+		return common.Hash{}, err
+	}
+	return requestId, nil
+}
+
+// AddL1Message adds msg from the L1 address sender in a new L1 block at
+// l1Block, or the next L1 block if nil, and returns its request ID. Unlike
+// transactions, L1 messages are kept in the chain if they fail.
+func (b *Backend) AddL1Message(msg message.Message, sender common.Address, l1Block *uint64) (common.Hash, error) {
+	b.Lock()
+	defer b.Unlock()
+	var block L1BlockInfo
+	if l1Block != nil {
+		var err error
+		block, err = b.l1Emulator.GenerateBlockAt(*l1Block)
+		if err != nil {
+			return common.Hash{}, err
+		}
+	} else {
+		block = b.l1Emulator.GenerateBlock()
+	}
+	requestId, err := b.addInboxMessage(msg, sender, b.l1GasPrice, block)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := b.waitForBlockCount(block.blockId.Height.AsInt().Uint64()); err != nil {
+		return common.Hash{}, err
+	}
+	return requestId, nil
 }
 
 func (b *Backend) Aggregator() *common.Address {
@@ -379,7 +655,29 @@ func (b *Backend) SubscribeNewTxsEvent(ch chan<- core2.NewTxsEvent) event.Subscr
 func (b *Backend) PendingSnapshot() (*snapshot.Snapshot, error) {
 	b.Lock()
 	defer b.Unlock()
-	return nil, nil
+	if len(b.pendingTxes) == 0 {
+		return nil, nil
+	}
+	latest, err := b.db.LatestSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	snap := latest.Clone()
+	for _, tx := range b.pendingTxes {
+		sender, err := types.Sender(b.signer, tx)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := message.NewL2Message(message.SignedTransaction{Tx: tx})
+		if err != nil {
+			return nil, err
+		}
+		// Queued transactions which would fail are left out of the snapshot
+		if _, err := snap.AddMessage(msg, common.NewAddressFromEth(sender), common.NewHashFromEth(tx.Hash())); err != nil {
+			logger.Debug().Err(err).Hex("hash", tx.Hash().Bytes()).Msg("queued transaction not applied to pending snapshot")
+		}
+	}
+	return snap, nil
 }
 
 func (b *Backend) Start(ctx context.Context) {
@@ -392,8 +690,9 @@ type L1BlockInfo struct {
 
 type L1Emulator struct {
 	sync.Mutex
-	timeIncrease int64
-	latestHeight uint64
+	timeIncrease  int64
+	latestHeight  uint64
+	nextTimestamp *int64
 }
 
 func NewL1Emulator(initialHeight uint64) *L1Emulator {
@@ -415,29 +714,82 @@ func (b *L1Emulator) Reorg(height uint64) {
 }
 
 func (b *L1Emulator) addBlock() L1BlockInfo {
+	timestamp := b.nextTimestampUnsafe()
+	if b.nextTimestamp != nil {
+		// Later blocks continue on from the requested timestamp
+		b.timeIncrease = timestamp - time.Now().Unix()
+		b.nextTimestamp = nil
+	}
 	info := L1BlockInfo{
 		blockId: &common.BlockId{
 			Height:     common.NewTimeBlocksInt(int64(b.latestHeight + 1)),
 			HeaderHash: common.RandHash(),
 		},
-		timestamp: big.NewInt(time.Now().Unix() + b.timeIncrease),
+		timestamp: big.NewInt(timestamp),
 	}
 	b.latestHeight += 1
 	return info
 }
 
+// NextTimestamp returns the timestamp the next generated block would have
+// if it was generated now
+func (b *L1Emulator) NextTimestamp() int64 {
+	b.Lock()
+	defer b.Unlock()
+	return b.nextTimestampUnsafe()
+}
+
+func (b *L1Emulator) nextTimestampUnsafe() int64 {
+	if b.nextTimestamp != nil {
+		return *b.nextTimestamp
+	}
+	return time.Now().Unix() + b.timeIncrease
+}
+
 func (b *L1Emulator) GenerateBlock() L1BlockInfo {
 	b.Lock()
 	defer b.Unlock()
 	return b.addBlock()
 }
 
+// Load continues the emulated chain on from the given height. Block
+// timestamps continue from timestamp if it is later than the current time.
+func (b *L1Emulator) Load(height uint64, timestamp int64) {
+	b.Lock()
+	defer b.Unlock()
+	b.latestHeight = height
+	b.timeIncrease = 0
+	b.nextTimestamp = nil
+	if ahead := timestamp - time.Now().Unix(); ahead > 0 {
+		b.timeIncrease = ahead
+	}
+}
+
+// GenerateBlockAt generates a block at height, skipping any blocks between
+// it and the latest block
+func (b *L1Emulator) GenerateBlockAt(height uint64) (L1BlockInfo, error) {
+	b.Lock()
+	defer b.Unlock()
+	if height <= b.latestHeight {
+		return L1BlockInfo{}, errors.Errorf("L1 block %v isn't after the latest L1 block %v", height, b.latestHeight)
+	}
+	b.latestHeight = height - 1
+	return b.addBlock(), nil
+}
+
 func (b *L1Emulator) SetTime(timestamp int64) {
 	b.Lock()
 	defer b.Unlock()
 	b.timeIncrease = timestamp - time.Now().Unix()
 }
 
+// SetNextTimestamp sets the timestamp of the next generated block
+func (b *L1Emulator) SetNextTimestamp(timestamp int64) {
+	b.Lock()
+	defer b.Unlock()
+	b.nextTimestamp = &timestamp
+}
+
 func (b *L1Emulator) IncreaseTime(amount int64) {
 	b.Lock()
 	defer b.Unlock()
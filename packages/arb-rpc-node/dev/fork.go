@@ -0,0 +1,241 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/monitor"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/txdb"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/core"
+)
+
+// NewForkedDevNode starts a dev node from the state of an arb-node database
+// at the end of L2 block forkBlock, or at its latest block if forkBlock is
+// negative. The database in forkDir is never modified; it is overlaid into
+// dir, which must be empty, and the dev node's blocks are added on top.
+func NewForkedDevNode(ctx context.Context, forkDir string, dir string, arbosPath string, agg common.Address, forkBlock int64) (*Backend, *txdb.TxDB, func(), <-chan error, error) {
+	if err := overlayDir(forkDir, dir); err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "error copying forked database")
+	}
+
+	mon, err := monitor.NewMonitor(dir, arbosPath)
+	if err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "error opening monitor")
+	}
+
+	db, errChan, err := txdb.New(ctx, mon.Core, mon.Storage.GetNodeStore(), 10*time.Millisecond)
+	if err != nil {
+		mon.Close()
+		return nil, nil, nil, nil, errors.Wrap(err, "error opening txdb")
+	}
+
+	cancel := func() {
+		db.Close()
+		mon.Close()
+	}
+
+	snap, err := db.LatestSnapshot()
+	if err != nil {
+		cancel()
+		return nil, nil, nil, nil, err
+	}
+	if snap == nil {
+		cancel()
+		return nil, nil, nil, nil, errors.New("forked database has no blocks")
+	}
+	chainId, err := snap.ChainId()
+	if err != nil {
+		cancel()
+		return nil, nil, nil, nil, err
+	}
+
+	backendCore, err := NewBackendCore(ctx, mon.Core, chainId)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, nil, err
+	}
+	signer := types.NewEIP155Signer(chainId)
+	backend := NewBackend(ctx, backendCore, db, NewL1Emulator(0), signer, agg, big.NewInt(100000000000))
+	if err := backend.fork(forkBlock); err != nil {
+		cancel()
+		return nil, nil, nil, nil, errors.Wrap(err, "error forking chain")
+	}
+
+	return backend, db, cancel, errChan, nil
+}
+
+// fork reorgs the chain back to the end of the given L2 block, or keeps the
+// latest block if height is negative, and continues the emulated L1 chain on
+// from that block
+func (b *Backend) fork(height int64) error {
+	b.Lock()
+	defer b.Unlock()
+	if err := b.waitForExecution(); err != nil {
+		return err
+	}
+
+	if height >= 0 {
+		info, err := b.db.GetBlock(uint64(height))
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			return errors.Errorf("block %v not found", height)
+		}
+		messageCount, err := b.messageCountAtLog(info.BlockLog)
+		if err != nil {
+			return err
+		}
+		if err := core.ReorgAndWait(b.arbcore, messageCount); err != nil {
+			return err
+		}
+		if err := b.waitForExecution(); err != nil {
+			return err
+		}
+	}
+
+	delayedCount, err := b.arbcore.GetTotalDelayedMessagesSequenced()
+	if err != nil {
+		return err
+	}
+	b.delayedCount = delayedCount
+
+	latest, err := b.db.LatestBlock()
+	if err != nil {
+		return err
+	}
+	l2Block, err := b.db.GetL2Block(latest)
+	if err != nil {
+		return err
+	}
+	if height >= 0 && latest.Header.Number.Cmp(big.NewInt(height)) != 0 {
+		// Reorgs can only stop at the boundary of a sequencer batch item
+		logger.Warn().Int64("requested", height).Uint64("block", latest.Header.Number.Uint64()).Msg("forked from an earlier block than requested")
+	}
+
+	blockCount, err := b.db.BlockCount()
+	if err != nil {
+		return err
+	}
+	l1Height := l2Block.L1BlockNum.Uint64()
+	b.l1Emulator.Load(l1Height, int64(latest.Header.Time))
+	b.blockCountOffset = int64(blockCount) - int64(l1Height)
+	logger.Info().Uint64("block", latest.Header.Number.Uint64()).Uint64("l1Block", l1Height).Msg("forked chain")
+	return nil
+}
+
+// messageCountAtLog finds the number of messages which had been read when the
+// machine emitted the log with the given index
+func (b *Backend) messageCountAtLog(logIndex uint64) (*big.Int, error) {
+	totalGas, err := b.arbcore.GetLastMachineTotalGas()
+	if err != nil {
+		return nil, err
+	}
+
+	// Search for the first point in execution where the log was emitted
+	low := big.NewInt(0)
+	high := new(big.Int).Set(totalGas)
+	var found core.ExecutionCursor
+	for low.Cmp(high) <= 0 {
+		mid := new(big.Int).Add(low, high)
+		mid.Rsh(mid, 1)
+		cursor, err := b.arbcore.GetExecutionCursor(mid)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.TotalLogCount().Cmp(new(big.Int).SetUint64(logIndex)) > 0 {
+			found = cursor
+			high = mid.Sub(mid, big.NewInt(1))
+		} else {
+			low = mid.Add(mid, big.NewInt(1))
+		}
+	}
+	if found == nil {
+		return nil, errors.Errorf("log %v hasn't been emitted", logIndex)
+	}
+
+	// A block can be ended by the first message of the following block rather
+	// than an end of block message, in which case that message isn't kept
+	messageCount := found.TotalMessagesRead()
+	if messageCount.Sign() > 0 {
+		last, err := core.GetSingleMessage(b.arbcore, new(big.Int).Sub(messageCount, big.NewInt(1)))
+		if err != nil {
+			return nil, err
+		}
+		if last.Kind != message.EndOfBlockType {
+			messageCount = new(big.Int).Sub(messageCount, big.NewInt(1))
+		}
+	}
+	return messageCount, nil
+}
+
+// overlayDir copies the database in src into the empty or missing directory
+// dst. RocksDB never modifies its table files once written, so they are hard
+// linked where possible instead of being copied.
+func overlayDir(src string, dst string) error {
+	if files, err := ioutil.ReadDir(dst); err == nil && len(files) > 0 {
+		return errors.Errorf("directory %v isn't empty", dst)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		if filepath.Ext(path) == ".sst" {
+			if err := os.Link(path, target); err == nil {
+				return nil
+			}
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,120 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
+)
+
+func TestOverlayDir(t *testing.T) {
+	src := t.TempDir()
+	test.FailIfError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	test.FailIfError(t, ioutil.WriteFile(filepath.Join(src, "000001.sst"), []byte("table"), 0644))
+	test.FailIfError(t, ioutil.WriteFile(filepath.Join(src, "sub", "MANIFEST"), []byte("manifest"), 0644))
+
+	dst := filepath.Join(t.TempDir(), "overlay")
+	test.FailIfError(t, overlayDir(src, dst))
+
+	// Changes to the overlay must not reach the source
+	test.FailIfError(t, os.Remove(filepath.Join(dst, "000001.sst")))
+	test.FailIfError(t, ioutil.WriteFile(filepath.Join(dst, "sub", "MANIFEST"), []byte("changed"), 0644))
+	data, err := ioutil.ReadFile(filepath.Join(src, "000001.sst"))
+	test.FailIfError(t, err)
+	if string(data) != "table" {
+		t.Error("table file modified")
+	}
+	data, err = ioutil.ReadFile(filepath.Join(src, "sub", "MANIFEST"))
+	test.FailIfError(t, err)
+	if string(data) != "manifest" {
+		t.Error("copied file modified")
+	}
+
+	if err := overlayDir(src, dst); err == nil {
+		t.Error("overlaid into directory that wasn't empty")
+	}
+}
+
+func TestFork(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	chainId := big.NewInt(42161)
+	backend, db, cancelDevNode, _, err := NewDevNode(ctx, dir, *arbosfile, chainId, common.RandAddress(), 0)
+	test.FailIfError(t, err)
+	initMsg, err := message.NewInitMessage(config, common.RandAddress(), []message.ChainConfigOption{message.ChainIDConfig{ChainId: chainId}})
+	test.FailIfError(t, err)
+	_, err = backend.AddInboxMessage(initMsg, common.RandAddress())
+	test.FailIfError(t, err)
+
+	hardhat := NewHardhat(backend)
+	account := common.RandAddress()
+	_, err = hardhat.SetBalance(account.ToEthAddress(), hexutil.Big(*big.NewInt(100)))
+	test.FailIfError(t, err)
+	forkBlock, err := db.LatestBlock()
+	test.FailIfError(t, err)
+	_, err = hardhat.SetBalance(account.ToEthAddress(), hexutil.Big(*big.NewInt(200)))
+	test.FailIfError(t, err)
+	cancelDevNode()
+
+	forked, forkedDb, cancelForkedNode, _, err := NewForkedDevNode(ctx, dir, t.TempDir(), *arbosfile, common.RandAddress(), forkBlock.Header.Number.Int64())
+	test.FailIfError(t, err)
+	defer cancelForkedNode()
+
+	if forked.ChainID().Cmp(chainId) != 0 {
+		t.Errorf("forked chain has chain id %v instead of %v", forked.ChainID(), chainId)
+	}
+	latest, err := forkedDb.LatestBlock()
+	test.FailIfError(t, err)
+	if latest.Header.Number.Cmp(forkBlock.Header.Number) != 0 {
+		t.Errorf("forked at block %v instead of %v", latest.Header.Number, forkBlock.Header.Number)
+	}
+	snap, err := forkedDb.LatestSnapshot()
+	test.FailIfError(t, err)
+	balance, err := snap.GetBalance(account)
+	test.FailIfError(t, err)
+	if balance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("forked balance %v instead of 100", balance)
+	}
+
+	// New blocks are added on top of the forked block
+	_, err = NewHardhat(forked).SetBalance(account.ToEthAddress(), hexutil.Big(*big.NewInt(300)))
+	test.FailIfError(t, err)
+	latest, err = forkedDb.LatestBlock()
+	test.FailIfError(t, err)
+	if latest.Header.Number.Cmp(new(big.Int).Add(forkBlock.Header.Number, big.NewInt(1))) != 0 {
+		t.Errorf("expected block %v after fork but got %v", forkBlock.Header.Number.Int64()+1, latest.Header.Number)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/arbos"
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+)
+
+// Maximum nonce increase accepted by hardhat_setNonce since every increment
+// is executed as a separate transaction
+const maxNonceIncrease = 10000
+
+// Destination of the funds removed by hardhat_setBalance
+var burnAddress = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+// Hardhat implements the hardhat_* state manipulation methods. Every change is
+// made by adding messages to the inbox so that it is reflected in AVM state.
+type Hardhat struct {
+	backend *Backend
+
+	mu           sync.Mutex
+	impersonated map[common.Address]bool
+}
+
+func NewHardhat(backend *Backend) *Hardhat {
+	return &Hardhat{
+		backend:      backend,
+		impersonated: make(map[common.Address]bool),
+	}
+}
+
+func (h *Hardhat) SetBalance(account ethcommon.Address, balance hexutil.Big) (bool, error) {
+	h.backend.Lock()
+	defer h.backend.Unlock()
+	target := (*big.Int)(&balance)
+	if target.Sign() < 0 {
+		return false, errors.New("balance can't be negative")
+	}
+	addr := common.NewAddressFromEth(account)
+	snap, err := h.backend.db.LatestSnapshot()
+	if err != nil {
+		return false, err
+	}
+	current, err := snap.GetBalance(addr)
+	if err != nil {
+		return false, err
+	}
+	diff := new(big.Int).Sub(target, current)
+	switch diff.Sign() {
+	case 0:
+		return true, nil
+	case 1:
+		// Deposit the difference into a contract which immediately
+		// selfdestructs to the account so that none of its code is run
+		initCode := append([]byte{0x73}, addr.Bytes()...)
+		initCode = append(initCode, 0xff)
+		deposit := message.EthDepositTx{
+			L2Message: message.NewSafeL2Message(message.ContractTransaction{
+				BasicTx: message.BasicTx{
+					MaxGas:      big.NewInt(1000000),
+					GasPriceBid: big.NewInt(0),
+					DestAddress: common.Address{},
+					Payment:     diff,
+					Data:        initCode,
+				},
+			}),
+		}
+		if _, err := h.backend.deliverRequest(deposit, common.RandAddress(), big.NewInt(0), nil); err != nil {
+			return false, err
+		}
+	default:
+		tx := message.ContractTransaction{
+			BasicTx: message.BasicTx{
+				MaxGas:      big.NewInt(1000000),
+				GasPriceBid: big.NewInt(0),
+				DestAddress: burnAddress,
+				Payment:     new(big.Int).Neg(diff),
+				Data:        nil,
+			},
+		}
+		if _, err := h.backend.deliverRequest(message.NewSafeL2Message(tx), addr, big.NewInt(0), nil); err != nil {
+			return false, err
+		}
+	}
+
+	snap, err = h.backend.db.LatestSnapshot()
+	if err != nil {
+		return false, err
+	}
+	updated, err := snap.GetBalance(addr)
+	if err != nil {
+		return false, err
+	}
+	if updated.Cmp(target) != 0 {
+		return false, errors.Errorf("balance set to %v instead of %v, fees may be enabled", updated, target)
+	}
+	return true, nil
+}
+
+func (h *Hardhat) SetNonce(account ethcommon.Address, nonce hexutil.Uint64) (bool, error) {
+	h.backend.Lock()
+	defer h.backend.Unlock()
+	addr := common.NewAddressFromEth(account)
+	snap, err := h.backend.db.LatestSnapshot()
+	if err != nil {
+		return false, err
+	}
+	current, err := snap.GetTransactionCount(addr)
+	if err != nil {
+		return false, err
+	}
+	target := new(big.Int).SetUint64(uint64(nonce))
+	if target.Cmp(current) < 0 {
+		return false, errors.Errorf("nonce can't be decreased from %v to %v", current, target)
+	}
+	increase := new(big.Int).Sub(target, current)
+	if increase.Sign() == 0 {
+		return true, nil
+	}
+	if increase.Cmp(big.NewInt(maxNonceIncrease)) > 0 {
+		return false, errors.Errorf("nonce can't be increased by more than %v at once", maxNonceIncrease)
+	}
+
+	// ArbOS only increments an account's nonce when it sends a transaction,
+	// so send empty transactions from the account until it reaches the target
+	txes := make([]message.AbstractL2Message, 0, increase.Uint64())
+	var lastTx message.Transaction
+	for seq := new(big.Int).Set(current); seq.Cmp(target) < 0; seq = new(big.Int).Add(seq, big.NewInt(1)) {
+		lastTx = message.Transaction{
+			MaxGas:      big.NewInt(100000),
+			GasPriceBid: big.NewInt(0),
+			SequenceNum: seq,
+			DestAddress: burnAddress,
+			Payment:     big.NewInt(0),
+			Data:        nil,
+		}
+		txes = append(txes, lastTx)
+	}
+	batch, err := message.NewTransactionBatchFromMessages(txes)
+	if err != nil {
+		return false, err
+	}
+	lastId := lastTx.MessageID(addr, h.backend.chainID)
+	if _, err := h.backend.deliverRequest(message.NewSafeL2Message(batch), addr, big.NewInt(0), &lastId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *Hardhat) SetCode(account ethcommon.Address, code hexutil.Bytes) (bool, error) {
+	h.backend.Lock()
+	defer h.backend.Unlock()
+	addr := common.NewAddressFromEth(account)
+	if err := h.installAccount(addr, func(state *accountState) {
+		state.code = code
+	}); err != nil {
+		return false, err
+	}
+
+	snap, err := h.backend.db.LatestSnapshot()
+	if err != nil {
+		return false, err
+	}
+	updated, err := snap.GetCode(addr)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(updated, code) {
+		return false, errors.New("code wasn't installed")
+	}
+	return true, nil
+}
+
+func (h *Hardhat) SetStorageAt(account ethcommon.Address, index hexutil.Big, value hexutil.Big) (bool, error) {
+	h.backend.Lock()
+	defer h.backend.Unlock()
+	if index.ToInt().Sign() < 0 || index.ToInt().BitLen() > 256 || value.ToInt().Sign() < 0 || value.ToInt().BitLen() > 256 {
+		return false, errors.New("storage index and value must be 256 bit unsigned integers")
+	}
+	addr := common.NewAddressFromEth(account)
+	key := ethcommon.BigToHash(index.ToInt())
+	if err := h.installAccount(addr, func(state *accountState) {
+		if value.ToInt().Sign() == 0 {
+			delete(state.storage, key)
+		} else {
+			state.storage[key] = ethcommon.BigToHash(value.ToInt())
+		}
+	}); err != nil {
+		return false, err
+	}
+
+	snap, err := h.backend.db.LatestSnapshot()
+	if err != nil {
+		return false, err
+	}
+	updated, err := snap.GetStorageAt(addr, index.ToInt())
+	if err != nil {
+		return false, err
+	}
+	if updated.Cmp(value.ToInt()) != 0 {
+		return false, errors.Errorf("storage set to %v instead of %v", updated, value.ToInt())
+	}
+	return true, nil
+}
+
+type accountState struct {
+	balance *big.Int
+	nonce   *big.Int
+	code    []byte
+	storage map[ethcommon.Hash]ethcommon.Hash
+}
+
+// installAccount lets update modify the current state of the account and then
+// reinstalls the account with the result. ArbOS has no way to change only the
+// code or storage of an existing account, so the whole account is replaced
+// through the ArbosTest precompile, which only accepts calls from the zero
+// address.
+func (h *Hardhat) installAccount(addr common.Address, update func(state *accountState)) error {
+	snap, err := h.backend.db.LatestSnapshot()
+	if err != nil {
+		return err
+	}
+	state := &accountState{}
+	state.balance, err = snap.GetBalance(addr)
+	if err != nil {
+		return err
+	}
+	state.nonce, err = snap.GetTransactionCount(addr)
+	if err != nil {
+		return err
+	}
+	state.code, err = snap.GetCode(addr)
+	if err != nil {
+		return err
+	}
+	state.storage, err = snap.GetStorage(addr)
+	if err != nil {
+		return errors.Wrap(err, "error reading account storage, ArbOS may not support ArbosTest")
+	}
+	update(state)
+
+	tx := message.ContractTransaction{
+		BasicTx: message.BasicTx{
+			MaxGas:      big.NewInt(100000000),
+			GasPriceBid: big.NewInt(0),
+			DestAddress: common.NewAddressFromEth(arbos.ARBOS_TEST_ADDRESS),
+			Payment:     big.NewInt(0),
+			Data:        arbos.InstallAccountData(addr, len(state.code) == 0, state.balance, state.nonce, state.code, state.storage),
+		},
+	}
+	_, err = h.backend.deliverRequest(message.NewSafeL2Message(tx), common.Address{}, big.NewInt(0), nil)
+	return err
+}
+
+func (h *Hardhat) ImpersonateAccount(account ethcommon.Address) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.impersonated[common.NewAddressFromEth(account)] = true
+	return true, nil
+}
+
+func (h *Hardhat) StopImpersonatingAccount(account ethcommon.Address) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.impersonated, common.NewAddressFromEth(account))
+	return true, nil
+}
+
+func (h *Hardhat) IsImpersonated(account ethcommon.Address) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.impersonated[common.NewAddressFromEth(account)]
+}
+
+// SendImpersonatedTransaction sends an unsigned transaction from sender. The
+// returned hash is the request ID of the transaction.
+func (h *Hardhat) SendImpersonatedTransaction(_ context.Context, sender ethcommon.Address, args *web3.SendTransactionArgs) (ethcommon.Hash, error) {
+	h.backend.Lock()
+	defer h.backend.Unlock()
+	addr := common.NewAddressFromEth(sender)
+
+	var nonce *big.Int
+	if args.Nonce != nil {
+		nonce = new(big.Int).SetUint64(uint64(*args.Nonce))
+	} else {
+		snap, err := h.backend.db.LatestSnapshot()
+		if err != nil {
+			return ethcommon.Hash{}, err
+		}
+		nonce, err = snap.GetTransactionCount(addr)
+		if err != nil {
+			return ethcommon.Hash{}, err
+		}
+	}
+	tx := message.Transaction{
+		MaxGas:      big.NewInt(2000000),
+		GasPriceBid: big.NewInt(0),
+		SequenceNum: nonce,
+		Payment:     big.NewInt(0),
+	}
+	if args.Gas != nil {
+		tx.MaxGas = new(big.Int).SetUint64(uint64(*args.Gas))
+	}
+	if args.GasPrice != nil {
+		tx.GasPriceBid = args.GasPrice.ToInt()
+	}
+	if args.To != nil {
+		tx.DestAddress = common.NewAddressFromEth(*args.To)
+	}
+	if args.Value != nil {
+		tx.Payment = args.Value.ToInt()
+	}
+	if args.Data != nil {
+		tx.Data = *args.Data
+	}
+
+	logger.
+		Info().
+		Str("from", sender.Hex()).
+		Str("nonce", nonce.String()).
+		Str("value", tx.Payment.String()).
+		Msg("sent impersonated transaction")
+
+	requestId, err := h.backend.deliverRequest(message.NewSafeL2Message(tx), addr, h.backend.l1GasPrice, nil)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	return requestId.ToEthHash(), nil
+}
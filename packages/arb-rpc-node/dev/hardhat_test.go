@@ -0,0 +1,195 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
+)
+
+func TestHardhat(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, _, srv, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	ctx := context.Background()
+	client := web3.NewEthClient(srv, true, metrics.NewMetricsConfig(nil))
+	hardhat := NewHardhat(backend)
+	account := common.RandAddress().ToEthAddress()
+	dest := common.RandAddress().ToEthAddress()
+
+	checkBalance := func(expected *big.Int) {
+		t.Helper()
+		balance, err := client.BalanceAt(ctx, account, nil)
+		test.FailIfError(t, err)
+		if balance.Cmp(expected) != 0 {
+			t.Errorf("expected balance %v but got %v", expected, balance)
+		}
+	}
+
+	_, err := hardhat.SetBalance(account, hexutil.Big(*big.NewInt(1000)))
+	test.FailIfError(t, err)
+	checkBalance(big.NewInt(1000))
+
+	_, err = hardhat.SetBalance(account, hexutil.Big(*big.NewInt(400)))
+	test.FailIfError(t, err)
+	checkBalance(big.NewInt(400))
+
+	_, err = hardhat.SetNonce(account, 5)
+	test.FailIfError(t, err)
+	nonce, err := client.PendingNonceAt(ctx, account)
+	test.FailIfError(t, err)
+	if nonce != 5 {
+		t.Errorf("expected nonce 5 but got %v", nonce)
+	}
+	if _, err := hardhat.SetNonce(account, 2); err == nil {
+		t.Error("nonce decreased")
+	}
+
+	// Returns storage slot 0
+	code := hexutil.Bytes{0x60, 0x00, 0x54, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+	contract := common.RandAddress().ToEthAddress()
+	_, err = hardhat.SetBalance(contract, hexutil.Big(*big.NewInt(77)))
+	test.FailIfError(t, err)
+	_, err = hardhat.SetCode(contract, code)
+	test.FailIfError(t, err)
+	installed, err := client.CodeAt(ctx, contract, nil)
+	test.FailIfError(t, err)
+	if !bytes.Equal(installed, code) {
+		t.Errorf("installed code %v instead of %v", hexutil.Bytes(installed), code)
+	}
+	balance, err := client.BalanceAt(ctx, contract, nil)
+	test.FailIfError(t, err)
+	if balance.Cmp(big.NewInt(77)) != 0 {
+		t.Errorf("setting code changed balance to %v", balance)
+	}
+
+	storageAt := func(index int64) *big.Int {
+		t.Helper()
+		snap, err := backend.db.LatestSnapshot()
+		test.FailIfError(t, err)
+		val, err := snap.GetStorageAt(common.NewAddressFromEth(contract), big.NewInt(index))
+		test.FailIfError(t, err)
+		return val
+	}
+	_, err = hardhat.SetStorageAt(contract, hexutil.Big(*big.NewInt(0)), hexutil.Big(*big.NewInt(42)))
+	test.FailIfError(t, err)
+	_, err = hardhat.SetStorageAt(contract, hexutil.Big(*big.NewInt(1)), hexutil.Big(*big.NewInt(43)))
+	test.FailIfError(t, err)
+	ret, err := client.CallContract(ctx, ethereum.CallMsg{To: &contract}, nil)
+	test.FailIfError(t, err)
+	if new(big.Int).SetBytes(ret).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("contract read storage %v instead of 42", ret)
+	}
+	stored := storageAt(1)
+	if stored.Cmp(big.NewInt(43)) != 0 {
+		t.Errorf("storage slot 1 is %v instead of 43", stored)
+	}
+
+	// Replacing the code keeps the storage
+	_, err = hardhat.SetCode(contract, append(hexutil.Bytes{0x5b}, code...))
+	test.FailIfError(t, err)
+	stored = storageAt(0)
+	if stored.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("storage slot 0 is %v instead of 42 after setting code", stored)
+	}
+
+	_, err = hardhat.SetStorageAt(contract, hexutil.Big(*big.NewInt(0)), hexutil.Big(*big.NewInt(0)))
+	test.FailIfError(t, err)
+	stored = storageAt(0)
+	if stored.Sign() != 0 {
+		t.Errorf("storage slot 0 is %v instead of cleared", stored)
+	}
+
+	if hardhat.IsImpersonated(account) {
+		t.Error("account impersonated before request")
+	}
+	_, err = hardhat.ImpersonateAccount(account)
+	test.FailIfError(t, err)
+	if !hardhat.IsImpersonated(account) {
+		t.Error("account not impersonated")
+	}
+
+	value := hexutil.Big(*big.NewInt(100))
+	txHash, err := hardhat.SendImpersonatedTransaction(ctx, account, &web3.SendTransactionArgs{
+		To:    &dest,
+		Value: &value,
+	})
+	test.FailIfError(t, err)
+	receipt, err := client.TransactionReceipt(ctx, txHash)
+	test.FailIfError(t, err)
+	if receipt == nil || receipt.Status != 1 {
+		t.Error("impersonated transaction failed")
+	}
+	checkBalance(big.NewInt(300))
+
+	_, err = hardhat.StopImpersonatingAccount(account)
+	test.FailIfError(t, err)
+	if hardhat.IsImpersonated(account) {
+		t.Error("account still impersonated")
+	}
+}
+
+func TestSetNextBlockTimestamp(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, db, _, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	evm := NewEVM(backend)
+
+	timestamp := time.Now().Unix() + 3600
+	_, err := evm.SetNextBlockTimestamp(timestamp)
+	test.FailIfError(t, err)
+	test.FailIfError(t, evm.Mine(nil))
+	latest, err := db.LatestBlock()
+	test.FailIfError(t, err)
+	if int64(latest.Header.Time) != timestamp {
+		t.Errorf("mined block at %v instead of %v", latest.Header.Time, timestamp)
+	}
+
+	// Later blocks continue on from the requested time
+	test.FailIfError(t, evm.Mine(nil))
+	latest, err = db.LatestBlock()
+	test.FailIfError(t, err)
+	if int64(latest.Header.Time) < timestamp || int64(latest.Header.Time) > timestamp+60 {
+		t.Errorf("next block at %v after setting timestamp %v", latest.Header.Time, timestamp)
+	}
+}
@@ -65,7 +65,7 @@ func TestL2ToL1Tx(t *testing.T) {
 	auth := bind.NewKeyedTransactor(privkey)
 
 	clnt, pks := test.SimulatedBackend(t)
-	ethAuth := bind.NewKeyedTransactor(pks[0])
+	ethAuth := test.SimulatedTransactor(t, pks[0])
 
 	deposit := message.EthDepositTx{
 		L2Message: message.NewSafeL2Message(message.ContractTransaction{
@@ -0,0 +1,204 @@
+/*
+* Copyright 2021, Offchain Labs, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dev
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/test"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/web3"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/protocol"
+)
+
+func TestManualMining(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, _, srv, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	ctx := context.Background()
+	client := web3.NewEthClient(srv, true, metrics.NewMetricsConfig(nil))
+	senderKey, err := crypto.GenerateKey()
+	test.FailIfError(t, err)
+	sender := crypto.PubkeyToAddress(senderKey.PublicKey)
+	_, err = NewHardhat(backend).SetBalance(sender, hexutil.Big(*big.NewInt(1000000000000000000)))
+	test.FailIfError(t, err)
+
+	evm := NewEVM(backend)
+	_, err = evm.SetAutomine(false)
+	test.FailIfError(t, err)
+
+	startBlock, err := backend.db.BlockCount()
+	test.FailIfError(t, err)
+
+	dest := common.RandAddress().ToEthAddress()
+	var txes []*types.Transaction
+	for i := uint64(0); i < 3; i++ {
+		tx := types.NewTransaction(i, dest, big.NewInt(1), 100000, big.NewInt(0), nil)
+		signedTx, err := types.SignTx(tx, backend.signer, senderKey)
+		test.FailIfError(t, err)
+		test.FailIfError(t, client.SendTransaction(ctx, signedTx))
+		txes = append(txes, signedTx)
+	}
+
+	blockCount, err := backend.db.BlockCount()
+	test.FailIfError(t, err)
+	if blockCount != startBlock {
+		t.Fatal("queued transactions were mined")
+	}
+	pending, _ := backend.TxPoolContent()
+	if len(pending[sender]) != len(txes) {
+		t.Fatalf("expected %v queued transactions but got %v", len(txes), len(pending[sender]))
+	}
+	nonce, err := client.PendingNonceAt(ctx, sender)
+	test.FailIfError(t, err)
+	if nonce != uint64(len(txes)) {
+		t.Errorf("expected pending nonce %v but got %v", len(txes), nonce)
+	}
+	pendingSnap, err := backend.PendingSnapshot()
+	test.FailIfError(t, err)
+	balance, err := pendingSnap.GetBalance(common.NewAddressFromEth(dest))
+	test.FailIfError(t, err)
+	if balance.Cmp(big.NewInt(int64(len(txes)))) != 0 {
+		t.Errorf("expected pending balance %v but got %v", len(txes), balance)
+	}
+
+	test.FailIfError(t, evm.Mine(nil))
+
+	var blockNum *big.Int
+	for i, tx := range txes {
+		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+		test.FailIfError(t, err)
+		if receipt == nil || receipt.Status != 1 {
+			t.Fatal("queued transaction failed")
+		}
+		if receipt.TransactionIndex != uint(i) {
+			t.Errorf("expected tx index %v but got %v", i, receipt.TransactionIndex)
+		}
+		if blockNum == nil {
+			blockNum = receipt.BlockNumber
+		} else if receipt.BlockNumber.Cmp(blockNum) != 0 {
+			t.Error("queued transactions mined in different blocks")
+		}
+	}
+	pending, _ = backend.TxPoolContent()
+	if len(pending) != 0 {
+		t.Error("queue not cleared after mining")
+	}
+}
+
+func TestPendingBlock(t *testing.T) {
+	config := protocol.ChainParams{
+		StakeRequirement:          big.NewInt(10),
+		StakeToken:                common.Address{},
+		GracePeriod:               common.NewTimeBlocksInt(3),
+		MaxExecutionSteps:         10000000000,
+		ArbGasSpeedLimitPerSecond: 2000000000000,
+	}
+	backend, _, srv, cancelDevNode := NewTestDevNode(t, *arbosfile, config, common.RandAddress(), nil)
+	defer cancelDevNode()
+
+	ctx := context.Background()
+	metricsConfig := metrics.NewMetricsConfig(nil)
+	client := web3.NewEthClient(srv, true, metricsConfig)
+	web3Server := web3.NewServer(srv, true, metricsConfig)
+	hardhat := NewHardhat(backend)
+
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		test.FailIfError(t, err)
+		_, err = hardhat.SetBalance(crypto.PubkeyToAddress(key.PublicKey), hexutil.Big(*big.NewInt(1000000000000000000)))
+		test.FailIfError(t, err)
+		keys = append(keys, key)
+	}
+	// Send from the higher address first so that arrival order differs from
+	// sorting by sender
+	if bytes.Compare(crypto.PubkeyToAddress(keys[0].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[1].PublicKey).Bytes()) < 0 {
+		keys[0], keys[1] = keys[1], keys[0]
+	}
+
+	evm := NewEVM(backend)
+	_, err := evm.SetAutomine(false)
+	test.FailIfError(t, err)
+
+	dest := common.RandAddress().ToEthAddress()
+	var txes []*types.Transaction
+	for _, nonce := range []uint64{0, 1} {
+		for _, key := range keys {
+			tx := types.NewTransaction(nonce, dest, big.NewInt(1), 100000, big.NewInt(0), nil)
+			signedTx, err := types.SignTx(tx, backend.signer, key)
+			test.FailIfError(t, err)
+			test.FailIfError(t, client.SendTransaction(ctx, signedTx))
+			txes = append(txes, signedTx)
+		}
+	}
+
+	nextTimestamp := time.Now().Unix() + 1000
+	_, err = evm.SetNextBlockTimestamp(nextTimestamp)
+	test.FailIfError(t, err)
+
+	pendingNum := rpc.PendingBlockNumber
+	pendingBlock, err := web3Server.GetBlockByNumber(&pendingNum, false)
+	test.FailIfError(t, err)
+	hashes, ok := pendingBlock.Transactions.([]hexutil.Bytes)
+	if !ok || len(hashes) != len(txes) {
+		t.Fatal("pending block doesn't contain the queued transactions")
+	}
+	for i, tx := range txes {
+		if !bytes.Equal(hashes[i], tx.Hash().Bytes()) {
+			t.Error("pending block has transaction", i, "out of order")
+		}
+	}
+	if uint64(*pendingBlock.Timestamp) != uint64(nextTimestamp) {
+		t.Error("pending block has timestamp", uint64(*pendingBlock.Timestamp), "instead of", nextTimestamp)
+	}
+
+	test.FailIfError(t, evm.Mine(nil))
+	for i, tx := range txes {
+		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+		test.FailIfError(t, err)
+		if receipt == nil || receipt.Status != 1 {
+			t.Fatal("queued transaction failed")
+		}
+		if receipt.TransactionIndex != uint(i) {
+			t.Errorf("pending block showed tx index %v but it was mined at %v", i, receipt.TransactionIndex)
+		}
+	}
+	latest, err := backend.db.LatestBlock()
+	test.FailIfError(t, err)
+	if latest.Header.Time != uint64(nextTimestamp) {
+		t.Error("mined block has timestamp", latest.Header.Time, "instead of", nextTimestamp)
+	}
+}
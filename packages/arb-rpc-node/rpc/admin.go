@@ -0,0 +1,37 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpc
+
+import (
+	"context"
+)
+
+// Admin implements the arbadmin namespace, which is meant for node operators
+// and shouldn't be exposed publicly
+type Admin struct {
+	lockout *LockoutBatcher
+}
+
+func NewAdmin(lockout *LockoutBatcher) *Admin {
+	return &Admin{lockout: lockout}
+}
+
+// HandoverSequencer makes this node stop sequencing and hand the sequencer
+// lockout over to the sequencer with the given RPC URL. It returns once that
+// sequencer holds the lockout, which may be before it has caught up.
+func (a *Admin) HandoverSequencer(ctx context.Context, target string) error {
+	return a.lockout.HandoverSequencer(ctx, target)
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type etcdLease struct {
+	id  clientv3.LeaseID
+	ttl int64
+}
+
+type etcdStore struct {
+	client *clientv3.Client
+
+	// Leases are kept per key and refreshed on each Set instead of granting a
+	// new one for every write
+	mutex  sync.Mutex
+	leases map[string]etcdLease
+}
+
+func newEtcdStore(endpoints string) (*etcdStore, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(endpoints, ","),
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to etcd")
+	}
+	return &etcdStore{client: client, leases: make(map[string]etcdLease)}, nil
+}
+
+// lease returns a live lease with the given TTL for key, refreshing the
+// existing one if possible and granting a new one otherwise
+func (e *etcdStore) lease(ctx context.Context, key string, ttl int64) (clientv3.LeaseID, error) {
+	e.mutex.Lock()
+	existing, ok := e.leases[key]
+	e.mutex.Unlock()
+	if ok && existing.ttl == ttl {
+		if _, err := e.client.KeepAliveOnce(ctx, existing.id); err == nil {
+			return existing.id, nil
+		} else if err != rpctypes.ErrLeaseNotFound {
+			return clientv3.NoLease, err
+		}
+	} else if ok {
+		e.releaseLease(ctx, key)
+	}
+	resp, err := e.client.Grant(ctx, ttl)
+	if err != nil {
+		return clientv3.NoLease, err
+	}
+	e.mutex.Lock()
+	e.leases[key] = etcdLease{id: resp.ID, ttl: ttl}
+	e.mutex.Unlock()
+	return resp.ID, nil
+}
+
+// releaseLease revokes and forgets the lease held for key, if any. Revoking
+// deletes any key still attached to the lease.
+func (e *etcdStore) releaseLease(ctx context.Context, key string) {
+	e.mutex.Lock()
+	existing, ok := e.leases[key]
+	delete(e.leases, key)
+	e.mutex.Unlock()
+	if !ok {
+		return
+	}
+	if _, err := e.client.Revoke(ctx, existing.id); err != nil && err != rpctypes.ErrLeaseNotFound {
+		logger.Warn().Err(err).Msg("failed to revoke etcd lease")
+	}
+}
+
+func (e *etcdStore) Set(ctx context.Context, key string, value string, timeout time.Duration, onlyIfUnset bool) (bool, error) {
+	var opts []clientv3.OpOption
+	if timeout > 0 {
+		// Lease TTLs are in whole seconds so round up, which errs on the side
+		// of other sequencers seeing the key for longer than its holder does
+		ttl := int64((timeout + time.Second - 1) / time.Second)
+		lease, err := e.lease(ctx, key, ttl)
+		if err != nil {
+			return false, err
+		}
+		opts = append(opts, clientv3.WithLease(lease))
+	}
+	put := clientv3.OpPut(key, value, opts...)
+	if !onlyIfUnset {
+		if _, err := e.client.Do(ctx, put); err != nil {
+			return false, err
+		}
+		if timeout <= 0 {
+			// The key no longer uses its lease
+			e.releaseLease(ctx, key)
+		}
+		return true, nil
+	}
+	resp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(put).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	if !resp.Succeeded && timeout > 0 {
+		// Someone else holds the key so the lease isn't attached to anything
+		e.releaseLease(ctx, key)
+	}
+	return resp.Succeeded, nil
+}
+
+func (e *etcdStore) Get(ctx context.Context, key string) (string, bool, error) {
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return "", false, err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", false, nil
+	}
+	return string(resp.Kvs[0].Value), true, nil
+}
+
+func (e *etcdStore) Delete(ctx context.Context, key string) error {
+	if _, err := e.client.Delete(ctx, key); err != nil {
+		return err
+	}
+	e.releaseLease(ctx, key)
+	return nil
+}
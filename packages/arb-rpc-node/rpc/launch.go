@@ -52,6 +52,7 @@ func (b ForwarderBatcherMode) isBatcherMode() {}
 type StatefulBatcherMode struct {
 	Auth         *bind.TransactOpts
 	InboxAddress common.Address
+	TxPool       batcher.TxPoolConfig
 }
 
 func (b StatefulBatcherMode) isBatcherMode() {}
@@ -59,6 +60,7 @@ func (b StatefulBatcherMode) isBatcherMode() {}
 type StatelessBatcherMode struct {
 	Auth         *bind.TransactOpts
 	InboxAddress common.Address
+	TxPool       batcher.TxPoolConfig
 }
 
 func (b StatelessBatcherMode) isBatcherMode() {}
@@ -83,13 +85,13 @@ func SetupBatcher(
 	batcherMode BatcherMode,
 	dataSigner func([]byte) ([]byte, error),
 	broadcasterSettings configuration.FeedOutput,
-	gasPriceUrl string,
+	authConfig ethbridge.TransactAuthConfig,
 ) (batcher.TransactionBatcher, error) {
 	switch batcherMode := batcherMode.(type) {
 	case ForwarderBatcherMode:
 		return batcher.NewForwarder(ctx, batcherMode.NodeURL)
 	case StatelessBatcherMode:
-		auth, err := ethbridge.NewTransactAuth(ctx, client, batcherMode.Auth, gasPriceUrl)
+		auth, err := ethbridge.NewTransactAuthAdvanced(ctx, client, batcherMode.Auth, authConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -97,9 +99,9 @@ func SetupBatcher(
 		if err != nil {
 			return nil, err
 		}
-		return batcher.NewStatelessBatcher(ctx, db, l2ChainId, client, inbox, maxBatchTime), nil
+		return batcher.NewStatelessBatcher(ctx, db, l2ChainId, auth, inbox, maxBatchTime, batcherMode.TxPool), nil
 	case StatefulBatcherMode:
-		auth, err := ethbridge.NewTransactAuth(ctx, client, batcherMode.Auth, gasPriceUrl)
+		auth, err := ethbridge.NewTransactAuthAdvanced(ctx, client, batcherMode.Auth, authConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -107,7 +109,7 @@ func SetupBatcher(
 		if err != nil {
 			return nil, err
 		}
-		return batcher.NewStatefulBatcher(ctx, db, l2ChainId, client, inbox, maxBatchTime)
+		return batcher.NewStatefulBatcher(ctx, db, l2ChainId, auth, inbox, maxBatchTime, batcherMode.TxPool)
 	case SequencerBatcherMode:
 		rollup, err := ethbridgecontracts.NewRollupUserFacet(rollupAddress.ToEthAddress(), client)
 		if err != nil {
@@ -135,7 +137,7 @@ func SetupBatcher(
 			batcherMode.Auth,
 			dataSigner,
 			feedBroadcaster,
-			gasPriceUrl,
+			authConfig,
 		)
 		if err != nil {
 			return nil, err
@@ -45,15 +45,22 @@ type LockoutBatcher struct {
 	sequencerBatcher *batcher.SequencerBatcher
 	core             core.ArbOutputLookup
 	inboxReader      *monitor.InboxReader
-	redis            *lockoutRedis
+	lockout          Lockout
 	errChan          chan error
 	config           configuration.Lockout
+	handoverChan     chan handoverRequest
 
 	lockoutExpiresAt    time.Time
 	livelinessExpiresAt time.Time
 	currentSeq          string
 	lastLockedSeqNum    *big.Int
 	currentBatcher      batcher.TransactionBatcher
+	handingOver         bool
+}
+
+type handoverRequest struct {
+	target string
+	result chan error
 }
 
 func SetupLockout(
@@ -64,25 +71,40 @@ func SetupLockout(
 	config configuration.Lockout,
 	errChan chan error,
 ) (*LockoutBatcher, error) {
-	redis, err := newLockoutRedis(config)
+	store, err := NewLockoutStore(config)
 	if err != nil {
 		return nil, err
 	}
+	return SetupLockoutWithStore(ctx, seqBatcher, core, inboxReader, config, store, errChan), nil
+}
+
+// SetupLockoutWithStore is like SetupLockout but coordinates through the given
+// store instead of the one selected by the config
+func SetupLockoutWithStore(
+	ctx context.Context,
+	seqBatcher *batcher.SequencerBatcher,
+	core core.ArbOutputLookup,
+	inboxReader *monitor.InboxReader,
+	config configuration.Lockout,
+	store LockoutStore,
+	errChan chan error,
+) *LockoutBatcher {
 	newBatcher := &LockoutBatcher{
 		sequencerBatcher: seqBatcher,
 		currentBatcher: &errorBatcher{
 			err: errors.New("sequencer lockout manager starting up"),
 		},
-		currentSeq:  "[starting up]",
-		core:        core,
-		inboxReader: inboxReader,
-		config:      config,
-		redis:       redis,
-		errChan:     errChan,
+		currentSeq:   "[starting up]",
+		core:         core,
+		inboxReader:  inboxReader,
+		config:       config,
+		lockout:      NewStoreLockout(store, config),
+		errChan:      errChan,
+		handoverChan: make(chan handoverRequest),
 	}
 	newBatcher.sequencerBatcher.LockoutManager = newBatcher
 	go newBatcher.lockoutManager(ctx)
-	return newBatcher, nil
+	return newBatcher
 }
 
 const ACCEPTABLE_SEQ_NUM_GAP int64 = 0
@@ -97,8 +119,8 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 			err: errors.New("sequencer lockout manager shutting down"),
 		}
 		backgroundContext := context.Background()
-		b.redis.releaseLockout(backgroundContext, &b.lockoutExpiresAt)
-		b.redis.releaseLiveliness(backgroundContext, &b.livelinessExpiresAt)
+		b.lockout.ReleaseLockout(backgroundContext, &b.lockoutExpiresAt)
+		b.lockout.ReleaseLiveliness(backgroundContext, &b.livelinessExpiresAt)
 		b.mutex.Unlock()
 		holdingMutex = false
 		logger.Debug().Msg("shut down sequencer lockout manager and released locks")
@@ -133,30 +155,30 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 				alive = false
 				if b.livelinessExpiresAt.After(time.Now()) {
 					logger.Warn().Str("ourSeqNum", currentSeqNum.String()).Str("targetSeqNum", b.lastLockedSeqNum.String()).Msg("fell behind sequencer position")
-					b.redis.releaseLiveliness(ctx, &b.livelinessExpiresAt)
+					b.lockout.ReleaseLiveliness(ctx, &b.livelinessExpiresAt)
 				}
 			}
-			b.lastLockedSeqNum = b.redis.getLatestSeqNum(ctx)
+			b.lastLockedSeqNum = b.lockout.GetLatestSeqNum(ctx)
 		}
 		if alive {
-			b.redis.acquireOrUpdateLiveliness(ctx, &b.livelinessExpiresAt)
+			b.lockout.AcquireOrUpdateLiveliness(ctx, &b.livelinessExpiresAt)
 			if b.livelinessExpiresAt.Before(time.Now()) {
 				logger.Warn().Str("rpc", b.config.SelfRPCURL).Msg("failed to acquire liveliness lockout, is another sequencer running with this RPC URL?")
 			}
 		}
-		selectedSeq := b.redis.selectSequencer(ctx)
+		selectedSeq := b.lockout.SelectSequencer(ctx)
 		if selectedSeq == b.config.SelfRPCURL {
 			if !holdingMutex {
 				b.mutex.Lock()
 				holdingMutex = true
 			}
 			if b.livelinessExpiresAt.After(time.Now()) {
-				b.redis.acquireOrUpdateLockout(ctx, &b.lockoutExpiresAt)
+				b.lockout.AcquireOrUpdateLockout(ctx, &b.lockoutExpiresAt)
 			}
 			if b.hasSequencerLockout() {
 				if b.currentBatcher != b.sequencerBatcher {
 					logger.Info().Str("rpc", b.config.SelfRPCURL).Msg("acquired sequencer lockout")
-					targetSeqNum := b.redis.getLatestSeqNum(ctx)
+					targetSeqNum := b.lockout.GetLatestSeqNum(ctx)
 					b.lastLockedSeqNum = targetSeqNum
 					attemptCatchupUntil := b.lockoutExpiresAt.Add(-b.config.MaxLatency)
 					for {
@@ -168,6 +190,7 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 								return
 							case <-time.After(5 * time.Second):
 							}
+							continue
 						}
 						if currentSeqNum.Cmp(targetSeqNum) >= 0 {
 							logger.
@@ -177,7 +200,7 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 								Msg("caught up to previous sequencer position")
 							break
 						}
-						if attemptCatchupUntil.After(time.Now()) {
+						if time.Now().After(attemptCatchupUntil) {
 							logger.
 								Warn().
 								Str("targetSeqNum", targetSeqNum.String()).
@@ -196,7 +219,7 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 				holdingMutex = false
 				seqNum, err := b.core.GetMessageCount()
 				if err == nil {
-					b.redis.updateLatestSeqNum(ctx, seqNum, b.lockoutExpiresAt)
+					b.lockout.UpdateLatestSeqNum(ctx, seqNum, b.lockoutExpiresAt)
 					b.lastLockedSeqNum = seqNum
 				} else {
 					logger.Warn().Err(err).Msg("error getting sequence number")
@@ -215,11 +238,11 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 				if b.hasSequencerLockout() {
 					seqNum, err := b.core.GetMessageCount()
 					if err == nil {
-						b.redis.updateLatestSeqNum(ctx, seqNum, b.lockoutExpiresAt)
+						b.lockout.UpdateLatestSeqNum(ctx, seqNum, b.lockoutExpiresAt)
 					} else {
 						logger.Warn().Err(err).Msg("error getting sequence number")
 					}
-					b.redis.releaseLockout(ctx, &b.lockoutExpiresAt)
+					b.lockout.ReleaseLockout(ctx, &b.lockoutExpiresAt)
 				}
 				b.inboxReader.MessageDeliveryMutex.Unlock()
 				b.currentBatcher = nil
@@ -233,7 +256,7 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 				b.currentSeq = selectedSeq
 				b.mutex.Unlock()
 				holdingMutex = false
-			} else if b.redis.getLockout(ctx) == selectedSeq {
+			} else if b.lockout.GetLockout(ctx) == selectedSeq {
 				logger.Info().Str("rpc", selectedSeq).Msg("forwarding to new sequencer")
 				var err error
 				b.currentBatcher, err = batcher.NewForwarder(ctx, selectedSeq)
@@ -262,11 +285,99 @@ func (b *LockoutBatcher) lockoutManager(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
+		case req := <-b.handoverChan:
+			req.result <- b.handover(ctx, req.target, holdingMutex)
 		case <-time.After(refreshDelay):
 		}
 	}
 }
 
+// HandoverSequencer stops sequencing, publishes all pending messages and then
+// transfers the sequencer lockout to target. It returns once target has taken
+// the lockout, at which point target may still be catching up to the handed
+// over position before it starts sequencing. Target takes precedence over the
+// sequencer priorities until the handover TTL expires or it stops being live.
+func (b *LockoutBatcher) HandoverSequencer(ctx context.Context, target string) error {
+	req := handoverRequest{target: target, result: make(chan error, 1)}
+	select {
+	case b.handoverChan <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Must only be called from the lockout manager goroutine
+func (b *LockoutBatcher) handover(ctx context.Context, target string, holdingMutex bool) error {
+	if target == b.config.SelfRPCURL {
+		return errors.New("sequencer can't hand over to itself")
+	}
+	if holdingMutex || !b.ShouldSequence() {
+		return errors.New("not the active sequencer")
+	}
+	if err := b.lockout.SetHandoverTarget(ctx, target); err != nil {
+		return errors.Wrap(err, "failed to set handover target")
+	}
+	// Selection clears the target if it isn't live
+	if selected := b.lockout.SelectSequencer(ctx); selected != target {
+		return errors.Errorf("handover target %v isn't live", target)
+	}
+	abort := func(err error) error {
+		if clearErr := b.lockout.SetHandoverTarget(ctx, ""); clearErr != nil {
+			logger.Warn().Err(clearErr).Msg("failed to clear handover target")
+		}
+		return err
+	}
+
+	logger.Info().Str("target", target).Msg("handing over sequencer lockout")
+	b.setHandingOver(true)
+	defer b.setHandingOver(false)
+	// Wait for transactions which are already being sequenced
+	b.inboxReader.MessageDeliveryMutex.Lock()
+	b.inboxReader.MessageDeliveryMutex.Unlock()
+
+	if err := b.sequencerBatcher.PublishPendingBatch(ctx); err != nil {
+		return abort(errors.Wrap(err, "failed to publish pending batch"))
+	}
+	b.lockout.AcquireOrUpdateLockout(ctx, &b.lockoutExpiresAt)
+	if !b.hasSequencerLockout() {
+		return abort(errors.New("lost sequencer lockout during handover"))
+	}
+	seqNum, err := b.core.GetMessageCount()
+	if err != nil {
+		return abort(errors.Wrap(err, "error getting sequence number"))
+	}
+	b.lockout.UpdateLatestSeqNum(ctx, seqNum, b.lockoutExpiresAt)
+	b.lastLockedSeqNum = seqNum
+	b.lockout.ReleaseLockout(ctx, &b.lockoutExpiresAt)
+
+	// The target only takes the lockout once it has caught up to seqNum
+	deadline := time.Now().Add(b.config.Timeout)
+	for b.lockout.GetLockout(ctx) != target {
+		if time.Now().After(deadline) {
+			return abort(errors.Errorf("%v didn't take over the sequencer lockout", target))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	logger.Info().Str("target", target).Str("seqNum", seqNum.String()).Msg("handed over sequencer lockout")
+	return nil
+}
+
+func (b *LockoutBatcher) setHandingOver(handingOver bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handingOver = handingOver
+}
+
 // Does not acquire mutex
 func (b *LockoutBatcher) hasSequencerLockout() bool {
 	return b.lockoutExpiresAt.After(time.Now())
@@ -275,7 +386,7 @@ func (b *LockoutBatcher) hasSequencerLockout() bool {
 func (b *LockoutBatcher) ShouldSequence() bool {
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
-	return b.currentBatcher == b.sequencerBatcher && b.hasSequencerLockout()
+	return b.currentBatcher == b.sequencerBatcher && b.hasSequencerLockout() && !b.handingOver
 }
 
 func (b *LockoutBatcher) getBatcher() batcher.TransactionBatcher {
@@ -286,6 +397,11 @@ func (b *LockoutBatcher) getBatcher() batcher.TransactionBatcher {
 			err: errors.New("sequencer lockout expired"),
 		}
 	}
+	if b.currentBatcher == b.sequencerBatcher && b.handingOver {
+		return &errorBatcher{
+			err: errors.New("sequencer handover in progress"),
+		}
+	}
 	return b.currentBatcher
 }
 
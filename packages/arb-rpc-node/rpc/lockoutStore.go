@@ -0,0 +1,283 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
+)
+
+// Lockout coordinates which of a set of sequencers is currently sequencing
+type Lockout interface {
+	// AcquireOrUpdateLockout takes the sequencer lockout if it's free or renews
+	// it if hasLockUntil shows it's already held, updating hasLockUntil to
+	// when the lockout must be renewed by
+	AcquireOrUpdateLockout(ctx context.Context, hasLockUntil *time.Time)
+	ReleaseLockout(ctx context.Context, hasLockUntil *time.Time)
+	// AcquireOrUpdateLiveliness marks this sequencer as live and caught up
+	AcquireOrUpdateLiveliness(ctx context.Context, hasLockUntil *time.Time)
+	ReleaseLiveliness(ctx context.Context, hasLockUntil *time.Time)
+	// GetLockout returns the RPC URL of the sequencer holding the lockout or
+	// an empty string if nobody does
+	GetLockout(ctx context.Context) string
+	// SelectSequencer returns the RPC URL of the handover target if there is
+	// a live one, and otherwise the highest priority live sequencer or an
+	// empty string if none are live
+	SelectSequencer(ctx context.Context) string
+	// SetHandoverTarget makes the given sequencer take priority over all
+	// others for as long as it's live, or clears the target if rpc is empty
+	SetHandoverTarget(ctx context.Context, rpc string) error
+	GetLatestSeqNum(ctx context.Context) *big.Int
+	UpdateLatestSeqNum(ctx context.Context, seqNum *big.Int, hasLockUntil time.Time)
+}
+
+// LockoutStore is a key value store with expiring keys which is shared
+// between all the sequencers
+type LockoutStore interface {
+	// Set stores value under key for the given duration, or without expiry if
+	// timeout is zero. If onlyIfUnset is true, an existing value is left in
+	// place and false is returned.
+	Set(ctx context.Context, key string, value string, timeout time.Duration, onlyIfUnset bool) (bool, error)
+	// Get returns the value stored under key and false if there isn't one
+	Get(ctx context.Context, key string) (string, bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+const LOCKOUT_KEY string = "lockout.lockout"
+const PRIORITIES_KEY string = "lockout.priorities"
+const LIVELINESS_KEY_PREFIX string = "lockout.liveliness."
+const SEQUENCE_NUMBER_KEY string = "lockout.sequenceNumber"
+const HANDOVER_KEY string = "lockout.handover"
+
+// NewLockoutStore connects to the store selected by the lockout backend
+// config
+func NewLockoutStore(config configuration.Lockout) (LockoutStore, error) {
+	switch config.Backend {
+	case "redis":
+		return newRedisStore(config.Redis)
+	case "etcd":
+		return newEtcdStore(config.Etcd)
+	case "memory":
+		return defaultMemoryStore, nil
+	default:
+		return nil, errors.Errorf("unknown sequencer lockout backend %v", config.Backend)
+	}
+}
+
+type storeLockout struct {
+	store         LockoutStore
+	rpc           string
+	timeout       time.Duration
+	maxLatency    time.Duration
+	seqNumTimeout time.Duration
+	handoverTTL   time.Duration
+}
+
+func NewStoreLockout(store LockoutStore, config configuration.Lockout) Lockout {
+	return &storeLockout{
+		store:         store,
+		rpc:           config.SelfRPCURL,
+		timeout:       config.Timeout,
+		maxLatency:    config.MaxLatency,
+		seqNumTimeout: config.SeqNumTimeout,
+		handoverTTL:   config.HandoverTTL,
+	}
+}
+
+func withRetry(ctx context.Context, f func() error) {
+	backoff := time.Millisecond * 100
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Warn().Msg("lockout context canceled")
+			return
+		default:
+		}
+		err := errors.WithStack(f())
+		if err == nil {
+			return
+		}
+		logger.Warn().Err(err).Msg("lockout store error")
+		time.Sleep(backoff)
+		if backoff < time.Second*2 {
+			backoff *= 2
+		}
+	}
+}
+
+func withTimeout(parentCtx context.Context, timeout time.Time, f func(context.Context) error) {
+	if timeout.Before(time.Now()) {
+		return
+	}
+	timedCtx, cancelTimedCtx := context.WithDeadline(parentCtx, timeout)
+	withRetry(timedCtx, func() error {
+		return f(timedCtx)
+	})
+	cancelTimedCtx()
+}
+
+func (l *storeLockout) SelectSequencer(ctx context.Context) (targetSequencer string) {
+	withRetry(ctx, func() error {
+		handoverTarget, ok, err := l.store.Get(ctx, HANDOVER_KEY)
+		if err != nil {
+			return err
+		}
+		if ok {
+			_, live, err := l.store.Get(ctx, LIVELINESS_KEY_PREFIX+handoverTarget)
+			if err != nil {
+				return err
+			}
+			if live {
+				targetSequencer = handoverTarget
+				return nil
+			}
+			// The target went down so fall back to the normal priorities
+			logger.Warn().Str("rpc", handoverTarget).Msg("handover target no longer live")
+			if err := l.store.Delete(ctx, HANDOVER_KEY); err != nil {
+				return err
+			}
+		}
+		prioritiesString, ok, err := l.store.Get(ctx, PRIORITIES_KEY)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("sequencer priorities unset")
+		}
+		priorities := strings.Split(prioritiesString, ",")
+		for _, rpc := range priorities {
+			_, live, err := l.store.Get(ctx, LIVELINESS_KEY_PREFIX+rpc)
+			if err != nil {
+				return err
+			}
+			if !live {
+				continue
+			}
+			targetSequencer = rpc
+			return nil
+		}
+		targetSequencer = ""
+		return nil
+	})
+	return
+}
+
+func (l *storeLockout) SetHandoverTarget(ctx context.Context, rpc string) error {
+	if rpc == "" {
+		return l.store.Delete(ctx, HANDOVER_KEY)
+	}
+	// The target only takes precedence over the priorities for a limited
+	// time so that they apply again once maintenance is over
+	_, err := l.store.Set(ctx, HANDOVER_KEY, rpc, l.handoverTTL, false)
+	return err
+}
+
+func (l *storeLockout) acquireGenericLockout(ctx context.Context, key string, value string, timeout time.Duration, new bool) (hasLockUntil time.Time) {
+	withRetry(ctx, func() error {
+		attemptingLockUntil := time.Now().Add(timeout)
+		created, err := l.store.Set(ctx, key, value, timeout, new)
+		if err != nil {
+			return err
+		}
+		if created {
+			hasLockUntil = attemptingLockUntil
+		}
+		return nil
+	})
+	return
+}
+
+// This series of methods reads and then possibly modifies hasLockUntil via a pointer.
+// This ensures that the lockout isn't overrun when it is used, and that the new value is updated.
+
+func (l *storeLockout) acquireOrUpdateGenericLockout(ctx context.Context, key string, value string, hasLockUntil *time.Time) {
+	if hasLockUntil.Before(time.Now()) {
+		*hasLockUntil = l.acquireGenericLockout(ctx, key, value, l.timeout, true)
+	} else {
+		timedCtx, cancelTimedCtx := context.WithDeadline(ctx, *hasLockUntil)
+		*hasLockUntil = l.acquireGenericLockout(timedCtx, key, value, l.timeout, false)
+		cancelTimedCtx()
+	}
+	if *hasLockUntil != (time.Time{}) {
+		*hasLockUntil = hasLockUntil.Add(-l.maxLatency)
+	}
+}
+
+func (l *storeLockout) releaseGenericLockout(parentCtx context.Context, key string, hasLockUntil *time.Time) {
+	timeout := *hasLockUntil
+	*hasLockUntil = time.Time{}
+	withTimeout(parentCtx, timeout, func(timedCtx context.Context) error {
+		return l.store.Delete(timedCtx, key)
+	})
+}
+
+func (l *storeLockout) AcquireOrUpdateLockout(ctx context.Context, hasLockUntil *time.Time) {
+	l.acquireOrUpdateGenericLockout(ctx, LOCKOUT_KEY, l.rpc, hasLockUntil)
+}
+
+func (l *storeLockout) ReleaseLockout(ctx context.Context, hasLockUntil *time.Time) {
+	l.releaseGenericLockout(ctx, LOCKOUT_KEY, hasLockUntil)
+}
+
+func (l *storeLockout) AcquireOrUpdateLiveliness(ctx context.Context, hasLockUntil *time.Time) {
+	l.acquireOrUpdateGenericLockout(ctx, LIVELINESS_KEY_PREFIX+l.rpc, "OK", hasLockUntil)
+}
+
+func (l *storeLockout) ReleaseLiveliness(ctx context.Context, hasLockUntil *time.Time) {
+	l.releaseGenericLockout(ctx, LIVELINESS_KEY_PREFIX+l.rpc, hasLockUntil)
+}
+
+func (l *storeLockout) GetLockout(ctx context.Context) (rpc string) {
+	withRetry(ctx, func() error {
+		var err error
+		rpc, _, err = l.store.Get(ctx, LOCKOUT_KEY)
+		return err
+	})
+	return
+}
+
+func (l *storeLockout) GetLatestSeqNum(ctx context.Context) (seqNum *big.Int) {
+	withRetry(ctx, func() error {
+		seqNumString, ok, err := l.store.Get(ctx, SEQUENCE_NUMBER_KEY)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			seqNum = big.NewInt(0)
+			return nil
+		}
+		seqNum, ok = new(big.Int).SetString(seqNumString, 10)
+		if !ok {
+			return errors.New("invalid sequence number in lockout store")
+		}
+		return nil
+	})
+	return
+}
+
+func (l *storeLockout) UpdateLatestSeqNum(parentCtx context.Context, seqNum *big.Int, hasLockUntil time.Time) {
+	withTimeout(parentCtx, hasLockUntil, func(timedCtx context.Context) error {
+		_, err := l.store.Set(timedCtx, SEQUENCE_NUMBER_KEY, seqNum.String(), l.seqNumTimeout, false)
+		return err
+	})
+}
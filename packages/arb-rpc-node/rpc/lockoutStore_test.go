@@ -0,0 +1,220 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
+)
+
+func testLockoutConfig(rpc string) configuration.Lockout {
+	return configuration.Lockout{
+		SelfRPCURL:    rpc,
+		Timeout:       time.Second * 10,
+		MaxLatency:    time.Second,
+		SeqNumTimeout: time.Minute,
+	}
+}
+
+func TestMemoryLockoutStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLockoutStore()
+
+	set, err := store.Set(ctx, "key", "a", time.Millisecond*50, true)
+	if err != nil || !set {
+		t.Fatal("failed to set unset key", err)
+	}
+	if set, _ := store.Set(ctx, "key", "b", 0, true); set {
+		t.Error("shouldn't overwrite existing key")
+	}
+	if value, ok, _ := store.Get(ctx, "key"); !ok || value != "a" {
+		t.Error("wrong value", value, ok)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if _, ok, _ := store.Get(ctx, "key"); ok {
+		t.Error("key should have expired")
+	}
+	if set, _ := store.Set(ctx, "key", "b", 0, true); !set {
+		t.Error("should be able to set expired key")
+	}
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Get(ctx, "key"); ok {
+		t.Error("key should have been deleted")
+	}
+}
+
+func TestStoreLockout(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLockoutStore()
+	if _, err := store.Set(ctx, PRIORITIES_KEY, "a,b", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	first := NewStoreLockout(store, testLockoutConfig("a"))
+	second := NewStoreLockout(store, testLockoutConfig("b"))
+
+	if selected := first.SelectSequencer(ctx); selected != "" {
+		t.Error("no sequencer should be selected before any are live", selected)
+	}
+
+	var secondLive, secondLockout time.Time
+	second.AcquireOrUpdateLiveliness(ctx, &secondLive)
+	if !secondLive.After(time.Now()) {
+		t.Fatal("failed to acquire liveliness")
+	}
+	if selected := first.SelectSequencer(ctx); selected != "b" {
+		t.Error("only live sequencer should be selected but got", selected)
+	}
+	second.AcquireOrUpdateLockout(ctx, &secondLockout)
+	if !secondLockout.After(time.Now()) {
+		t.Fatal("failed to acquire lockout")
+	}
+	second.UpdateLatestSeqNum(ctx, big.NewInt(5), secondLockout)
+
+	var firstLive, firstLockout time.Time
+	first.AcquireOrUpdateLiveliness(ctx, &firstLive)
+	if selected := second.SelectSequencer(ctx); selected != "a" {
+		t.Error("higher priority sequencer should be selected but got", selected)
+	}
+	first.AcquireOrUpdateLockout(ctx, &firstLockout)
+	if !firstLockout.IsZero() {
+		t.Error("shouldn't acquire lockout held by another sequencer")
+	}
+	if holder := first.GetLockout(ctx); holder != "b" {
+		t.Error("wrong lockout holder", holder)
+	}
+
+	// A renewal keeps the lockout
+	second.AcquireOrUpdateLockout(ctx, &secondLockout)
+	if !secondLockout.After(time.Now()) {
+		t.Error("failed to renew lockout")
+	}
+
+	second.ReleaseLockout(ctx, &secondLockout)
+	if !secondLockout.IsZero() {
+		t.Error("release should clear lockout expiry")
+	}
+	first.AcquireOrUpdateLockout(ctx, &firstLockout)
+	if !firstLockout.After(time.Now()) {
+		t.Fatal("failed to acquire released lockout")
+	}
+	if seqNum := first.GetLatestSeqNum(ctx); seqNum.Cmp(big.NewInt(5)) != 0 {
+		t.Error("wrong latest sequence number", seqNum)
+	}
+
+	second.ReleaseLiveliness(ctx, &secondLive)
+	first.ReleaseLiveliness(ctx, &firstLive)
+	if selected := first.SelectSequencer(ctx); selected != "" {
+		t.Error("no sequencer should be selected after all released liveliness", selected)
+	}
+}
+
+func TestStoreLockoutHandoverTarget(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLockoutStore()
+	if _, err := store.Set(ctx, PRIORITIES_KEY, "a,b", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	first := NewStoreLockout(store, testLockoutConfig("a"))
+	second := NewStoreLockout(store, testLockoutConfig("b"))
+
+	var firstLive, secondLive time.Time
+	first.AcquireOrUpdateLiveliness(ctx, &firstLive)
+	second.AcquireOrUpdateLiveliness(ctx, &secondLive)
+	if selected := first.SelectSequencer(ctx); selected != "a" {
+		t.Error("highest priority sequencer should be selected but got", selected)
+	}
+
+	if err := first.SetHandoverTarget(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if selected := first.SelectSequencer(ctx); selected != "b" {
+		t.Error("handover target should be selected but got", selected)
+	}
+
+	// The target is dropped once it's no longer live
+	second.ReleaseLiveliness(ctx, &secondLive)
+	if selected := first.SelectSequencer(ctx); selected != "a" {
+		t.Error("should fall back to priorities but got", selected)
+	}
+	second.AcquireOrUpdateLiveliness(ctx, &secondLive)
+	if selected := first.SelectSequencer(ctx); selected != "a" {
+		t.Error("handover target shouldn't be reinstated but got", selected)
+	}
+}
+
+func TestStoreLockoutHandover(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLockoutStore()
+	if _, err := store.Set(ctx, PRIORITIES_KEY, "a,b", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	activeConfig := testLockoutConfig("a")
+	activeConfig.HandoverTTL = time.Millisecond * 200
+	active := NewStoreLockout(store, activeConfig)
+	target := NewStoreLockout(store, testLockoutConfig("b"))
+
+	var activeLive, activeLockout, targetLive, targetLockout time.Time
+	active.AcquireOrUpdateLiveliness(ctx, &activeLive)
+	target.AcquireOrUpdateLiveliness(ctx, &targetLive)
+	active.AcquireOrUpdateLockout(ctx, &activeLockout)
+	if !activeLockout.After(time.Now()) {
+		t.Fatal("failed to acquire lockout")
+	}
+
+	// The active sequencer's side of the handover
+	if err := active.SetHandoverTarget(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if selected := active.SelectSequencer(ctx); selected != "b" {
+		t.Fatal("handover target should be selected but got", selected)
+	}
+	active.UpdateLatestSeqNum(ctx, big.NewInt(7), activeLockout)
+	active.ReleaseLockout(ctx, &activeLockout)
+
+	// The target's lockout manager then takes over from the handed over position
+	if selected := target.SelectSequencer(ctx); selected != "b" {
+		t.Fatal("target should select itself but got", selected)
+	}
+	target.AcquireOrUpdateLockout(ctx, &targetLockout)
+	if !targetLockout.After(time.Now()) {
+		t.Fatal("target failed to acquire lockout")
+	}
+	if holder := active.GetLockout(ctx); holder != "b" {
+		t.Error("wrong lockout holder after handover", holder)
+	}
+	if seqNum := target.GetLatestSeqNum(ctx); seqNum.Cmp(big.NewInt(7)) != 0 {
+		t.Error("wrong handed over sequence number", seqNum)
+	}
+	active.AcquireOrUpdateLockout(ctx, &activeLockout)
+	if !activeLockout.IsZero() {
+		t.Error("previous sequencer shouldn't take the lockout back")
+	}
+
+	// The handover target expires and the priorities apply again
+	time.Sleep(time.Millisecond * 300)
+	if _, ok, _ := store.Get(ctx, HANDOVER_KEY); ok {
+		t.Error("handover target should have expired")
+	}
+	if selected := target.SelectSequencer(ctx); selected != "a" {
+		t.Error("should fall back to priorities but got", selected)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultMemoryStore is shared by every sequencer in the process that uses
+// the memory lockout backend
+var defaultMemoryStore = NewMemoryLockoutStore()
+
+type memoryEntry struct {
+	value   string
+	expires time.Time
+}
+
+// MemoryLockoutStore keeps the lockout state in process, which allows
+// running several sequencers against each other in tests
+type MemoryLockoutStore struct {
+	mutex   sync.Mutex
+	entries map[string]memoryEntry
+}
+
+func NewMemoryLockoutStore() *MemoryLockoutStore {
+	return &MemoryLockoutStore{entries: make(map[string]memoryEntry)}
+}
+
+// Does not acquire mutex
+func (m *MemoryLockoutStore) get(key string) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expires.IsZero() && !entry.expires.After(time.Now()) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+func (m *MemoryLockoutStore) Set(_ context.Context, key string, value string, timeout time.Duration, onlyIfUnset bool) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.get(key); ok && onlyIfUnset {
+		return false, nil
+	}
+	entry := memoryEntry{value: value}
+	if timeout > 0 {
+		entry.expires = time.Now().Add(timeout)
+	}
+	m.entries[key] = entry
+	return true, nil
+}
+
+func (m *MemoryLockoutStore) Get(_ context.Context, key string) (string, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, ok := m.get(key)
+	return entry.value, ok, nil
+}
+
+func (m *MemoryLockoutStore) Delete(_ context.Context, key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.entries, key)
+	return nil
+}
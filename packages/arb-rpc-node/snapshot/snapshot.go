@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/pkg/errors"
@@ -85,6 +86,38 @@ func (s *Snapshot) AddMessage(msg message.Message, sender common.Address, target
 	return res, nil
 }
 
+// ApplyMessage can only be called if the snapshot is uniquely owned
+// Unlike AddMessage, it executes msg at the snapshot's current time and
+// accepts whatever request id is produced, which allows replaying messages
+// that were originally sequenced elsewhere.
+// If an error is returned, s is unmodified
+func (s *Snapshot) ApplyMessage(msg message.Message, sender common.Address) (*evm.TxResult, []value.Value, error) {
+	mach := s.mach.Clone()
+	inboxMsg := message.NewInboxMessage(msg, sender, s.nextInboxSeqNum, big.NewInt(0), s.time)
+	res, debugPrints, err := runTx(mach, inboxMsg, 100000000000)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mach = mach
+	s.nextInboxSeqNum = new(big.Int).Add(s.nextInboxSeqNum, big.NewInt(1))
+	return res, debugPrints, nil
+}
+
+// ReplayRequest can only be called if the snapshot is uniquely owned
+// It re-executes the request which produced res at the chain time that the
+// request originally executed at
+func (s *Snapshot) ReplayRequest(res *evm.TxResult) (*evm.TxResult, []value.Value, error) {
+	msg, err := message.NestedMessage(res.IncomingRequest.Data, res.IncomingRequest.Kind)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.AdvanceTime(inbox.ChainTime{
+		BlockNum:  common.NewTimeBlocks(new(big.Int).Set(res.IncomingRequest.L1BlockNumber)),
+		Timestamp: new(big.Int).Set(res.IncomingRequest.L2Timestamp),
+	})
+	return s.ApplyMessage(msg, res.IncomingRequest.Sender)
+}
+
 // AdvanceTime can only be called if the snapshot is uniquely owned
 func (s *Snapshot) AdvanceTime(time inbox.ChainTime) {
 	s.time = time
@@ -328,6 +361,19 @@ func (s *Snapshot) GetStorageAt(account common.Address, index *big.Int) (*big.In
 	return arbos.ParseGetStorageAtResult(res.ReturnData)
 }
 
+// GetStorage returns all of the nonzero storage of the account. It relies on
+// the ArbosTest precompile so it's only available on test chains.
+func (s *Snapshot) GetStorage(account common.Address) (map[ethcommon.Hash]ethcommon.Hash, error) {
+	res, err := s.basicCall(arbos.GetMarshalledStorageData(account), common.NewAddressFromEth(arbos.ARBOS_TEST_ADDRESS))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkValidResult(res); err != nil {
+		return nil, err
+	}
+	return arbos.ParseMarshalledStorageResult(res.ReturnData)
+}
+
 func (s *Snapshot) ArbOSVersion() (*big.Int, error) {
 	res, _, err := s.basicCallUnsafe(arbos.ArbOSVersionData(), common.NewAddressFromEth(arbos.ARB_SYS_ADDRESS))
 	if err != nil {
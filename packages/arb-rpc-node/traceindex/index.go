@@ -0,0 +1,505 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package traceindex
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethcore "github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/evm"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/snapshot"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/machine"
+)
+
+var logger = log.With().Caller().Stack().Str("component", "traceindex").Logger()
+
+var (
+	progressKey       = []byte("p")
+	blockPrefix       = []byte("b")
+	addressPrefix     = []byte("a")
+	transactionPrefix = []byte("t")
+)
+
+// ChainDB is the subset of the TxDB that the indexer reads blocks from
+type ChainDB interface {
+	BlockCount() (uint64, error)
+	GetBlock(height uint64) (*machine.BlockInfo, error)
+	GetBlockResults(block *machine.BlockInfo) (*evm.BlockInfo, []*evm.TxResult, error)
+	GetSnapshot(blockHeight uint64) (*snapshot.Snapshot, error)
+	SubscribeChainEvent(ch chan<- ethcore.ChainEvent) event.Subscription
+}
+
+type indexedBlock struct {
+	Hash   common.Hash `json:"hash"`
+	Traces []*Trace    `json:"traces"`
+}
+
+// FilterLimits bound the work a single Filter call can do. A zero value
+// disables the corresponding limit.
+type FilterLimits struct {
+	MaxBlockRange uint64
+	MaxResults    uint64
+}
+
+// Index re-executes every block with tracing enabled and stores the resulting
+// internal calls so that they can be looked up by block, transaction or
+// address
+type Index struct {
+	db     ChainDB
+	store  ethdb.KeyValueStore
+	limits FilterLimits
+
+	mutex     sync.RWMutex
+	nextBlock uint64
+}
+
+func New(db ChainDB, store ethdb.KeyValueStore, limits FilterLimits) (*Index, error) {
+	idx := &Index{
+		db:     db,
+		store:  store,
+		limits: limits,
+	}
+	data, err := store.Get(progressKey)
+	if err == nil {
+		idx.nextBlock = binary.BigEndian.Uint64(data)
+	} else if has, _ := store.Has(progressKey); has {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Start indexes all existing blocks and then follows the chain as new blocks
+// are added to the TxDB
+func (idx *Index) Start(ctx context.Context) <-chan error {
+	errChan := make(chan error, 1)
+	chainEvents := make(chan ethcore.ChainEvent, 10)
+	sub := idx.db.SubscribeChainEvent(chainEvents)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			if err := idx.catchUp(ctx); err != nil {
+				errChan <- err
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-chainEvents:
+			case <-time.After(time.Second * 5):
+			}
+		}
+	}()
+	return errChan
+}
+
+// IndexedBlockCount returns the number of blocks that have been indexed
+func (idx *Index) IndexedBlockCount() uint64 {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return idx.nextBlock
+}
+
+func (idx *Index) catchUp(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		blockCount, err := idx.db.BlockCount()
+		if err != nil {
+			return err
+		}
+		if err := idx.handleReorg(); err != nil {
+			return err
+		}
+		next := idx.IndexedBlockCount()
+		if next >= blockCount {
+			return nil
+		}
+		block, err := idx.db.GetBlock(next)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			// Block was removed by a reorg so wait for it to be replaced
+			return nil
+		}
+		traces, err := idx.traceBlock(block)
+		if err != nil {
+			// Leave the block unindexed so that it's traced again on the next
+			// pass rather than serving traces that are missing transactions
+			logger.Warn().Err(err).Uint64("block", next).Msg("couldn't trace block")
+			return nil
+		}
+		if traces == nil {
+			// Block results weren't available due to a reorg
+			return nil
+		}
+		if err := idx.addBlock(block.Header.Number.Uint64(), block.Header.Hash(), traces); err != nil {
+			return err
+		}
+	}
+}
+
+// handleReorg removes indexed blocks which are no longer part of the chain
+func (idx *Index) handleReorg() error {
+	for {
+		next := idx.IndexedBlockCount()
+		if next == 0 {
+			return nil
+		}
+		indexed, err := idx.getBlock(next - 1)
+		if err != nil {
+			return err
+		}
+		current, err := idx.db.GetBlock(next - 1)
+		if err != nil {
+			return err
+		}
+		if indexed != nil && current != nil && current.Header.Hash() == indexed.Hash {
+			return nil
+		}
+		logger.Info().Uint64("block", next-1).Msg("removing reorged block from trace index")
+		if err := idx.removeLastBlock(); err != nil {
+			return err
+		}
+	}
+}
+
+func (idx *Index) traceBlock(block *machine.BlockInfo) ([]*Trace, error) {
+	traces := make([]*Trace, 0)
+	height := block.Header.Number.Uint64()
+	if height == 0 {
+		return traces, nil
+	}
+	_, results, err := idx.db.GetBlockResults(block)
+	if err != nil || results == nil {
+		return nil, err
+	}
+	snap, err := idx.db.GetSnapshot(height - 1)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, errors.Errorf("no snapshot available for block %v", height-1)
+	}
+	snap = snap.Clone()
+	for _, res := range results {
+		_, debugPrints, err := snap.ReplayRequest(res)
+		if err != nil {
+			// Later transactions would be traced against the wrong state
+			return nil, errors.Wrapf(err, "couldn't replay transaction %v", res.IncomingRequest.MessageID)
+		}
+		items, err := evm.GetTrace(debugPrints)
+		if err != nil {
+			return nil, err
+		}
+		if items == nil {
+			continue
+		}
+		root, err := evm.NewCallTree(items)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trace for transaction %v", res.IncomingRequest.MessageID)
+		}
+		traces = append(traces, FlattenCallTree(root, res, block.Header.Hash())...)
+	}
+	return traces, nil
+}
+
+func blockKey(height uint64) []byte {
+	return append(append([]byte{}, blockPrefix...), encodeUint64(height)...)
+}
+
+func addressKey(address common.Address, height uint64) []byte {
+	key := append(append([]byte{}, addressPrefix...), address.Bytes()...)
+	return append(key, encodeUint64(height)...)
+}
+
+func transactionKey(txHash common.Hash) []byte {
+	return append(append([]byte{}, transactionPrefix...), txHash.Bytes()...)
+}
+
+func encodeUint64(val uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, val)
+	return data
+}
+
+func traceAddresses(traces []*Trace) map[common.Address]bool {
+	addresses := make(map[common.Address]bool)
+	for _, trace := range traces {
+		addresses[trace.From()] = true
+		if to := trace.To(); to != nil {
+			addresses[*to] = true
+		}
+	}
+	return addresses
+}
+
+func (idx *Index) addBlock(height uint64, hash common.Hash, traces []*Trace) error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if height != idx.nextBlock {
+		return errors.Errorf("tried to index block %v but expected %v", height, idx.nextBlock)
+	}
+	data, err := json.Marshal(indexedBlock{Hash: hash, Traces: traces})
+	if err != nil {
+		return err
+	}
+	batch := idx.store.NewBatch()
+	if err := batch.Put(blockKey(height), data); err != nil {
+		return err
+	}
+	for address := range traceAddresses(traces) {
+		if err := batch.Put(addressKey(address, height), []byte{}); err != nil {
+			return err
+		}
+	}
+	for _, trace := range traces {
+		if err := batch.Put(transactionKey(trace.TransactionHash), encodeUint64(height)); err != nil {
+			return err
+		}
+	}
+	if err := batch.Put(progressKey, encodeUint64(height+1)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	idx.nextBlock = height + 1
+	return nil
+}
+
+func (idx *Index) removeLastBlock() error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if idx.nextBlock == 0 {
+		return nil
+	}
+	height := idx.nextBlock - 1
+	block, err := idx.getBlockUnsafe(height)
+	if err != nil {
+		return err
+	}
+	batch := idx.store.NewBatch()
+	if block != nil {
+		for address := range traceAddresses(block.Traces) {
+			if err := batch.Delete(addressKey(address, height)); err != nil {
+				return err
+			}
+		}
+		for _, trace := range block.Traces {
+			if err := batch.Delete(transactionKey(trace.TransactionHash)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := batch.Delete(blockKey(height)); err != nil {
+		return err
+	}
+	if err := batch.Put(progressKey, encodeUint64(height)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	idx.nextBlock = height
+	return nil
+}
+
+func (idx *Index) getBlock(height uint64) (*indexedBlock, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return idx.getBlockUnsafe(height)
+}
+
+func (idx *Index) getBlockUnsafe(height uint64) (*indexedBlock, error) {
+	if height >= idx.nextBlock {
+		return nil, nil
+	}
+	data, err := idx.store.Get(blockKey(height))
+	if err != nil {
+		return nil, err
+	}
+	var block indexedBlock
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// BlockTraces returns all traces in the given block or nil if the block
+// hasn't been indexed
+func (idx *Index) BlockTraces(height uint64) ([]*Trace, error) {
+	block, err := idx.getBlock(height)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return block.Traces, nil
+}
+
+// TransactionTraces returns all traces of the given transaction or nil if
+// the transaction hasn't been indexed
+func (idx *Index) TransactionTraces(txHash common.Hash) ([]*Trace, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	if has, err := idx.store.Has(transactionKey(txHash)); err != nil || !has {
+		return nil, err
+	}
+	data, err := idx.store.Get(transactionKey(txHash))
+	if err != nil {
+		return nil, err
+	}
+	block, err := idx.getBlockUnsafe(binary.BigEndian.Uint64(data))
+	if err != nil || block == nil {
+		return nil, err
+	}
+	var traces []*Trace
+	for _, trace := range block.Traces {
+		if trace.TransactionHash == txHash {
+			traces = append(traces, trace)
+		}
+	}
+	return traces, nil
+}
+
+type Filter struct {
+	FromBlock   uint64
+	ToBlock     uint64
+	FromAddress []common.Address
+	ToAddress   []common.Address
+	After       uint64
+	Count       *uint64
+}
+
+func (f *Filter) matches(trace *Trace) bool {
+	if len(f.FromAddress) > 0 && !containsAddress(f.FromAddress, trace.From()) {
+		return false
+	}
+	if len(f.ToAddress) > 0 {
+		to := trace.To()
+		if to == nil || !containsAddress(f.ToAddress, *to) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the traces within the block range of the filter which
+// match its address restrictions. It fails if the range covers more blocks or
+// the filter matches more traces than the index's limits allow.
+func (idx *Index) Filter(filter *Filter) ([]*Trace, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	if filter.FromBlock > filter.ToBlock {
+		return nil, errors.New("fromBlock must not be after toBlock")
+	}
+	toBlock := filter.ToBlock
+	if idx.nextBlock == 0 {
+		return []*Trace{}, nil
+	}
+	if toBlock >= idx.nextBlock {
+		toBlock = idx.nextBlock - 1
+	}
+	if filter.FromBlock > toBlock {
+		return []*Trace{}, nil
+	}
+	if idx.limits.MaxBlockRange > 0 && toBlock-filter.FromBlock >= idx.limits.MaxBlockRange {
+		return nil, errors.Errorf("block range of %v exceeds limit of %v", toBlock-filter.FromBlock+1, idx.limits.MaxBlockRange)
+	}
+
+	var heights []uint64
+	if len(filter.FromAddress) == 0 && len(filter.ToAddress) == 0 {
+		for height := filter.FromBlock; height <= toBlock; height++ {
+			heights = append(heights, height)
+		}
+	} else {
+		heightSet := make(map[uint64]bool)
+		addresses := append(append([]common.Address{}, filter.FromAddress...), filter.ToAddress...)
+		for _, address := range addresses {
+			prefix := append(append([]byte{}, addressPrefix...), address.Bytes()...)
+			it := idx.store.NewIterator(prefix, encodeUint64(filter.FromBlock))
+			for it.Next() {
+				height := binary.BigEndian.Uint64(it.Key()[len(prefix):])
+				if height > toBlock {
+					break
+				}
+				heightSet[height] = true
+			}
+			it.Release()
+			if err := it.Error(); err != nil {
+				return nil, err
+			}
+		}
+		for height := range heightSet {
+			heights = append(heights, height)
+		}
+		sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	}
+
+	traces := make([]*Trace, 0)
+	skipped := uint64(0)
+	for _, height := range heights {
+		block, err := idx.getBlockUnsafe(height)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			continue
+		}
+		for _, trace := range block.Traces {
+			if !filter.matches(trace) {
+				continue
+			}
+			if skipped < filter.After {
+				skipped++
+				continue
+			}
+			traces = append(traces, trace)
+			if filter.Count != nil && uint64(len(traces)) >= *filter.Count {
+				return traces, nil
+			}
+			if idx.limits.MaxResults > 0 && uint64(len(traces)) > idx.limits.MaxResults {
+				return nil, errors.Errorf("query returned more than %v results", idx.limits.MaxResults)
+			}
+		}
+	}
+	return traces, nil
+}
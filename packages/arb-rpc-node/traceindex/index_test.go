@@ -0,0 +1,205 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package traceindex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethcore "github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/evm"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/snapshot"
+	arbcommon "github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/machine"
+)
+
+// failingChainDB has blocks whose results can't be loaded
+type failingChainDB struct {
+	blockCount uint64
+	feed       event.Feed
+}
+
+func (db *failingChainDB) BlockCount() (uint64, error) {
+	return db.blockCount, nil
+}
+
+func (db *failingChainDB) GetBlock(height uint64) (*machine.BlockInfo, error) {
+	if height >= db.blockCount {
+		return nil, nil
+	}
+	return &machine.BlockInfo{Header: &types.Header{Number: new(big.Int).SetUint64(height)}}, nil
+}
+
+func (db *failingChainDB) GetBlockResults(*machine.BlockInfo) (*evm.BlockInfo, []*evm.TxResult, error) {
+	return nil, nil, errors.New("results unavailable")
+}
+
+func (db *failingChainDB) GetSnapshot(uint64) (*snapshot.Snapshot, error) {
+	return nil, errors.New("snapshot unavailable")
+}
+
+func (db *failingChainDB) SubscribeChainEvent(ch chan<- ethcore.ChainEvent) event.Subscription {
+	return db.feed.Subscribe(ch)
+}
+
+func newTestTrace(height uint64, txHash common.Hash, from, to common.Address) *Trace {
+	return &Trace{
+		Action:          Action{CallType: "call", From: from, To: &to},
+		BlockNumber:     height,
+		TraceAddress:    []int{},
+		TransactionHash: txHash,
+		Type:            "call",
+	}
+}
+
+func TestIndex(t *testing.T) {
+	store := memorydb.New()
+	idx, err := New(nil, store, FilterLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := arbcommon.RandAddress().ToEthAddress()
+	bob := arbcommon.RandAddress().ToEthAddress()
+	carol := arbcommon.RandAddress().ToEthAddress()
+	tx1 := arbcommon.RandHash().ToEthHash()
+	tx2 := arbcommon.RandHash().ToEthHash()
+	tx3 := arbcommon.RandHash().ToEthHash()
+
+	blocks := [][]*Trace{
+		{},
+		{newTestTrace(1, tx1, alice, bob), newTestTrace(1, tx1, bob, carol)},
+		{newTestTrace(2, tx2, carol, bob)},
+		{newTestTrace(3, tx3, alice, carol)},
+	}
+	for height, traces := range blocks {
+		if err := idx.addBlock(uint64(height), arbcommon.RandHash().ToEthHash(), traces); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idx.addBlock(10, common.Hash{}, nil); err == nil {
+		t.Error("should fail to index block out of order")
+	}
+
+	traces, err := idx.BlockTraces(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 2 {
+		t.Error("wrong trace count for block", len(traces))
+	}
+
+	traces, err = idx.TransactionTraces(tx2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 || traces[0].From() != carol {
+		t.Error("wrong traces for transaction")
+	}
+
+	checkFilter := func(filter *Filter, expected int) {
+		t.Helper()
+		traces, err := idx.Filter(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(traces) != expected {
+			t.Errorf("expected %v traces but got %v", expected, len(traces))
+		}
+	}
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100}, 4)
+	checkFilter(&Filter{FromBlock: 2, ToBlock: 3}, 2)
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100, FromAddress: []common.Address{alice}}, 2)
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100, ToAddress: []common.Address{carol}}, 2)
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 2, ToAddress: []common.Address{carol}}, 1)
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100, FromAddress: []common.Address{alice}, ToAddress: []common.Address{carol}}, 1)
+	count := uint64(1)
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100, After: 1, Count: &count}, 1)
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100, After: 3}, 1)
+
+	limited, err := New(nil, store, FilterLimits{MaxBlockRange: 2, MaxResults: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limited.Filter(&Filter{FromBlock: 1, ToBlock: 3, FromAddress: []common.Address{carol}}); err == nil {
+		t.Error("filter should fail when block range exceeds limit")
+	}
+	if _, err := limited.Filter(&Filter{FromBlock: 1, ToBlock: 2}); err == nil {
+		t.Error("filter should fail when results exceed limit")
+	}
+	if traces, err := limited.Filter(&Filter{FromBlock: 1, ToBlock: 2, Count: &count}); err != nil || len(traces) != 1 {
+		t.Error("filter within limits should succeed", err)
+	}
+	if traces, err := limited.Filter(&Filter{FromBlock: 2, ToBlock: 100}); err != nil || len(traces) != 2 {
+		t.Error("range past the indexed blocks should be capped before checking limits", err)
+	}
+
+	// Simulate a reorg of the latest block
+	if err := idx.removeLastBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if idx.IndexedBlockCount() != 3 {
+		t.Error("wrong indexed block count after removal")
+	}
+	traces, err = idx.TransactionTraces(tx3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traces != nil {
+		t.Error("removed transaction should not be indexed")
+	}
+	checkFilter(&Filter{FromBlock: 0, ToBlock: 100, FromAddress: []common.Address{alice}}, 1)
+
+	// Progress should be restored when reopening the index
+	reopened, err := New(nil, store, FilterLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.IndexedBlockCount() != 3 {
+		t.Error("wrong indexed block count after reopening")
+	}
+}
+
+func TestIndexLeavesUntraceableBlockUnindexed(t *testing.T) {
+	idx, err := New(&failingChainDB{blockCount: 3}, memorydb.New(), FilterLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := idx.catchUp(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		// Block 0 has no transactions but block 1 can't be traced
+		if idx.IndexedBlockCount() != 1 {
+			t.Fatal("indexed", idx.IndexedBlockCount(), "blocks instead of stopping at the untraceable block")
+		}
+		traces, err := idx.BlockTraces(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if traces != nil {
+			t.Error("untraceable block should not be indexed")
+		}
+	}
+}
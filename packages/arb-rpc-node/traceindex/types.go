@@ -0,0 +1,153 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package traceindex
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/evm"
+)
+
+type Action struct {
+	CallType string          `json:"callType,omitempty"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to,omitempty"`
+	Gas      *hexutil.Big    `json:"gas"`
+	Input    hexutil.Bytes   `json:"input,omitempty"`
+	Init     hexutil.Bytes   `json:"init,omitempty"`
+	Value    *hexutil.Big    `json:"value"`
+}
+
+type Result struct {
+	Address *common.Address `json:"address,omitempty"`
+	Code    hexutil.Bytes   `json:"code,omitempty"`
+	GasUsed *hexutil.Big    `json:"gasUsed"`
+	Output  hexutil.Bytes   `json:"output,omitempty"`
+}
+
+// Trace is a single call made during a transaction in the flattened format
+// used by the Parity/OpenEthereum trace module
+type Trace struct {
+	Action              Action      `json:"action"`
+	BlockHash           common.Hash `json:"blockHash"`
+	BlockNumber         uint64      `json:"blockNumber"`
+	Error               string      `json:"error,omitempty"`
+	Result              *Result     `json:"result"`
+	Subtraces           int         `json:"subtraces"`
+	TraceAddress        []int       `json:"traceAddress"`
+	TransactionHash     common.Hash `json:"transactionHash"`
+	TransactionPosition uint64      `json:"transactionPosition"`
+	Type                string      `json:"type"`
+}
+
+// From returns the address that made the traced call
+func (t *Trace) From() common.Address {
+	return t.Action.From
+}
+
+// To returns the address that received the traced call or the address of the
+// contract that was created
+func (t *Trace) To() *common.Address {
+	if t.Result != nil && t.Result.Address != nil {
+		return t.Result.Address
+	}
+	return t.Action.To
+}
+
+// FlattenCallTree converts the call tree of the given transaction into a list
+// of traces in depth first order
+func FlattenCallTree(root *evm.CallFrame, res *evm.TxResult, blockHash common.Hash) []*Trace {
+	var traces []*Trace
+	var addFrame func(frame *evm.CallFrame, traceAddress []int)
+	addFrame = func(frame *evm.CallFrame, traceAddress []int) {
+		trace := newTrace(frame, traceAddress)
+		trace.BlockHash = blockHash
+		trace.BlockNumber = res.IncomingRequest.L2BlockNumber.Uint64()
+		trace.TransactionHash = res.IncomingRequest.MessageID.ToEthHash()
+		trace.TransactionPosition = res.TxIndex.Uint64()
+		traces = append(traces, trace)
+		for i, subFrame := range frame.Calls {
+			subAddress := make([]int, len(traceAddress), len(traceAddress)+1)
+			copy(subAddress, traceAddress)
+			addFrame(subFrame, append(subAddress, i))
+		}
+	}
+	addFrame(root, []int{})
+	return traces
+}
+
+func newTrace(frame *evm.CallFrame, traceAddress []int) *Trace {
+	call := frame.Call
+	trace := &Trace{
+		Action: Action{
+			From:  call.From.ToEthAddress(),
+			Gas:   (*hexutil.Big)(call.Gas),
+			Value: (*hexutil.Big)(call.Value),
+		},
+		Subtraces:    len(frame.Calls),
+		TraceAddress: traceAddress,
+	}
+
+	var createdAddress *common.Address
+	switch create := frame.Create.(type) {
+	case *evm.CreateTrace:
+		address := create.ContractAddress.ToEthAddress()
+		createdAddress = &address
+		trace.Action.Init = create.Code
+	case *evm.Create2Trace:
+		address := create.ContractAddress.ToEthAddress()
+		createdAddress = &address
+		trace.Action.Init = create.Code
+	}
+
+	if createdAddress != nil {
+		trace.Type = "create"
+	} else {
+		trace.Type = "call"
+		trace.Action.CallType = strings.ToLower(call.Type.String())
+		trace.Action.Input = call.Data
+		if call.To != nil {
+			to := call.To.ToEthAddress()
+			trace.Action.To = &to
+		}
+	}
+
+	if frame.Return == nil {
+		trace.Error = "Incomplete"
+		return trace
+	}
+	if frame.Return.Result == evm.RevertCode {
+		trace.Error = "Reverted"
+		return trace
+	}
+	if frame.Return.Result != evm.ReturnCode {
+		trace.Error = frame.Return.Result.String()
+		return trace
+	}
+
+	trace.Result = &Result{GasUsed: (*hexutil.Big)(frame.Return.GasUsed)}
+	if createdAddress != nil {
+		trace.Result.Address = createdAddress
+		trace.Result.Code = frame.Return.ReturnData
+	} else {
+		trace.Result.Output = frame.Return.ReturnData
+	}
+	return trace
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"encoding/json"
 	"math/big"
+	"strconv"
 
 	"github.com/ethersphere/bee/pkg/crypto/eip712"
 	"github.com/pkg/errors"
@@ -20,12 +21,19 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
 )
 
+// Impersonator sends transactions on behalf of accounts without their keys
+type Impersonator interface {
+	IsImpersonated(account common.Address) bool
+	SendImpersonatedTransaction(ctx context.Context, sender common.Address, args *SendTransactionArgs) (common.Hash, error)
+}
+
 type Accounts struct {
-	srv         *Server
-	addresses   []common.Address
-	privateKeys map[common.Address]*ecdsa.PrivateKey
-	signer      types.Signer
-	counter     *prometheus.CounterVec
+	srv          *Server
+	addresses    []common.Address
+	privateKeys  map[common.Address]*ecdsa.PrivateKey
+	signer       types.Signer
+	counter      *prometheus.CounterVec
+	impersonator Impersonator
 }
 
 func NewAccounts(ethServer *Server, privateKeys []*ecdsa.PrivateKey, metricsConfig *metrics.MetricsConfig) *Accounts {
@@ -66,6 +74,11 @@ func (s *Accounts) SendTransaction(ctx context.Context, args *SendTransactionArg
 	}
 	privKey, ok := s.privateKeys[sender]
 	if !ok {
+		if s.impersonator != nil && s.impersonator.IsImpersonated(sender) {
+			txHash, err := s.impersonator.SendImpersonatedTransaction(ctx, sender, args)
+			s.counter.WithLabelValues("eth_sendTransaction", strconv.FormatBool(err == nil)).Inc()
+			return txHash, err
+		}
 		s.counter.WithLabelValues("eth_sendTransaction", "false").Inc()
 		return common.Hash{}, errors.New("sender does not have unlocked wallet")
 	}
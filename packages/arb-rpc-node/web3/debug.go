@@ -0,0 +1,205 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web3
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-evm/evm"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/snapshot"
+	arbcommon "github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/value"
+)
+
+const callTracer = "callTracer"
+
+type Debug struct {
+	s       *Server
+	counter *prometheus.CounterVec
+}
+
+func NewDebug(s *Server) *Debug {
+	return &Debug{s: s, counter: s.counter}
+}
+
+// TraceTransaction re-executes the given transaction on top of the state at
+// the end of the previous block and returns its call tree
+func (d *Debug) TraceTransaction(txHash hexutil.Bytes, config *TraceConfig) (*CallFrameResult, error) {
+	frame, err := d.traceTransaction(txHash, config)
+	if err != nil {
+		d.counter.WithLabelValues("debug_traceTransaction", "false").Inc()
+		return nil, err
+	}
+	d.counter.WithLabelValues("debug_traceTransaction", "true").Inc()
+	return frame, nil
+}
+
+// TraceCall executes the given call against the state at the given block and
+// returns its call tree
+func (d *Debug) TraceCall(callArgs CallTxArgs, blockNum rpc.BlockNumberOrHash, config *TraceConfig) (*CallFrameResult, error) {
+	frame, err := d.traceCall(callArgs, blockNum, config)
+	if err != nil {
+		d.counter.WithLabelValues("debug_traceCall", "false").Inc()
+		return nil, err
+	}
+	d.counter.WithLabelValues("debug_traceCall", "true").Inc()
+	return frame, nil
+}
+
+func (d *Debug) traceTransaction(txHash hexutil.Bytes, config *TraceConfig) (*CallFrameResult, error) {
+	if err := checkTraceConfig(config); err != nil {
+		return nil, err
+	}
+	res, info, err := d.s.getTransactionInfoByHash(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("transaction not found")
+	}
+	snap, err := d.s.getParentSnapshot(info.Header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the transactions that came before this one in the block so that
+	// it is traced against the same state it originally executed on
+	_, blockResults, err := d.s.srv.GetMachineBlockResults(info)
+	if err != nil {
+		return nil, err
+	}
+	for _, blockRes := range blockResults {
+		if blockRes.TxIndex.Cmp(res.TxIndex) >= 0 {
+			break
+		}
+		if _, _, err := snap.ReplayRequest(blockRes); err != nil {
+			return nil, errors.Wrapf(err, "error replaying transaction %v", blockRes.IncomingRequest.MessageID)
+		}
+	}
+
+	_, debugPrints, err := snap.ReplayRequest(res)
+	if err != nil {
+		return nil, err
+	}
+	return callFrameFromDebugPrints(debugPrints)
+}
+
+func (d *Debug) traceCall(callArgs CallTxArgs, blockNum rpc.BlockNumberOrHash, config *TraceConfig) (*CallFrameResult, error) {
+	if err := checkTraceConfig(config); err != nil {
+		return nil, err
+	}
+	snap, err := d.s.getSnapshotForNumberOrHash(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	from, msg := buildCallMsg(callArgs, d.s.maxCallGas)
+	_, debugPrints, err := snap.Call(msg, from)
+	if err != nil {
+		return nil, err
+	}
+	return callFrameFromDebugPrints(debugPrints)
+}
+
+func (s *Server) getParentSnapshot(blockNum uint64) (*snapshot.Snapshot, error) {
+	if blockNum == 0 {
+		return nil, errors.New("can't trace transactions in genesis block")
+	}
+	snap, err := s.srv.GetSnapshot(blockNum - 1)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, errors.Errorf("unsupported block number %v", blockNum-1)
+	}
+	// Snapshots are shared through the cache so we must have our own copy
+	// before adding messages to it
+	return snap.Clone(), nil
+}
+
+func checkTraceConfig(config *TraceConfig) error {
+	if config != nil && config.Tracer != nil && *config.Tracer != callTracer {
+		return errors.Errorf("unsupported tracer %v, only %v is available", *config.Tracer, callTracer)
+	}
+	return nil
+}
+
+func callFrameFromDebugPrints(debugPrints []value.Value) (*CallFrameResult, error) {
+	items, err := evm.GetTrace(debugPrints)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return nil, errors.New("execution didn't produce a trace")
+	}
+	root, err := evm.NewCallTree(items)
+	if err != nil {
+		return nil, err
+	}
+	return newCallFrameResult(root), nil
+}
+
+func newCallFrameResult(frame *evm.CallFrame) *CallFrameResult {
+	call := frame.Call
+	res := &CallFrameResult{
+		Type:  strings.ToUpper(call.Type.String()),
+		From:  call.From.ToEthAddress(),
+		Value: (*hexutil.Big)(call.Value),
+		Gas:   (*hexutil.Big)(call.Gas),
+		Input: call.Data,
+	}
+	if call.To != nil {
+		to := call.To.ToEthAddress()
+		res.To = &to
+	}
+
+	switch create := frame.Create.(type) {
+	case *evm.CreateTrace:
+		res.Type = "CREATE"
+		res.Input = create.Code
+		res.To = ethAddressPtr(create.ContractAddress)
+	case *evm.Create2Trace:
+		res.Type = "CREATE2"
+		res.Input = create.Code
+		res.To = ethAddressPtr(create.ContractAddress)
+	}
+
+	if frame.Return != nil {
+		res.GasUsed = (*hexutil.Big)(frame.Return.GasUsed)
+		res.Output = frame.Return.ReturnData
+		if frame.Return.Result == evm.RevertCode {
+			res.Error = "execution reverted"
+		} else if frame.Return.Result != evm.ReturnCode {
+			res.Error = frame.Return.Result.String()
+		}
+	}
+
+	for _, subFrame := range frame.Calls {
+		res.Calls = append(res.Calls, newCallFrameResult(subFrame))
+	}
+	return res
+}
+
+func ethAddressPtr(address arbcommon.Address) *common.Address {
+	ret := address.ToEthAddress()
+	return &ret
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-evm/message"
 	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/aggregator"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/batcher"
 	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/snapshot"
 	arbcommon "github.com/offchainlabs/arbitrum/packages/arb-util/common"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/machine"
@@ -349,6 +350,14 @@ func (s *Server) GetBlockByNumber(blockNum *rpc.BlockNumber, includeTxData bool)
 		s.counter.WithLabelValues("eth_getBlockByNumber", "false").Inc()
 		return nil, err
 	}
+	if *blockNum == rpc.PendingBlockNumber {
+		if pool, ok := s.srv.TxPool().(batcher.PendingBlockPool); ok {
+			if pending, timestamp := pool.PendingBlock(); len(pending) > 0 {
+				s.counter.WithLabelValues("eth_getBlockByNumber", "true").Inc()
+				return s.getPendingBlock(info, pending, timestamp, includeTxData)
+			}
+		}
+	}
 	s.counter.WithLabelValues("eth_getBlockByNumber", "true").Inc()
 	return s.getBlock(info, includeTxData)
 }
@@ -520,6 +529,35 @@ func (s *Server) getBlock(block *machine.BlockInfo, includeTxData bool) (*GetBlo
 	return makeBlockResult(l2Block, block.Header, transactions), nil
 }
 
+// getPendingBlock builds the block following latest out of the transactions
+// waiting in the pool, in the order they'll be mined
+func (s *Server) getPendingBlock(latest *machine.BlockInfo, pending []batcher.PendingTx, timestamp uint64, includeTxData bool) (*GetBlockResult, error) {
+	l2Block, _, err := s.srv.GetMachineBlockResults(latest)
+	if err != nil || l2Block == nil {
+		return nil, err
+	}
+
+	txResults := make([]*TransactionResult, 0, len(pending))
+	txHashes := make([]hexutil.Bytes, 0, len(pending))
+	for _, p := range pending {
+		txResults = append(txResults, newPendingTransactionResult(p.Sender, p.Tx))
+		txHashes = append(txHashes, p.Tx.Hash().Bytes())
+	}
+
+	header := types.CopyHeader(latest.Header)
+	header.ParentHash = latest.Header.Hash()
+	header.Number = new(big.Int).Add(latest.Header.Number, big.NewInt(1))
+	header.Time = timestamp
+	header.GasUsed = 0
+	header.Bloom = types.Bloom{}
+
+	var transactions interface{} = txHashes
+	if includeTxData {
+		transactions = txResults
+	}
+	return makeBlockResult(l2Block, header, transactions), nil
+}
+
 func makeBlockResult(blockLog *evm.BlockInfo, header *types.Header, transactions interface{}) *GetBlockResult {
 	size := uint64(0)
 	uncles := make([]hexutil.Bytes, 0)
@@ -90,6 +90,11 @@ func (c *EthClient) SuggestGasPrice(_ context.Context) (*big.Int, error) {
 	return (*big.Int)(gasPriceRaw), err
 }
 
+// SuggestGasTipCap returns 0 since L2 transactions don't pay priority fees
+func (c *EthClient) SuggestGasTipCap(_ context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
 func (c *EthClient) ChainID(_ context.Context) (*big.Int, error) {
 	return c.srv.srv.ChainId(), nil
 }
@@ -163,6 +168,25 @@ func (c *EthClient) TransactionByHash(_ context.Context, txHash common.Hash) (*t
 	return tx.Tx, false, nil
 }
 
+func (c *EthClient) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	blockNum := rpc.LatestBlockNumber
+	if number != nil {
+		blockNum = rpc.BlockNumber(number.Int64())
+	}
+	height, err := c.srv.srv.BlockNum(&blockNum)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.srv.srv.BlockInfoByNumber(height)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ethereum.NotFound
+	}
+	return info.Header, nil
+}
+
 func (c *EthClient) BlockByHash(_ context.Context, hash common.Hash) (*types.Block, error) {
 	info, err := c.srv.srv.BlockInfoByHash(arbcommon.NewHashFromEth(hash))
 	if err != nil || info == nil {
@@ -4,6 +4,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type GetBlockResult struct {
@@ -100,3 +101,30 @@ type TransactionResult struct {
 	ArbSubType      *hexutil.Uint64 `json:"arbSubType"`
 	L1BlockNumber   *hexutil.Big    `json:"l1BlockNumber"`
 }
+
+type TraceConfig struct {
+	Tracer *string `json:"tracer"`
+}
+
+// CallFrameResult matches the output format of geth's callTracer
+type CallFrameResult struct {
+	Type    string             `json:"type"`
+	From    common.Address     `json:"from"`
+	To      *common.Address    `json:"to,omitempty"`
+	Value   *hexutil.Big       `json:"value,omitempty"`
+	Gas     *hexutil.Big       `json:"gas"`
+	GasUsed *hexutil.Big       `json:"gasUsed,omitempty"`
+	Input   hexutil.Bytes      `json:"input"`
+	Output  hexutil.Bytes      `json:"output,omitempty"`
+	Error   string             `json:"error,omitempty"`
+	Calls   []*CallFrameResult `json:"calls,omitempty"`
+}
+
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber  `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber  `json:"toBlock"`
+	FromAddress []*common.Address `json:"fromAddress"`
+	ToAddress   []*common.Address `json:"toAddress"`
+	After       *hexutil.Uint64   `json:"after"`
+	Count       *hexutil.Uint64   `json:"count"`
+}
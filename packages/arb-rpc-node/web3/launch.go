@@ -40,7 +40,13 @@ func GenerateWeb3Server(server *aggregator.Server, privateKeys []*ecdsa.PrivateK
 		return nil, err
 	}
 
-	if err := s.RegisterName("eth", NewAccounts(ethServer, privateKeys, metricsConfig)); err != nil {
+	accounts := NewAccounts(ethServer, privateKeys, metricsConfig)
+	for _, val := range plugins {
+		if impersonator, ok := val.(Impersonator); ok {
+			accounts.impersonator = impersonator
+		}
+	}
+	if err := s.RegisterName("eth", accounts); err != nil {
 		return nil, err
 	}
 
@@ -48,6 +54,10 @@ func GenerateWeb3Server(server *aggregator.Server, privateKeys []*ecdsa.PrivateK
 		return nil, err
 	}
 
+	if err := s.RegisterName("debug", NewDebug(ethServer)); err != nil {
+		return nil, err
+	}
+
 	if err := s.RegisterName("personal", NewPersonalAccounts(privateKeys, metricsConfig)); err != nil {
 		return nil, err
 	}
@@ -61,6 +71,12 @@ func GenerateWeb3Server(server *aggregator.Server, privateKeys []*ecdsa.PrivateK
 		return nil, err
 	}
 
+	if pool := server.TxPool(); pool != nil {
+		if err := s.RegisterName("txpool", NewTxPool(pool, metricsConfig)); err != nil {
+			return nil, err
+		}
+	}
+
 	for name, val := range plugins {
 		if err := s.RegisterName(name, val); err != nil {
 			return nil, err
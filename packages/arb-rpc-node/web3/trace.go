@@ -0,0 +1,115 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web3
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/aggregator"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/traceindex"
+)
+
+type Trace struct {
+	srv     *aggregator.Server
+	index   *traceindex.Index
+	counter *prometheus.CounterVec
+}
+
+func NewTrace(srv *aggregator.Server, index *traceindex.Index, metricsConfig *metrics.MetricsConfig) *Trace {
+	return &Trace{srv: srv, index: index, counter: metricsConfig.MethodCallCounter}
+}
+
+// Block returns the traces of all transactions in the given block or null if
+// the block hasn't been indexed yet
+func (t *Trace) Block(blockNum rpc.BlockNumber) ([]*traceindex.Trace, error) {
+	height, err := t.srv.BlockNum(&blockNum)
+	if err != nil {
+		t.counter.WithLabelValues("trace_block", "false").Inc()
+		return nil, err
+	}
+	traces, err := t.index.BlockTraces(height)
+	if err != nil {
+		t.counter.WithLabelValues("trace_block", "false").Inc()
+		return nil, err
+	}
+	t.counter.WithLabelValues("trace_block", "true").Inc()
+	return traces, nil
+}
+
+// Transaction returns the traces of the given transaction or null if the
+// transaction hasn't been indexed yet
+func (t *Trace) Transaction(txHash common.Hash) ([]*traceindex.Trace, error) {
+	traces, err := t.index.TransactionTraces(txHash)
+	if err != nil {
+		t.counter.WithLabelValues("trace_transaction", "false").Inc()
+		return nil, err
+	}
+	t.counter.WithLabelValues("trace_transaction", "true").Inc()
+	return traces, nil
+}
+
+// Filter returns the traces matching the given block range and addresses
+func (t *Trace) Filter(args *TraceFilterArgs) ([]*traceindex.Trace, error) {
+	traces, err := t.filter(args)
+	if err != nil {
+		t.counter.WithLabelValues("trace_filter", "false").Inc()
+		return nil, err
+	}
+	t.counter.WithLabelValues("trace_filter", "true").Inc()
+	return traces, nil
+}
+
+func (t *Trace) filter(args *TraceFilterArgs) ([]*traceindex.Trace, error) {
+	filter := &traceindex.Filter{}
+	if args.FromBlock != nil {
+		fromBlock, err := t.srv.BlockNum(args.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		filter.FromBlock = fromBlock
+	}
+	toBlock := rpc.LatestBlockNumber
+	if args.ToBlock != nil {
+		toBlock = *args.ToBlock
+	}
+	height, err := t.srv.BlockNum(&toBlock)
+	if err != nil {
+		return nil, err
+	}
+	filter.ToBlock = height
+	for _, address := range args.FromAddress {
+		if address != nil {
+			filter.FromAddress = append(filter.FromAddress, *address)
+		}
+	}
+	for _, address := range args.ToAddress {
+		if address != nil {
+			filter.ToAddress = append(filter.ToAddress, *address)
+		}
+	}
+	if args.After != nil {
+		filter.After = uint64(*args.After)
+	}
+	if args.Count != nil {
+		count := uint64(*args.Count)
+		filter.Count = &count
+	}
+	return t.index.Filter(filter)
+}
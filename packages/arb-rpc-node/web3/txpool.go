@@ -0,0 +1,125 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web3
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-node-core/metrics"
+	"github.com/offchainlabs/arbitrum/packages/arb-rpc-node/batcher"
+)
+
+type TxPool struct {
+	pool    batcher.TxPool
+	counter *prometheus.CounterVec
+}
+
+func NewTxPool(pool batcher.TxPool, metricsConfig *metrics.MetricsConfig) *TxPool {
+	return &TxPool{pool: pool, counter: metricsConfig.MethodCallCounter}
+}
+
+// Content returns the transactions waiting to be batched, split into those
+// that can be included next and those waiting on a nonce gap
+func (t *TxPool) Content() map[string]map[string]map[string]*TransactionResult {
+	t.counter.WithLabelValues("txpool_content", "true").Inc()
+	pending, queued := t.pool.TxPoolContent()
+	return map[string]map[string]map[string]*TransactionResult{
+		"pending": poolContent(pending),
+		"queued":  poolContent(queued),
+	}
+}
+
+// Status returns the number of pending and queued transactions
+func (t *TxPool) Status() map[string]hexutil.Uint {
+	t.counter.WithLabelValues("txpool_status", "true").Inc()
+	pending, queued := t.pool.TxPoolContent()
+	return map[string]hexutil.Uint{
+		"pending": hexutil.Uint(poolCount(pending)),
+		"queued":  hexutil.Uint(poolCount(queued)),
+	}
+}
+
+// Inspect returns a textual summary of each transaction in the pool
+func (t *TxPool) Inspect() map[string]map[string]map[string]string {
+	t.counter.WithLabelValues("txpool_inspect", "true").Inc()
+	pending, queued := t.pool.TxPoolContent()
+	return map[string]map[string]map[string]string{
+		"pending": poolSummary(pending),
+		"queued":  poolSummary(queued),
+	}
+}
+
+func poolContent(txes map[common.Address][]*types.Transaction) map[string]map[string]*TransactionResult {
+	content := make(map[string]map[string]*TransactionResult)
+	for account, accountTxes := range txes {
+		dump := make(map[string]*TransactionResult)
+		for _, tx := range accountTxes {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = newPendingTransactionResult(account, tx)
+		}
+		content[account.Hex()] = dump
+	}
+	return content
+}
+
+func poolSummary(txes map[common.Address][]*types.Transaction) map[string]map[string]string {
+	content := make(map[string]map[string]string)
+	for account, accountTxes := range txes {
+		dump := make(map[string]string)
+		for _, tx := range accountTxes {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = inspectTransaction(tx)
+		}
+		content[account.Hex()] = dump
+	}
+	return content
+}
+
+func poolCount(txes map[common.Address][]*types.Transaction) int {
+	count := 0
+	for _, accountTxes := range txes {
+		count += len(accountTxes)
+	}
+	return count
+}
+
+func newPendingTransactionResult(from common.Address, tx *types.Transaction) *TransactionResult {
+	vVal, rVal, sVal := tx.RawSignatureValues()
+	return &TransactionResult{
+		From:     from,
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Hash:     tx.Hash(),
+		Input:    tx.Data(),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		To:       tx.To(),
+		Value:    (*hexutil.Big)(tx.Value()),
+		V:        (*hexutil.Big)(vVal),
+		R:        (*hexutil.Big)(rVal),
+		S:        (*hexutil.Big)(sVal),
+	}
+}
+
+func inspectTransaction(tx *types.Transaction) string {
+	if tx.To() == nil {
+		return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei", tx.Value(), tx.Gas(), tx.GasPrice())
+	}
+	return fmt.Sprintf("%s: %v wei + %v gas × %v wei", tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice())
+}
@@ -19,9 +19,11 @@ package broadcastclient
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"net"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +32,7 @@ import (
 
 	"github.com/gobwas/ws"
 	"github.com/gobwas/ws/wsutil"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/rs/zerolog/log"
 
@@ -37,8 +40,21 @@ import (
 	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
 )
 
+var (
+	InvalidSignatureCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "arbitrum",
+		Subsystem: "feed",
+		Name:      "invalid_signatures",
+		Help:      "Number of feed messages rejected because they were not signed by the sequencer",
+	})
+)
+
 type BroadcastClient struct {
-	websocketUrl    string
+	websocketUrl     string
+	sequencerAddress *common.Address
+
+	seqNumMutex *sync.Mutex
+	// Sequence number of the last message received, nil if unknown
 	lastInboxSeqNum *big.Int
 
 	connMutex *sync.Mutex
@@ -55,20 +71,26 @@ type BroadcastClient struct {
 
 var logger = log.With().Caller().Str("component", "broadcaster").Logger()
 
-func NewBroadcastClient(websocketUrl string, lastInboxSeqNum *big.Int, idleTimeout time.Duration) *BroadcastClient {
+// NewBroadcastClient creates a client for the feed at websocketUrl. If
+// sequencerAddress is not nil, every received message must carry a valid
+// signature from that address, otherwise the connection is dropped.
+// If lastInboxSeqNum is not nil, the feed is asked to replay every message
+// after it, and after reconnecting the feed is asked to replay every message
+// after the last one received.
+func NewBroadcastClient(websocketUrl string, lastInboxSeqNum *big.Int, idleTimeout time.Duration, sequencerAddress *common.Address) *BroadcastClient {
 	var seqNum *big.Int
-	if lastInboxSeqNum == nil {
-		seqNum = big.NewInt(0)
-	} else {
-		seqNum = lastInboxSeqNum
+	if lastInboxSeqNum != nil {
+		seqNum = new(big.Int).Set(lastInboxSeqNum)
 	}
 
 	return &BroadcastClient{
-		websocketUrl:    websocketUrl,
-		lastInboxSeqNum: seqNum,
-		connMutex:       &sync.Mutex{},
-		retryMutex:      &sync.Mutex{},
-		idleTimeout:     idleTimeout,
+		websocketUrl:     websocketUrl,
+		lastInboxSeqNum:  seqNum,
+		sequencerAddress: sequencerAddress,
+		seqNumMutex:      &sync.Mutex{},
+		connMutex:        &sync.Mutex{},
+		retryMutex:       &sync.Mutex{},
+		idleTimeout:      idleTimeout,
 	}
 }
 
@@ -100,12 +122,23 @@ func (bc *BroadcastClient) connect(ctx context.Context, messageReceiver chan bro
 	timeoutDialer := ws.Dialer{
 		Timeout: 10 * time.Second,
 	}
+	if seqNum := bc.LastInboxSeqNum(); seqNum != nil {
+		logger.Info().Str("seqnum", seqNum.String()).Msg("requesting feed replay")
+		timeoutDialer.Header = ws.HandshakeHeaderHTTP(http.Header{
+			broadcaster.LastSequenceNumberHeader: []string{seqNum.String()},
+		})
+	}
 
-	conn, _, _, err := timeoutDialer.Dial(ctx, bc.websocketUrl)
+	conn, br, _, err := timeoutDialer.Dial(ctx, bc.websocketUrl)
 	if err != nil {
 		logger.Warn().Err(err).Msg("broadcast client unable to connect")
 		return nil, errors.Wrap(err, "broadcast client unable to connect")
 	}
+	if br != nil {
+		// The server may send messages immediately after the handshake, in
+		// which case they were read into br along with the response
+		conn = bufferedConn{Conn: conn, reader: br}
+	}
 
 	bc.connMutex.Lock()
 	bc.conn = conn
@@ -157,8 +190,21 @@ func (bc *BroadcastClient) startBackgroundReader(ctx context.Context, messageRec
 				}
 
 				if res.Version == 1 {
+					if res.ReplayUnavailable {
+						logger.Warn().Str("feed", bc.websocketUrl).Msg("feed no longer has the requested messages, missing messages will be read from L1")
+					}
+
+					if err := bc.verifySignatures(res.Messages); err != nil {
+						InvalidSignatureCounter.Inc()
+						logger.Error().Err(err).Str("feed", bc.websocketUrl).Msg("dropping feed connection after receiving invalid message")
+						_ = bc.conn.Close()
+						bc.RetryConnect(ctx, messageReceiver)
+						continue
+					}
+
 					for _, message := range res.Messages {
 						messageReceiver <- *message
+						bc.setLastInboxSeqNum(message.FeedItem.BatchItem.LastSeqNum)
 					}
 
 					if res.ConfirmedAccumulator.IsConfirmed && bc.ConfirmedAccumulatorListener != nil {
@@ -170,6 +216,49 @@ func (bc *BroadcastClient) startBackgroundReader(ctx context.Context, messageRec
 	}()
 }
 
+// LastInboxSeqNum returns the sequence number of the last message received,
+// or nil if it isn't known
+func (bc *BroadcastClient) LastInboxSeqNum() *big.Int {
+	bc.seqNumMutex.Lock()
+	defer bc.seqNumMutex.Unlock()
+	if bc.lastInboxSeqNum == nil {
+		return nil
+	}
+	return new(big.Int).Set(bc.lastInboxSeqNum)
+}
+
+func (bc *BroadcastClient) setLastInboxSeqNum(seqNum *big.Int) {
+	if seqNum == nil {
+		return
+	}
+	bc.seqNumMutex.Lock()
+	defer bc.seqNumMutex.Unlock()
+	bc.lastInboxSeqNum = new(big.Int).Set(seqNum)
+}
+
+func (bc *BroadcastClient) verifySignatures(messages []*broadcaster.BroadcastFeedMessage) error {
+	if bc.sequencerAddress == nil {
+		return nil
+	}
+
+	for _, message := range messages {
+		signer, err := message.RecoverSigner()
+		if err != nil {
+			return errors.Wrapf(err, "unable to recover signer of message with accumulator %v", message.FeedItem.BatchItem.Accumulator)
+		}
+		if signer != *bc.sequencerAddress {
+			return errors.Errorf(
+				"message with accumulator %v signed by %v instead of sequencer %v",
+				message.FeedItem.BatchItem.Accumulator,
+				signer,
+				*bc.sequencerAddress,
+			)
+		}
+	}
+
+	return nil
+}
+
 func (bc *BroadcastClient) readData(ctx context.Context, state ws.State) ([]byte, ws.OpCode, error) {
 	controlHandler := wsutil.ControlFrameHandler(bc.conn, state)
 	reader := wsutil.Reader{
@@ -261,6 +350,17 @@ func (bc *BroadcastClient) RetryConnect(ctx context.Context, messageReceiver cha
 	}
 }
 
+// bufferedConn reads any data buffered during the handshake before reading
+// from the connection itself
+type bufferedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
 func (bc *BroadcastClient) Close() {
 	logger.Debug().Msg("closing broadcaster client connection")
 	bc.shuttingDown = true
@@ -7,7 +7,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
+
 	"github.com/offchainlabs/arbitrum/packages/arb-util/broadcaster"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
 )
 
 func TestReceiveMessages(t *testing.T) {
@@ -56,7 +59,7 @@ func TestReceiveMessages(t *testing.T) {
 }
 
 func startMakeBroadcastClient(ctx context.Context, t *testing.T, index int, expectedCount int, wg *sync.WaitGroup) {
-	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9742/", nil, 20*time.Second)
+	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9742/", nil, 20*time.Second, nil)
 	messageCount := 0
 
 	// connect returns
@@ -108,7 +111,7 @@ func TestServerClientDisconnect(t *testing.T) {
 	}
 	defer b.Stop()
 
-	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9743/", nil, 20*time.Second)
+	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9743/", nil, 20*time.Second, nil)
 
 	client, err := broadcastClient.Connect(ctx)
 	if err != nil {
@@ -164,7 +167,7 @@ func TestBroadcastClientReconnectsOnServerDisconnect(t *testing.T) {
 	}
 	defer b1.Stop()
 
-	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9743/", nil, 2*time.Second)
+	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9743/", nil, 2*time.Second, nil)
 
 	// connect returns
 	_, err = broadcastClient.Connect(ctx)
@@ -260,7 +263,7 @@ func TestBroadcasterSendsCachedMessagesOnClientConnect(t *testing.T) {
 }
 
 func connectAndGetCachedMessages(ctx context.Context, t *testing.T, clientIndex int, wg *sync.WaitGroup) {
-	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9842/", nil, 60*time.Second)
+	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9842/", nil, 60*time.Second, nil)
 	testClient, err := broadcastClient.Connect(ctx)
 	if err != nil {
 		t.Fatal(err)
@@ -291,3 +294,153 @@ func connectAndGetCachedMessages(ctx context.Context, t *testing.T, clientIndex
 		}
 	}()
 }
+
+func TestBroadcastClientRejectsInvalidSignature(t *testing.T) {
+	ctx := context.Background()
+
+	settings := configuration.FeedOutput{
+		Addr:          "0.0.0.0",
+		IOTimeout:     2 * time.Second,
+		Port:          "9744",
+		Ping:          5 * time.Second,
+		ClientTimeout: 20 * time.Second,
+		Queue:         1,
+		Workers:       128,
+	}
+
+	b := broadcaster.NewBroadcaster(settings)
+
+	err := b.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	sequencerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequencerAddress := common.NewAddressFromEth(crypto.PubkeyToAddress(sequencerKey.PublicKey))
+
+	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9744/", nil, 20*time.Second, &sequencerAddress)
+	defer broadcastClient.Close()
+
+	client, err := broadcastClient.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newBroadcastMessage := broadcaster.SequencedMessages()
+
+	hash1, feedItem1, _ := newBroadcastMessage()
+	signature1, err := crypto.Sign(broadcaster.SignatureHash(feedItem1.BatchItem).Bytes(), sequencerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.BroadcastSingle(hash1, feedItem1.BatchItem, signature1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case receivedMsg := <-client:
+		if receivedMsg.FeedItem.BatchItem.Accumulator != feedItem1.BatchItem.Accumulator {
+			t.Fatal("received unexpected batch item")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not receive correctly signed batch item")
+	}
+
+	hash2, feedItem2, _ := newBroadcastMessage()
+	signature2, err := crypto.Sign(broadcaster.SignatureHash(feedItem2.BatchItem).Bytes(), otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.BroadcastSingle(hash2, feedItem2.BatchItem, signature2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case receivedMsg := <-client:
+		if receivedMsg.FeedItem.BatchItem.Accumulator == feedItem2.BatchItem.Accumulator {
+			t.Fatal("client accepted batch item with invalid signature")
+		}
+	case <-time.After(2 * time.Second):
+	}
+
+	if broadcastClient.GetRetryCount() <= 0 {
+		t.Error("client should have disconnected after invalid signature")
+	}
+}
+
+func TestBroadcastClientReplaysFromSequenceNumber(t *testing.T) {
+	ctx := context.Background()
+
+	settings := configuration.FeedOutput{
+		Addr:          "0.0.0.0",
+		IOTimeout:     2 * time.Second,
+		Port:          "9745",
+		Ping:          5 * time.Second,
+		ClientTimeout: 20 * time.Second,
+		Queue:         1,
+		Workers:       128,
+		Backlog: configuration.FeedBacklog{
+			Size: 100,
+		},
+	}
+
+	b := broadcaster.NewBroadcaster(settings)
+
+	err := b.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	newBroadcastMessage := broadcaster.SequencedMessages()
+	var feedItems []broadcaster.SequencerFeedItem
+	for i := 0; i < 10; i++ {
+		prevAcc, feedItem, _ := newBroadcastMessage()
+		if err := b.BroadcastSingle(prevAcc, feedItem.BatchItem, nil); err != nil {
+			t.Fatal(err)
+		}
+		feedItems = append(feedItems, feedItem)
+	}
+
+	// Confirmed messages are dropped from the cache but stay in the backlog
+	b.ConfirmedAccumulator(feedItems[7].BatchItem.Accumulator)
+	for b.MessageCacheCount() != 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	lastSeqNum := feedItems[3].BatchItem.LastSeqNum
+	broadcastClient := NewBroadcastClient("ws://127.0.0.1:9745/", lastSeqNum, 20*time.Second, nil)
+	defer broadcastClient.Close()
+
+	client, err := broadcastClient.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range feedItems[4:] {
+		select {
+		case receivedMsg := <-client:
+			if receivedMsg.FeedItem.BatchItem.Accumulator != expected.BatchItem.Accumulator {
+				t.Fatal("received unexpected batch item")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("client did not receive replayed batch item")
+		}
+	}
+
+	// Wait for the last message to be recorded after it was delivered
+	time.Sleep(100 * time.Millisecond)
+	if broadcastClient.LastInboxSeqNum().Cmp(feedItems[9].BatchItem.LastSeqNum) != 0 {
+		t.Error("client didn't track last sequence number", broadcastClient.LastInboxSeqNum())
+	}
+}
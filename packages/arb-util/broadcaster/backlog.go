@@ -0,0 +1,199 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package broadcaster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
+)
+
+type backlogEntry struct {
+	position    uint64
+	lastSeqNum  *big.Int
+	accumulator common.Hash
+}
+
+// backlog keeps the most recent feed messages, including ones which have
+// already been confirmed on L1, so that clients which reconnect can replay
+// the messages they missed. Messages are stored in the database keyed by
+// their position in the feed, and an index of them is kept in memory.
+type backlog struct {
+	db      ethdb.KeyValueStore
+	maxSize int
+	entries []backlogEntry
+}
+
+func openBacklog(settings configuration.FeedBacklog) (*backlog, error) {
+	var db ethdb.KeyValueStore
+	if len(settings.Path) == 0 {
+		db = memorydb.New()
+	} else {
+		var err error
+		db, err = rawdb.NewLevelDBDatabase(settings.Path, 16, 16, "feed_backlog", false)
+		if err != nil {
+			return nil, errors.Wrap(err, "error opening feed backlog")
+		}
+	}
+	return newBacklog(db, settings.Size)
+}
+
+func newBacklog(db ethdb.KeyValueStore, maxSize int) (*backlog, error) {
+	b := &backlog{db: db, maxSize: maxSize}
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		var msg BroadcastFeedMessage
+		if err := json.Unmarshal(it.Value(), &msg); err != nil {
+			return nil, errors.Wrap(err, "corrupt feed backlog entry")
+		}
+		b.entries = append(b.entries, backlogEntry{
+			position:    binary.BigEndian.Uint64(it.Key()),
+			lastSeqNum:  msg.FeedItem.BatchItem.LastSeqNum,
+			accumulator: msg.FeedItem.BatchItem.Accumulator,
+		})
+	}
+	if err := it.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return b, b.prune()
+}
+
+func backlogKey(position uint64) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], position)
+	return key[:]
+}
+
+func (b *backlog) close() error {
+	return b.db.Close()
+}
+
+// add appends messages to the backlog, dropping any existing messages which
+// they reorg out
+func (b *backlog) add(messages []*BroadcastFeedMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	prevAcc := messages[0].FeedItem.PrevAcc
+	i := len(b.entries) - 1
+	for ; i >= 0; i-- {
+		if b.entries[i].accumulator == prevAcc {
+			break
+		}
+	}
+	// If the previous message isn't in the backlog everything in it is either
+	// out of date or there's a gap, so either way it can't be replayed
+	if err := b.truncate(i + 1); err != nil {
+		return err
+	}
+
+	batch := b.db.NewBatch()
+	nextPosition := uint64(0)
+	if len(b.entries) > 0 {
+		nextPosition = b.entries[len(b.entries)-1].position + 1
+	}
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := batch.Put(backlogKey(nextPosition), data); err != nil {
+			return errors.WithStack(err)
+		}
+		b.entries = append(b.entries, backlogEntry{
+			position:    nextPosition,
+			lastSeqNum:  msg.FeedItem.BatchItem.LastSeqNum,
+			accumulator: msg.FeedItem.BatchItem.Accumulator,
+		})
+		nextPosition++
+	}
+	if err := batch.Write(); err != nil {
+		return errors.WithStack(err)
+	}
+	return b.prune()
+}
+
+// truncate removes all entries starting at index
+func (b *backlog) truncate(index int) error {
+	if index >= len(b.entries) {
+		return nil
+	}
+	batch := b.db.NewBatch()
+	for _, entry := range b.entries[index:] {
+		if err := batch.Delete(backlogKey(entry.position)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return errors.WithStack(err)
+	}
+	b.entries = b.entries[:index]
+	return nil
+}
+
+// prune removes the oldest entries until the backlog is within its size limit
+func (b *backlog) prune() error {
+	excess := len(b.entries) - b.maxSize
+	if excess <= 0 {
+		return nil
+	}
+	batch := b.db.NewBatch()
+	for _, entry := range b.entries[:excess] {
+		if err := batch.Delete(backlogKey(entry.position)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return errors.WithStack(err)
+	}
+	b.entries = append(b.entries[:0], b.entries[excess:]...)
+	return nil
+}
+
+// messagesAfter returns every message after the one containing lastSeqNum.
+// If the backlog doesn't go back far enough to contain everything after
+// lastSeqNum, false is returned.
+func (b *backlog) messagesAfter(lastSeqNum *big.Int) ([]*BroadcastFeedMessage, bool, error) {
+	if len(b.entries) == 0 || b.entries[0].lastSeqNum.Cmp(lastSeqNum) > 0 {
+		return nil, false, nil
+	}
+	start := sort.Search(len(b.entries), func(i int) bool {
+		return b.entries[i].lastSeqNum.Cmp(lastSeqNum) > 0
+	})
+	messages := make([]*BroadcastFeedMessage, 0, len(b.entries)-start)
+	for _, entry := range b.entries[start:] {
+		data, err := b.db.Get(backlogKey(entry.position))
+		if err != nil {
+			return nil, false, errors.Wrap(err, "error reading feed backlog")
+		}
+		var msg BroadcastFeedMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, false, errors.Wrap(err, "corrupt feed backlog entry")
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, true, nil
+}
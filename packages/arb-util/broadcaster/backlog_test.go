@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package broadcaster
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/inbox"
+)
+
+func backlogMessages(prevAcc common.Hash, firstSeqNum int64, count int) []*BroadcastFeedMessage {
+	messages := make([]*BroadcastFeedMessage, 0, count)
+	for i := 0; i < count; i++ {
+		item := inbox.SequencerBatchItem{
+			LastSeqNum:        big.NewInt(firstSeqNum + int64(i)),
+			Accumulator:       common.RandHash(),
+			TotalDelayedCount: big.NewInt(0),
+			SequencerMessage:  common.RandBytes(20),
+		}
+		messages = append(messages, &BroadcastFeedMessage{
+			FeedItem:  SequencerFeedItem{BatchItem: item, PrevAcc: prevAcc},
+			Signature: common.RandBytes(65),
+		})
+		prevAcc = item.Accumulator
+	}
+	return messages
+}
+
+func checkReplay(t *testing.T, b *backlog, after int64, expected []*BroadcastFeedMessage) {
+	t.Helper()
+	replay, available, err := b.messagesAfter(big.NewInt(after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !available {
+		t.Fatal("replay after", after, "should be available")
+	}
+	if len(replay) != len(expected) {
+		t.Fatal("expected", len(expected), "messages but got", len(replay))
+	}
+	for i := range replay {
+		if replay[i].FeedItem.BatchItem.Accumulator != expected[i].FeedItem.BatchItem.Accumulator {
+			t.Error("wrong message at index", i)
+		}
+	}
+}
+
+func TestBacklogReplay(t *testing.T) {
+	db := memorydb.New()
+	b, err := newBacklog(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := backlogMessages(common.RandHash(), 0, 15)
+	for _, msg := range messages {
+		if err := b.add([]*BroadcastFeedMessage{msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the last 10 messages are kept
+	checkReplay(t, b, 5, messages[6:])
+	checkReplay(t, b, 14, nil)
+	if _, available, err := b.messagesAfter(big.NewInt(4)); err != nil || available {
+		t.Error("replay from before the backlog shouldn't be available")
+	}
+
+	// Reorg out the last 5 messages
+	reorg := backlogMessages(messages[9].FeedItem.BatchItem.Accumulator, 10, 3)
+	if err := b.add(reorg); err != nil {
+		t.Fatal(err)
+	}
+	checkReplay(t, b, 8, append([]*BroadcastFeedMessage{messages[9]}, reorg...))
+
+	// Reloading from the database gives the same backlog
+	b, err = newBacklog(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReplay(t, b, 8, append([]*BroadcastFeedMessage{messages[9]}, reorg...))
+
+	// A message which doesn't follow anything in the backlog replaces it
+	unrelated := backlogMessages(common.RandHash(), 20, 1)
+	if err := b.add(unrelated); err != nil {
+		t.Fatal(err)
+	}
+	checkReplay(t, b, 20, nil)
+	if _, available, err := b.messagesAfter(big.NewInt(12)); err != nil || available {
+		t.Error("replay across an unrelated message shouldn't be available")
+	}
+}
@@ -19,13 +19,12 @@ package broadcaster
 import (
 	"context"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/configuration"
+	"math/big"
 	"net"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/offchainlabs/arbitrum/packages/arb-util/hashing"
-
 	"github.com/offchainlabs/arbitrum/packages/arb-util/inbox"
 
 	"github.com/gobwas/ws"
@@ -77,6 +76,13 @@ func (b *Broadcaster) Start(ctx context.Context) error {
 	// goroutine.
 	var pool = gopool.NewPool(b.settings.Workers, b.settings.Queue, 1)
 	var clientManager = NewClientManager(pool, b.poller, b.settings)
+	if b.settings.Backlog.Size > 0 {
+		clientManager.backlog, err = openBacklog(b.settings.Backlog)
+		if err != nil {
+			logger.Error().Err(err).Msg("unable to open feed backlog")
+			return err
+		}
+	}
 	clientManager.Start(ctx)
 
 	b.clientManager = clientManager // maintain the pointer in this instance... used for testing
@@ -91,7 +97,24 @@ func (b *Broadcaster) Start(ctx context.Context) error {
 		safeConn := deadliner{conn, b.settings.IOTimeout}
 
 		// Zero-copy upgrade to WebSocket connection.
-		hs, err := ws.Upgrade(safeConn)
+		var requestedSeqNum *big.Int
+		upgrader := ws.Upgrader{
+			OnHeader: func(key, value []byte) error {
+				if !strings.EqualFold(string(key), LastSequenceNumberHeader) {
+					return nil
+				}
+				seqNum, ok := new(big.Int).SetString(string(value), 10)
+				if !ok || seqNum.Sign() < 0 {
+					return ws.RejectConnectionError(
+						ws.RejectionStatus(400),
+						ws.RejectionReason("invalid "+LastSequenceNumberHeader+" header"),
+					)
+				}
+				requestedSeqNum = seqNum
+				return nil
+			},
+		}
+		hs, err := upgrader.Upgrade(safeConn)
 		if err != nil {
 			logger.Warn().Err(err).Str("connection_name", nameConn(safeConn)).Msg("upgrade error")
 			_ = safeConn.Close()
@@ -112,7 +135,7 @@ func (b *Broadcaster) Start(ctx context.Context) error {
 		}
 
 		// Register incoming client in clientManager.
-		client := clientManager.Register(safeConn, desc)
+		client := clientManager.Register(safeConn, desc, requestedSeqNum)
 
 		// Subscribe to events about conn.
 		err = b.poller.Start(desc, func(ev netpoll.Event) {
@@ -227,9 +250,11 @@ func (b *Broadcaster) BroadcastSingle(prevAcc common.Hash, batchItem inbox.Seque
 	return b.clientManager.Broadcast(prevAcc, batchItem, signature)
 }
 
+// Broadcast signs and sends the batch items. dataSigner must sign the data it's
+// given with the "\x19Ethereum Signed Message" prefix, as eth_sign does.
 func (b *Broadcaster) Broadcast(prevAcc common.Hash, batchItems []inbox.SequencerBatchItem, dataSigner func([]byte) ([]byte, error)) error {
 	for _, item := range batchItems {
-		signature, err := dataSigner(hashing.SoliditySHA3WithPrefix(hashing.Bytes32(item.Accumulator)).Bytes())
+		signature, err := dataSigner(SignatureData(item))
 		if err != nil {
 			return err
 		}
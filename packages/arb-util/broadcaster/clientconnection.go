@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"math/big"
 	"math/rand"
 	"net"
 	"strconv"
@@ -45,19 +46,24 @@ type ClientConnection struct {
 	name          string
 	clientManager *ClientManager
 
+	// Sequence number of the last message the client already has, or nil if
+	// it didn't request a replay
+	requestedSeqNum *big.Int
+
 	lastHeardUnix int64
 	cancelFunc    context.CancelFunc
 	out           chan []byte
 }
 
-func NewClientConnection(conn net.Conn, desc *netpoll.Desc, clientManager *ClientManager) *ClientConnection {
+func NewClientConnection(conn net.Conn, desc *netpoll.Desc, clientManager *ClientManager, requestedSeqNum *big.Int) *ClientConnection {
 	return &ClientConnection{
-		conn:          conn,
-		desc:          desc,
-		name:          conn.RemoteAddr().String() + strconv.Itoa(rand.Intn(10)),
-		clientManager: clientManager,
-		lastHeardUnix: time.Now().Unix(),
-		out:           make(chan []byte, MaxSendQueue),
+		conn:            conn,
+		desc:            desc,
+		name:            conn.RemoteAddr().String() + strconv.Itoa(rand.Intn(10)),
+		clientManager:   clientManager,
+		requestedSeqNum: requestedSeqNum,
+		lastHeardUnix:   time.Now().Unix(),
+		out:             make(chan []byte, MaxSendQueue),
 	}
 }
 
@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
+	"math/big"
 	"net"
 	"sync/atomic"
 	"time"
@@ -41,6 +42,7 @@ type ClientManager struct {
 	clientCount       int32
 	broadcastMessages []*BroadcastFeedMessage
 	cacheSize         int32
+	backlog           *backlog
 	pool              *gopool.Pool
 	poller            netpoll.Poller
 	broadcastChan     chan BroadcastMessage
@@ -66,13 +68,25 @@ func NewClientManager(pool *gopool.Pool, poller netpoll.Poller, settings configu
 
 func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *ClientConnection) error {
 	start := time.Now()
-	if len(cm.broadcastMessages) > 0 {
-		// send the newly connected client all the messages we've got...
-		bm := BroadcastMessage{
-			Version:  1,
-			Messages: cm.broadcastMessages,
+	// send the newly connected client all the messages we've got...
+	bm := BroadcastMessage{
+		Version:  1,
+		Messages: cm.broadcastMessages,
+	}
+	if clientConnection.requestedSeqNum != nil {
+		// ...unless it asked to replay from a particular point
+		replay, available, err := cm.replayAfter(clientConnection.requestedSeqNum)
+		if err != nil {
+			logger.Error().Err(err).Str("client", clientConnection.name).Msg("error reading feed backlog")
 		}
-
+		if available {
+			bm.Messages = replay
+		} else {
+			logger.Info().Str("client", clientConnection.name).Str("seqnum", clientConnection.requestedSeqNum.String()).Msg("requested replay no longer available")
+			bm.ReplayUnavailable = true
+		}
+	}
+	if len(bm.Messages) > 0 || bm.ReplayUnavailable {
 		err := clientConnection.write(bm)
 		if err != nil {
 			logger.Error().Err(err).Str("client", clientConnection.name).Str("elapsed", time.Since(start).String()).Msg("error sending client cached messages")
@@ -89,10 +103,20 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 	return nil
 }
 
-// Register registers new connection as a Client.
-func (cm *ClientManager) Register(conn net.Conn, desc *netpoll.Desc) *ClientConnection {
+// replayAfter returns the messages following lastSeqNum from the backlog and
+// whether they were all still available
+func (cm *ClientManager) replayAfter(lastSeqNum *big.Int) ([]*BroadcastFeedMessage, bool, error) {
+	if cm.backlog == nil {
+		return nil, false, nil
+	}
+	return cm.backlog.messagesAfter(lastSeqNum)
+}
+
+// Register registers new connection as a Client. If requestedSeqNum isn't nil
+// the client is sent every message after it rather than the current cache.
+func (cm *ClientManager) Register(conn net.Conn, desc *netpoll.Desc, requestedSeqNum *big.Int) *ClientConnection {
 	createClient := ClientConnectionAction{
-		NewClientConnection(conn, desc, cm),
+		NewClientConnection(conn, desc, cm, requestedSeqNum),
 		true,
 	}
 
@@ -224,6 +248,12 @@ func (cm *ClientManager) doBroadcast(bm *BroadcastMessage) error {
 				cm.broadcastMessages = append(cm.broadcastMessages[:0], bm.Messages...)
 			}
 		}
+
+		if cm.backlog != nil {
+			if err := cm.backlog.add(bm.Messages); err != nil {
+				logger.Error().Err(err).Msg("failed to add messages to feed backlog")
+			}
+		}
 	}
 
 	var buf bytes.Buffer
@@ -282,6 +312,15 @@ func (cm *ClientManager) verifyClients() {
 	}
 }
 
+func (cm *ClientManager) closeBacklog() {
+	if cm.backlog == nil {
+		return
+	}
+	if err := cm.backlog.close(); err != nil {
+		logger.Warn().Err(err).Msg("error closing feed backlog")
+	}
+}
+
 func (cm *ClientManager) Stop() {
 	cm.cancelFunc()
 }
@@ -293,6 +332,7 @@ func (cm *ClientManager) Start(parentCtx context.Context) {
 	go func() {
 		defer cancelFunc()
 		defer cm.removeAll()
+		defer cm.closeBacklog()
 
 		pingInterval := time.NewTicker(cm.settings.Ping)
 		defer pingInterval.Stop()
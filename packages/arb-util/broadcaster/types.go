@@ -17,10 +17,17 @@
 package broadcaster
 
 import (
+	"github.com/ethereum/go-ethereum/crypto"
+
 	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/hashing"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/inbox"
 )
 
+// LastSequenceNumberHeader is sent by clients when connecting to request that
+// every message after the given sequence number be replayed
+const LastSequenceNumberHeader = "Arbitrum-Last-Sequence-Number"
+
 type ConfirmedAccumulator struct {
 	IsConfirmed bool        `json:"isConfirmed"`
 	Accumulator common.Hash `json:"accumulator"`
@@ -31,6 +38,28 @@ type BroadcastFeedMessage struct {
 	Signature []byte            `json:"signature"`
 }
 
+// SignatureData returns the data that the sequencer signs as a message when
+// broadcasting the given batch item
+func SignatureData(item inbox.SequencerBatchItem) []byte {
+	return hashing.Bytes32(item.Accumulator)
+}
+
+// SignatureHash returns the hash that the sequencer signs when broadcasting
+// the given batch item
+func SignatureHash(item inbox.SequencerBatchItem) common.Hash {
+	return hashing.SoliditySHA3WithPrefix(SignatureData(item))
+}
+
+// RecoverSigner returns the address of the key that produced the message's
+// signature over its batch item
+func (m *BroadcastFeedMessage) RecoverSigner() (common.Address, error) {
+	pubkey, err := crypto.SigToPub(SignatureHash(m.FeedItem.BatchItem).Bytes(), m.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.NewAddressFromEth(crypto.PubkeyToAddress(*pubkey)), nil
+}
+
 type SequencerFeedItem struct {
 	BatchItem inbox.SequencerBatchItem `json:"batchItem"`
 	PrevAcc   common.Hash              `json:"prevAcc"`
@@ -40,4 +69,7 @@ type BroadcastMessage struct {
 	Version              int                     `json:"version"`
 	Messages             []*BroadcastFeedMessage `json:"messages"`
 	ConfirmedAccumulator ConfirmedAccumulator    `json:"confirmedAccumulator"`
+	// ReplayUnavailable is set on the first message sent to a client if the
+	// messages it requested are no longer in the backlog
+	ReplayUnavailable bool `json:"replayUnavailable,omitempty"`
 }
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/providers/confmap"
@@ -19,6 +20,7 @@ import (
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/posflag"
 	"github.com/mitchellh/mapstructure"
+	"github.com/offchainlabs/arbitrum/packages/arb-util/common"
 	"github.com/offchainlabs/arbitrum/packages/arb-util/ethutils"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -28,12 +30,32 @@ import (
 var logger = log.With().Caller().Stack().Str("component", "configuration").Logger()
 
 type FeedInput struct {
-	Timeout time.Duration `koanf:"timeout"`
-	URLs    []string      `koanf:"url"`
+	SequencerAddress string        `koanf:"sequencer-address"`
+	Timeout          time.Duration `koanf:"timeout"`
+	URLs             []string      `koanf:"url"`
+}
+
+// GetSequencerAddress returns the address that feed messages must be signed
+// by, or nil if no sequencer address was configured
+func (f *FeedInput) GetSequencerAddress() (*common.Address, error) {
+	if len(f.SequencerAddress) == 0 {
+		return nil, nil
+	}
+	if !ethcommon.IsHexAddress(f.SequencerAddress) {
+		return nil, fmt.Errorf("invalid feed sequencer address: %v", f.SequencerAddress)
+	}
+	address := common.HexToAddress(f.SequencerAddress)
+	return &address, nil
+}
+
+type FeedBacklog struct {
+	Path string `koanf:"path"`
+	Size int    `koanf:"size"`
 }
 
 type FeedOutput struct {
 	Addr          string        `koanf:"addr"`
+	Backlog       FeedBacklog   `koanf:"backlog"`
 	IOTimeout     time.Duration `koanf:"io-timeout"`
 	Port          string        `koanf:"port"`
 	Ping          time.Duration `koanf:"ping"`
@@ -42,22 +64,42 @@ type FeedOutput struct {
 	Workers       int           `koanf:"workers"`
 }
 
+type DynamicFees struct {
+	Blocks           uint64  `koanf:"blocks"`
+	Enable           bool    `koanf:"enable"`
+	MaxFee           float64 `koanf:"max-fee"`
+	MaxPriorityFee   float64 `koanf:"max-priority-fee"`
+	RewardPercentile float64 `koanf:"reward-percentile"`
+}
+
 type Feed struct {
 	Input  FeedInput  `koanf:"input"`
 	Output FeedOutput `koanf:"output"`
 }
 
 type Healthcheck struct {
-	Addr          string `koanf:"addr"`
-	Enable        bool   `koanf:"enable"`
-	L1Node        bool   `koanf:"l1-node"`
-	Metrics       bool   `koanf:"metrics"`
-	MetricsPrefix string `koanf:"metrics-prefix"`
-	Port          string `koanf:"port"`
-	Sequencer     bool   `koanf:"sequencer"`
+	Addr          string        `koanf:"addr"`
+	Enable        bool          `koanf:"enable"`
+	L1Node        bool          `koanf:"l1-node"`
+	L1Provider    string        `koanf:"l1-provider"`
+	L1MaxBlockAge time.Duration `koanf:"l1-max-block-age"`
+	L1MinPeers    int           `koanf:"l1-min-peers"`
+	Metrics       bool          `koanf:"metrics"`
+	MetricsPrefix string        `koanf:"metrics-prefix"`
+	Port          string        `koanf:"port"`
+	Sequencer     bool          `koanf:"sequencer"`
+}
+
+type InboxReader struct {
+	ConfirmationDepth int64  `koanf:"confirmation-depth"`
+	Finality          string `koanf:"finality"`
 }
 
 type Lockout struct {
+	Backend       string        `koanf:"backend"`
+	Etcd          string        `koanf:"etcd"`
+	HandoverRPC   bool          `koanf:"handover-rpc"`
+	HandoverTTL   time.Duration `koanf:"handover-ttl"`
 	Redis         string        `koanf:"redis"`
 	SelfRPCURL    string        `koanf:"self-rpc-url"`
 	Timeout       time.Duration `koanf:"timeout"`
@@ -69,6 +111,15 @@ type Aggregator struct {
 	InboxAddress string `koanf:"inbox-address"`
 	MaxBatchTime int64  `koanf:"max-batch-time"`
 	Stateful     bool   `koanf:"stateful"`
+	TxPool       TxPool `koanf:"txpool"`
+}
+
+type TxPool struct {
+	AccountLimit int    `koanf:"account-limit"`
+	GlobalLimit  int    `koanf:"global-limit"`
+	Journal      bool   `koanf:"journal"`
+	Lifetime     int64  `koanf:"lifetime"`
+	PriceBump    uint64 `koanf:"price-bump"`
 }
 
 type RPC struct {
@@ -82,6 +133,11 @@ type Sequencer struct {
 	Lockout                    Lockout `koanf:"lockout"`
 }
 
+type TraceFilter struct {
+	MaxBlockRange uint64 `koanf:"max-block-range"`
+	MaxResults    uint64 `koanf:"max-results"`
+}
+
 type WS struct {
 	Addr string `koanf:"addr"`
 	Port string `koanf:"port"`
@@ -92,10 +148,12 @@ type Node struct {
 	Forwarder  struct {
 		Target string `koanf:"target"`
 	} `koanf:"forwarder"`
-	RPC       RPC       `koanf:"rpc"`
-	Sequencer Sequencer `koanf:"sequencer"`
-	Type      string    `koanf:"type"`
-	WS        WS        `koanf:"ws"`
+	RPC         RPC         `koanf:"rpc"`
+	Sequencer   Sequencer   `koanf:"sequencer"`
+	TraceFilter TraceFilter `koanf:"trace-filter"`
+	TraceIndex  bool        `koanf:"trace-index"`
+	Type        string      `koanf:"type"`
+	WS          WS          `koanf:"ws"`
 }
 
 type Persistent struct {
@@ -103,6 +161,14 @@ type Persistent struct {
 	GlobalConfig string `koanf:"global-config"`
 }
 
+type Resubmit struct {
+	BlockDelay        uint64  `koanf:"block-delay"`
+	BumpPercent       int64   `koanf:"bump-percent"`
+	ConfirmationDepth uint64  `koanf:"confirmation-depth"`
+	Enable            bool    `koanf:"enable"`
+	MaxGasPrice       float64 `koanf:"max-gas-price"`
+}
+
 type Rollup struct {
 	Address   string `koanf:"address"`
 	ChainID   uint64 `koanf:"chain-id"`
@@ -113,14 +179,45 @@ type Rollup struct {
 	} `koanf:"machine"`
 }
 
+type Watchtower struct {
+	ExitCode   int           `koanf:"exit-code"`
+	Interval   time.Duration `koanf:"interval"`
+	LogFile    string        `koanf:"log-file"`
+	WebhookURL string        `koanf:"webhook-url"`
+}
+
+type ValidatorStake struct {
+	ColdAddress    string  `koanf:"cold-address"`
+	MaxCommitment  float64 `koanf:"max-commitment"`
+	TopUp          bool    `koanf:"top-up"`
+	WithdrawExcess bool    `koanf:"withdraw-excess"`
+}
+
+type ValidatorStatus struct {
+	Addr   string `koanf:"addr"`
+	Enable bool   `koanf:"enable"`
+	Port   string `koanf:"port"`
+}
+
 type Validator struct {
-	Strategy             string `koanf:"strategy"`
-	UtilsAddress         string `koanf:"utils-address"`
-	WalletFactoryAddress string `koanf:"wallet-factory-address"`
+	DryRun               bool            `koanf:"dry-run"`
+	Stake                ValidatorStake  `koanf:"stake"`
+	Status               ValidatorStatus `koanf:"status"`
+	Strategy             string          `koanf:"strategy"`
+	UtilsAddress         string          `koanf:"utils-address"`
+	WalletFactoryAddress string          `koanf:"wallet-factory-address"`
+	Watchtower           Watchtower      `koanf:"watchtower"`
+}
+
+type RemoteSigner struct {
+	Address string `koanf:"address"`
+	API     string `koanf:"api"`
+	URL     string `koanf:"url"`
 }
 
 type Wallet struct {
-	Password string `koanf:"password"`
+	Password     string       `koanf:"password"`
+	RemoteSigner RemoteSigner `koanf:"remote-signer"`
 }
 
 type Log struct {
@@ -137,8 +234,16 @@ type Config struct {
 	GasPrice           float64     `koanf:"gas-price"`
 	GasPriceUrl        string      `koanf:"gas-price-url"`
 	Healthcheck        Healthcheck `koanf:"healthcheck"`
+	InboxReader        InboxReader `koanf:"inbox-reader"`
 	L1                 struct {
-		URL string `koanf:"url"`
+		BackupURLs      []string      `koanf:"backup-urls"`
+		DynamicFees     DynamicFees   `koanf:"dynamic-fees"`
+		Quorum          int           `koanf:"quorum"`
+		RecheckInterval time.Duration `koanf:"recheck-interval"`
+		Resubmit        Resubmit      `koanf:"resubmit"`
+		Retries         int           `koanf:"retries"`
+		RetryDelay      time.Duration `koanf:"retry-delay"`
+		URL             string        `koanf:"url"`
 	} `koanf:"l1"`
 	Log           Log        `koanf:"log"`
 	Node          Node       `koanf:"node"`
@@ -158,7 +263,19 @@ func (c *Config) GetValidatorDatabasePath() string {
 	return path.Join(c.Persistent.Chain, "validator_db")
 }
 
-func ParseNode(ctx context.Context) (*Config, *Wallet, *ethutils.RPCEthClient, *big.Int, error) {
+func (c *Config) GetChallengeJournalPath() string {
+	return path.Join(c.Persistent.Chain, "challenge_journal")
+}
+
+func (c *Config) GetTxPoolJournalPath() string {
+	return path.Join(c.Persistent.Chain, "txpool.rlp")
+}
+
+func (c *Config) GetTraceIndexDatabasePath() string {
+	return path.Join(c.Persistent.Chain, "trace_index")
+}
+
+func ParseNode(ctx context.Context) (*Config, *Wallet, *ethutils.MultiEthClient, *big.Int, error) {
 	f := flag.NewFlagSet("", flag.ContinueOnError)
 
 	AddFeedOutputOptions(f)
@@ -166,13 +283,25 @@ func ParseNode(ctx context.Context) (*Config, *Wallet, *ethutils.RPCEthClient, *
 	f.String("node.aggregator.inbox-address", "", "address of the inbox contract")
 	f.Int("node.aggregator.max-batch-time", 10, "max-batch-time=NumSeconds")
 	f.Bool("node.aggregator.stateful", false, "enable pending state tracking")
+	f.Int("node.aggregator.txpool.account-limit", 64, "maximum number of queued transactions per account")
+	f.Int("node.aggregator.txpool.global-limit", 4096, "maximum number of queued transactions")
+	f.Bool("node.aggregator.txpool.journal", true, "persist queued transactions so that they survive a restart")
+	f.Int64("node.aggregator.txpool.lifetime", 3*60*60, "number of seconds a transaction can stay queued")
+	f.Uint64("node.aggregator.txpool.price-bump", 10, "percentage a replacement transaction must increase the gas price by")
 	f.String("node.forwarder.target", "", "url of another node to send transactions through")
 	f.String("node.rpc.addr", "0.0.0.0", "RPC address")
 	f.Int("node.rpc.port", 8547, "RPC port")
 	f.Int64("node.sequencer.create-batch-block-interval", 270, "block interval at which to create new batches")
 	f.Int64("node.sequencer.delayed-messages-target-delay", 12, "delay before sequencing delayed messages")
+	f.String("node.sequencer.lockout.backend", "redis", "store used to coordinate the sequencer lockout (redis, etcd or memory)")
+	f.String("node.sequencer.lockout.etcd", "", "comma separated sequencer lockout etcd endpoints")
+	f.Bool("node.sequencer.lockout.handover-rpc", false, "enable arbadmin_handoverSequencer, which shouldn't be exposed publicly")
+	f.Duration("node.sequencer.lockout.handover-ttl", time.Hour, "how long the target of a handover takes precedence over the sequencer priorities")
 	f.String("node.sequencer.lockout.redis", "", "sequencer lockout redis instance URL")
 	f.String("node.sequencer.lockout.self-rpc-url", "", "own RPC URL for other sequencers to failover to")
+	f.Uint64("node.trace-filter.max-block-range", 10000, "maximum number of blocks trace_filter can search, no limit if 0")
+	f.Uint64("node.trace-filter.max-results", 10000, "maximum number of traces trace_filter can return, no limit if 0")
+	f.Bool("node.trace-index", false, "index internal transactions to support trace_block and trace_filter")
 	f.String("node.type", "forwarder", "forwarder, aggregator or sequencer")
 	f.String("node.ws.addr", "0.0.0.0", "websocket address")
 	f.Int("node.ws.port", 8548, "websocket port")
@@ -180,19 +309,31 @@ func ParseNode(ctx context.Context) (*Config, *Wallet, *ethutils.RPCEthClient, *
 	return ParseNonRelay(ctx, f)
 }
 
-func ParseValidator(ctx context.Context) (*Config, *Wallet, *ethutils.RPCEthClient, *big.Int, error) {
+func ParseValidator(ctx context.Context) (*Config, *Wallet, *ethutils.MultiEthClient, *big.Int, error) {
 	f := flag.NewFlagSet("", flag.ContinueOnError)
 
 	AddFeedOutputOptions(f)
 
+	f.Bool("validator.dry-run", false, "log the transactions the validator would make instead of sending them")
+	f.String("validator.stake.cold-address", "", "address to send returned deposits and withdrawn stake to")
+	f.Float64("validator.stake.max-commitment", 0, "maximum amount in ETH the validator may have staked, no limit if 0")
+	f.Bool("validator.stake.top-up", false, "add to the stake when the required stake rises above it")
+	f.Bool("validator.stake.withdraw-excess", false, "reduce the stake to the required stake when it falls")
+	f.String("validator.status.addr", "localhost", "address to bind the validator status RPC to")
+	f.Bool("validator.status.enable", false, "serve the validator_status RPC and a JSON status page at /status")
+	f.Int("validator.status.port", 8550, "port to bind the validator status RPC to")
 	f.String("validator.strategy", "StakeLatest", "strategy for validator to use")
 	f.String("validator.utils-address", "", "strategy for validator to use")
 	f.String("validator.wallet-factory-address", "", "strategy for validator to use")
+	f.Int("validator.watchtower.exit-code", 0, "exit with this code when the watchtower raises an alert, disabled if 0")
+	f.Duration("validator.watchtower.interval", time.Minute, "how often the watchtower checks for new nodes")
+	f.String("validator.watchtower.log-file", "", "file to append watchtower alerts to as JSON lines")
+	f.String("validator.watchtower.webhook-url", "", "URL to POST watchtower alerts to as JSON")
 
 	return ParseNonRelay(ctx, f)
 }
 
-func ParseNonRelay(ctx context.Context, f *flag.FlagSet) (*Config, *Wallet, *ethutils.RPCEthClient, *big.Int, error) {
+func ParseNonRelay(ctx context.Context, f *flag.FlagSet) (*Config, *Wallet, *ethutils.MultiEthClient, *big.Int, error) {
 	f.String("bridge-utils-address", "", "bridgeutils contract address")
 
 	f.Float64("gas-price", 4.5, "gasprice=FloatInGwei")
@@ -202,6 +343,24 @@ func ParseNonRelay(ctx context.Context, f *flag.FlagSet) (*Config, *Wallet, *eth
 	f.Uint64("rollup.chain-id", 42161, "chain id of the arbitrum chain")
 	f.String("rollup.machine.filename", "", "file to load machine from")
 
+	f.Int64("inbox-reader.confirmation-depth", 0, "only deliver inbox messages from L1 blocks this far behind the head, reorgs are followed if 0")
+	f.String("inbox-reader.finality", "", "only deliver inbox messages from L1 blocks tagged safe or finalized, overrides confirmation-depth")
+
+	f.StringSlice("l1.backup-urls", []string{}, "layer 1 ethereum node RPC URLs to fail over to when l1.url is unavailable")
+	f.Uint64("l1.dynamic-fees.blocks", 10, "number of recent blocks to base the priority fee on")
+	f.Bool("l1.dynamic-fees.enable", false, "send EIP-1559 transactions with fees based on eth_feeHistory instead of using gas-price or gas-price-url")
+	f.Float64("l1.dynamic-fees.max-fee", 500, "maximum fee per gas=FloatInGwei")
+	f.Float64("l1.dynamic-fees.max-priority-fee", 5, "maximum priority fee per gas=FloatInGwei")
+	f.Float64("l1.dynamic-fees.reward-percentile", 50, "percentile of priority fees paid in recent blocks to use")
+	f.Int("l1.quorum", 1, "number of L1 nodes that must agree on logs, headers and calls before they're used")
+	f.Duration("l1.recheck-interval", 30*time.Second, "how long an L1 node that failed is only used as a last resort")
+	f.Uint64("l1.resubmit.block-delay", 5, "number of blocks to wait for a transaction to be included before bumping its gas price")
+	f.Int64("l1.resubmit.bump-percent", 20, "percentage to increase the gas price, or both fee caps of EIP-1559 transactions, by when resubmitting a transaction")
+	f.Uint64("l1.resubmit.confirmation-depth", 100, "number of blocks to remember which version of a resubmitted transaction was included")
+	f.Bool("l1.resubmit.enable", false, "resubmit transactions with a higher gas price if they aren't included")
+	f.Float64("l1.resubmit.max-gas-price", 500, "maximum gas price or max fee per gas to resubmit transactions with=FloatInGwei")
+	f.Int("l1.retries", 2, "number of times to retry an L1 request after all nodes have failed")
+	f.Duration("l1.retry-delay", time.Second, "delay between L1 request retries")
 	f.String("l1.url", "", "layer 1 ethereum node RPC URL")
 
 	f.String("persistent.global-config", ".arbitrum", "location global configuration is located")
@@ -210,6 +369,9 @@ func ParseNonRelay(ctx context.Context, f *flag.FlagSet) (*Config, *Wallet, *eth
 	f.Bool("wait-to-catch-up", false, "wait to catch up to the chain before opening the RPC")
 
 	f.String("wallet.password", "", "password for wallet")
+	f.String("wallet.remote-signer.address", "", "account to use from the remote signer, defaults to its first account")
+	f.String("wallet.remote-signer.api", "eth", "remote signer interface, eth for web3signer or account for clef")
+	f.String("wallet.remote-signer.url", "", "URL of a JSON-RPC signer to use instead of the local keystore")
 
 	k, err := beginCommonParse(f)
 	if err != nil {
@@ -221,9 +383,15 @@ func ParseNonRelay(ctx context.Context, f *flag.FlagSet) (*Config, *Wallet, *eth
 		return nil, nil, nil, nil, errors.New("required parameter --l1.url is missing")
 	}
 
-	l1Client, err := ethutils.NewRPCEthClient(l1URL)
+	l1URLs := append([]string{l1URL}, k.Strings("l1.backup-urls")...)
+	l1Client, err := ethutils.NewMultiEthClient(l1URLs, ethutils.MultiEthClientConfig{
+		Quorum:          k.Int("l1.quorum"),
+		Retries:         k.Int("l1.retries"),
+		RetryDelay:      k.Duration("l1.retry-delay"),
+		RecheckInterval: k.Duration("l1.recheck-interval"),
+	})
 	if err != nil {
-		return nil, nil, nil, nil, errors.Wrap(err, "error running NewRPCEthClient")
+		return nil, nil, nil, nil, errors.Wrap(err, "error running NewMultiEthClient")
 	}
 
 	var l1ChainId *big.Int
@@ -371,6 +539,8 @@ func ParseRelay() (*Config, error) {
 
 func AddFeedOutputOptions(f *flag.FlagSet) {
 	f.String("feed.output.addr", "0.0.0.0", "address to bind the relay feed output to")
+	f.String("feed.output.backlog.path", "", "directory to store the feed backlog in, kept in memory if empty")
+	f.Int("feed.output.backlog.size", 10000, "number of feed messages to keep for replaying to reconnecting clients")
 	f.Duration("feed.output.io-timeout", 5*time.Second, "duration to wait before timing out HTTP to WS upgrade")
 	f.Int("feed.output.port", 9642, "port to bind the relay feed output to")
 	f.Duration("feed.output.ping", 5*time.Second, "duration for ping interval")
@@ -385,12 +555,16 @@ func beginCommonParse(f *flag.FlagSet) (*koanf.Koanf, error) {
 
 	f.String("env-prefix", "", "environment variables with given prefix will be loaded as configuration values")
 
+	f.String("feed.input.sequencer-address", "", "if set, only accept feed messages signed by this sequencer address")
 	f.Duration("feed.input.timeout", 20*time.Second, "duration to wait before timing out connection to server")
 	f.StringSlice("feed.input.url", []string{}, "URL of sequencer feed source")
 
 	f.Bool("healthcheck.enable", false, "enable healthcheck endpoint")
 	f.Bool("healthcheck.sequencer", false, "enable checking the health of the sequencer")
 	f.Bool("healthcheck.l1-node", false, "enable checking the health of the L1 node")
+	f.String("healthcheck.l1-provider", "openethereum", "checks used for the L1 node health (standard or openethereum)")
+	f.Duration("healthcheck.l1-max-block-age", 5*time.Minute, "maximum age of the latest L1 block before the L1 node is considered unhealthy")
+	f.Int("healthcheck.l1-min-peers", 1, "minimum number of peers the L1 node must have, unchecked if 0")
 	f.Bool("healthcheck.metrics", false, "enable prometheus endpoint")
 	f.String("healthcheck.metrics-prefix", "", "prepend the specified prefix to the exported metrics names")
 	f.String("healthcheck.addr", "", "address to bind the healthcheck endpoint to")
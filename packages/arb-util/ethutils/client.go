@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
 )
 
 type ReceiptFetcher interface {
@@ -43,7 +44,6 @@ type EthClient interface {
 	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
 	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
 	BlockInfoByNumber(ctx context.Context, number *big.Int) (*BlockInfo, error)
-	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
 	TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error)
 	PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error)
@@ -51,6 +51,12 @@ type EthClient interface {
 	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
 }
 
+// BlockTagFetcher is implemented by clients that can look up blocks by tags
+// such as "safe" or "finalized"
+type BlockTagFetcher interface {
+	BlockInfoByTag(ctx context.Context, tag string) (*BlockInfo, error)
+}
+
 type RPCEthClient struct {
 	*ethclient.Client
 	rpc *rpc.Client
@@ -81,14 +87,15 @@ func NewRPCEthClient(url string) (*RPCEthClient, error) {
 }
 
 func (r *RPCEthClient) BlockInfoByNumber(ctx context.Context, number *big.Int) (*BlockInfo, error) {
-	var raw json.RawMessage
-	var numParam string
-	if number != nil {
-		numParam = hexutil.EncodeBig(number)
-	} else {
-		numParam = "latest"
+	if number == nil {
+		return r.BlockInfoByTag(ctx, "latest")
 	}
-	if err := r.rpc.CallContext(ctx, &raw, "eth_getBlockByNumber", numParam, false); err != nil {
+	return r.BlockInfoByTag(ctx, hexutil.EncodeBig(number))
+}
+
+func (r *RPCEthClient) BlockInfoByTag(ctx context.Context, tag string) (*BlockInfo, error) {
+	var raw json.RawMessage
+	if err := r.rpc.CallContext(ctx, &raw, "eth_getBlockByNumber", tag, false); err != nil {
 		return nil, err
 	}
 	if len(raw) == 0 {
@@ -117,3 +124,25 @@ func (r *SimulatedEthClient) BlockInfoByNumber(ctx context.Context, number *big.
 		Number:     (*hexutil.Big)(header.Number),
 	}, nil
 }
+
+type FeeHistory struct {
+	OldestBlock   *hexutil.Big     `json:"oldestBlock"`
+	BaseFeePerGas []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64        `json:"gasUsedRatio"`
+	Reward        [][]*hexutil.Big `json:"reward"`
+}
+
+type FeeHistoryFetcher interface {
+	FeeHistory(ctx context.Context, blockCount uint64, rewardPercentiles []float64) (*FeeHistory, error)
+}
+
+func (r *RPCEthClient) FeeHistory(ctx context.Context, blockCount uint64, rewardPercentiles []float64) (*FeeHistory, error) {
+	var history FeeHistory
+	if err := r.rpc.CallContext(ctx, &history, "eth_feeHistory", hexutil.Uint64(blockCount), "latest", rewardPercentiles); err != nil {
+		return nil, err
+	}
+	if len(history.BaseFeePerGas) == 0 {
+		return nil, errors.New("fee history didn't include base fees")
+	}
+	return &history, nil
+}
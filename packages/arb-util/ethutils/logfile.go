@@ -0,0 +1,182 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethutils
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+var errLogFileOnly = errors.New("only logs are available from a log file")
+
+// LogFileClient answers FilterLogs queries from logs exported to a file in
+// the eth_getLogs JSON format, so that tools which only read events can run
+// without an L1 connection. Every other method returns an error.
+type LogFileClient struct {
+	logs []types.Log
+}
+
+func NewLogFileClient(path string) (*LogFileClient, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading log file")
+	}
+	var logs []types.Log
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return nil, errors.Wrap(err, "error parsing log file")
+	}
+	return &LogFileClient{logs: logs}, nil
+}
+
+func logMatches(ethLog types.Log, query ethereum.FilterQuery) bool {
+	if query.BlockHash != nil {
+		if ethLog.BlockHash != *query.BlockHash {
+			return false
+		}
+	} else {
+		if query.FromBlock != nil && new(big.Int).SetUint64(ethLog.BlockNumber).Cmp(query.FromBlock) < 0 {
+			return false
+		}
+		if query.ToBlock != nil && new(big.Int).SetUint64(ethLog.BlockNumber).Cmp(query.ToBlock) > 0 {
+			return false
+		}
+	}
+	if len(query.Addresses) > 0 {
+		found := false
+		for _, address := range query.Addresses {
+			if ethLog.Address == address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(query.Topics) > len(ethLog.Topics) {
+		return false
+	}
+	for i, options := range query.Topics {
+		if len(options) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range options {
+			if ethLog.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *LogFileClient) FilterLogs(_ context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, ethLog := range c.logs {
+		if !ethLog.Removed && logMatches(ethLog, query) {
+			logs = append(logs, ethLog)
+		}
+	}
+	return logs, nil
+}
+
+func (c *LogFileClient) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) PendingCodeAt(context.Context, common.Address) ([]byte, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return 0, errLogFileOnly
+}
+
+func (c *LogFileClient) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 0, errLogFileOnly
+}
+
+func (c *LogFileClient) SendTransaction(context.Context, *types.Transaction) error {
+	return errLogFileOnly
+}
+
+func (c *LogFileClient) TransactionReceipt(context.Context, common.Hash) (*types.Receipt, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) HeaderByHash(context.Context, common.Hash) (*types.Header, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) BlockByHash(context.Context, common.Hash) (*types.Block, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) BlockInfoByNumber(context.Context, *big.Int) (*BlockInfo, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) TransactionByHash(context.Context, common.Hash) (*types.Transaction, bool, error) {
+	return nil, false, errLogFileOnly
+}
+
+func (c *LogFileClient) TransactionInBlock(context.Context, common.Hash, uint) (*types.Transaction, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) PendingCallContract(context.Context, ethereum.CallMsg) ([]byte, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) BalanceAt(context.Context, common.Address, *big.Int) (*big.Int, error) {
+	return nil, errLogFileOnly
+}
+
+func (c *LogFileClient) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	return 0, errLogFileOnly
+}
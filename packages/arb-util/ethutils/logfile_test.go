@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethutils
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestLogFileClient(t *testing.T) {
+	contract := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	other := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	eventA := common.HexToHash("0xaa")
+	eventB := common.HexToHash("0xbb")
+	indexed := common.HexToHash("0x01")
+
+	logs := []types.Log{
+		{Address: contract, Topics: []common.Hash{eventA, indexed}, BlockNumber: 5},
+		{Address: contract, Topics: []common.Hash{eventB}, BlockNumber: 7},
+		{Address: other, Topics: []common.Hash{eventA, indexed}, BlockNumber: 8},
+		{Address: contract, Topics: []common.Hash{eventA, common.HexToHash("0x02")}, BlockNumber: 9},
+		{Address: contract, Topics: []common.Hash{eventA, indexed}, BlockNumber: 10, Removed: true},
+	}
+	data, err := json.Marshal(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := ioutil.TempFile("", "logs*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewLogFileClient(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkBlocks := func(query ethereum.FilterQuery, expected ...uint64) {
+		t.Helper()
+		found, err := client.FilterLogs(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != len(expected) {
+			t.Fatalf("expected %v logs but found %v", len(expected), len(found))
+		}
+		for i, ethLog := range found {
+			if ethLog.BlockNumber != expected[i] {
+				t.Errorf("expected log from block %v but got %v", expected[i], ethLog.BlockNumber)
+			}
+		}
+	}
+
+	checkBlocks(ethereum.FilterQuery{}, 5, 7, 8, 9)
+	checkBlocks(ethereum.FilterQuery{Addresses: []common.Address{contract}}, 5, 7, 9)
+	checkBlocks(ethereum.FilterQuery{
+		Addresses: []common.Address{contract},
+		Topics:    [][]common.Hash{{eventA}},
+	}, 5, 9)
+	checkBlocks(ethereum.FilterQuery{
+		Topics: [][]common.Hash{{eventA, eventB}, {indexed}},
+	}, 5, 8)
+	checkBlocks(ethereum.FilterQuery{
+		Topics: [][]common.Hash{nil, {indexed}},
+	}, 5, 8)
+	checkBlocks(ethereum.FilterQuery{
+		FromBlock: big.NewInt(7),
+		ToBlock:   big.NewInt(8),
+	}, 7, 8)
+
+	if _, _, err := client.TransactionByHash(context.Background(), common.Hash{}); err == nil {
+		t.Error("log file client should only serve logs")
+	}
+}
@@ -0,0 +1,575 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethutils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var logger = log.With().Caller().Stack().Str("component", "ethutils").Logger()
+
+// JSON-RPC error code providers use when rate limiting requests
+const limitExceededCode = -32005
+
+type MultiEthClientConfig struct {
+	// Number of endpoints that must return the same logs, headers and call
+	// results before they're used. Quorum reads are disabled if this is 1.
+	Quorum int
+	// Number of times to retry a request after every endpoint has failed
+	Retries int
+	// Delay between retries
+	RetryDelay time.Duration
+	// How long an endpoint that failed is only used as a last resort
+	RecheckInterval time.Duration
+}
+
+type endpoint struct {
+	url         string
+	client      EthClient
+	failedUntil time.Time
+}
+
+// MultiEthClient spreads requests over several L1 endpoints. Requests go to
+// the first healthy endpoint in the configured order and fail over to the
+// others on connection errors. When a quorum is configured, log, header and
+// call reads are only answered once enough endpoints agree on the result, and
+// reads of the latest block are pinned to the newest block they all have.
+type MultiEthClient struct {
+	config MultiEthClientConfig
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+}
+
+func NewMultiEthClient(urls []string, config MultiEthClientConfig) (*MultiEthClient, error) {
+	clients := make([]EthClient, 0, len(urls))
+	for _, url := range urls {
+		client, err := NewRPCEthClient(url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error connecting to %v", url)
+		}
+		clients = append(clients, client)
+	}
+	return newMultiEthClient(urls, clients, config)
+}
+
+func newMultiEthClient(urls []string, clients []EthClient, config MultiEthClientConfig) (*MultiEthClient, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("no L1 endpoints given")
+	}
+	if config.Quorum < 1 {
+		config.Quorum = 1
+	}
+	if config.Quorum > len(clients) {
+		return nil, errors.Errorf("quorum of %v is larger than the %v L1 endpoints given", config.Quorum, len(clients))
+	}
+	endpoints := make([]*endpoint, 0, len(clients))
+	for i, client := range clients {
+		endpoints = append(endpoints, &endpoint{url: urls[i], client: client})
+	}
+	return &MultiEthClient{
+		config:    config,
+		endpoints: endpoints,
+	}, nil
+}
+
+// ordered returns the healthy endpoints in their configured order followed by
+// the ones that have recently failed
+func (m *MultiEthClient) ordered() []*endpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	healthy := make([]*endpoint, 0, len(m.endpoints))
+	var failed []*endpoint
+	for _, e := range m.endpoints {
+		if now.Before(e.failedUntil) {
+			failed = append(failed, e)
+		} else {
+			healthy = append(healthy, e)
+		}
+	}
+	return append(healthy, failed...)
+}
+
+func (m *MultiEthClient) markFailed(e *endpoint, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Now().After(e.failedUntil) {
+		logger.Warn().Err(err).Str("url", e.url).Msg("L1 endpoint failed")
+	}
+	e.failedUntil = time.Now().Add(m.config.RecheckInterval)
+}
+
+func (m *MultiEthClient) markSucceeded(e *endpoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !e.failedUntil.IsZero() {
+		logger.Info().Str("url", e.url).Msg("L1 endpoint recovered")
+		e.failedUntil = time.Time{}
+	}
+}
+
+// shouldFailover reports whether err came from the endpoint being unavailable
+// rather than from the request itself, so that another endpoint may succeed
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || err == ethereum.NotFound {
+		return false
+	}
+	if rpcErr, ok := err.(rpc.Error); ok {
+		return rpcErr.ErrorCode() == limitExceededCode
+	}
+	return true
+}
+
+// retry calls attempt until it succeeds or reports the error is final,
+// waiting between attempts
+func (m *MultiEthClient) retry(ctx context.Context, attempt func() (bool, error)) error {
+	retryable, err := attempt()
+	for i := 0; i < m.config.Retries && retryable; i++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(m.config.RetryDelay):
+		}
+		retryable, err = attempt()
+	}
+	return err
+}
+
+// call runs f against each endpoint in turn until one of them answers
+func (m *MultiEthClient) call(ctx context.Context, f func(EthClient) error) error {
+	return m.retry(ctx, func() (bool, error) {
+		var err error
+		for _, e := range m.ordered() {
+			err = f(e.client)
+			if !shouldFailover(ctx, err) {
+				if ctx.Err() == nil {
+					m.markSucceeded(e)
+				}
+				return false, err
+			}
+			m.markFailed(e, err)
+		}
+		return true, err
+	})
+}
+
+// quorumCall runs f against the endpoints until Quorum of them return results
+// with the same key and returns that result
+func (m *MultiEthClient) quorumCall(ctx context.Context, f func(EthClient) (interface{}, string, error)) (interface{}, error) {
+	if m.config.Quorum == 1 {
+		var result interface{}
+		err := m.call(ctx, func(client EthClient) error {
+			var err error
+			result, _, err = f(client)
+			return err
+		})
+		return result, err
+	}
+
+	var result interface{}
+	err := m.retry(ctx, func() (bool, error) {
+		votes := make(map[string]int)
+		var lastErr error
+		for _, e := range m.ordered() {
+			res, key, err := f(e.client)
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if err != nil {
+				if shouldFailover(ctx, err) {
+					m.markFailed(e, err)
+				}
+				lastErr = err
+				continue
+			}
+			m.markSucceeded(e)
+			if len(votes) > 0 && votes[key] == 0 {
+				logger.Warn().Str("url", e.url).Msg("L1 endpoint disagrees with other endpoints")
+			}
+			votes[key]++
+			if votes[key] >= m.config.Quorum {
+				result = res
+				return false, nil
+			}
+		}
+		if len(votes) == 0 {
+			return shouldFailover(ctx, lastErr), lastErr
+		}
+		return true, errors.Errorf("L1 endpoints didn't reach a quorum of %v", m.config.Quorum)
+	})
+	return result, err
+}
+
+// agreedBlockNumber returns the highest block number that Quorum endpoints
+// have all reached
+func (m *MultiEthClient) agreedBlockNumber(ctx context.Context) (*big.Int, error) {
+	var lowest *big.Int
+	err := m.retry(ctx, func() (bool, error) {
+		lowest = nil
+		responses := 0
+		var lastErr error
+		for _, e := range m.ordered() {
+			info, err := e.client.BlockInfoByNumber(ctx, nil)
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if err != nil {
+				if shouldFailover(ctx, err) {
+					m.markFailed(e, err)
+				}
+				lastErr = err
+				continue
+			}
+			m.markSucceeded(e)
+			number := info.Number.ToInt()
+			if lowest == nil || number.Cmp(lowest) < 0 {
+				lowest = number
+			}
+			responses++
+			if responses >= m.config.Quorum {
+				return false, nil
+			}
+		}
+		if lastErr == nil {
+			lastErr = errors.New("not enough L1 endpoints responded")
+		}
+		return true, lastErr
+	})
+	return lowest, err
+}
+
+func logsKey(logs []types.Log) string {
+	var key strings.Builder
+	for _, ethLog := range logs {
+		_, _ = fmt.Fprintf(&key, "%v:%v:%v:%v;", ethLog.BlockHash.Hex(), ethLog.TxHash.Hex(), ethLog.Index, ethLog.Removed)
+	}
+	return key.String()
+}
+
+func (m *MultiEthClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if m.config.Quorum > 1 && query.BlockHash == nil && query.ToBlock == nil {
+		latest, err := m.agreedBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if query.FromBlock != nil && query.FromBlock.Cmp(latest) > 0 {
+			return nil, nil
+		}
+		query.ToBlock = latest
+	}
+	result, err := m.quorumCall(ctx, func(client EthClient) (interface{}, string, error) {
+		logs, err := client.FilterLogs(ctx, query)
+		return logs, logsKey(logs), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]types.Log), nil
+}
+
+func (m *MultiEthClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if m.config.Quorum > 1 && number == nil {
+		var err error
+		number, err = m.agreedBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result, err := m.quorumCall(ctx, func(client EthClient) (interface{}, string, error) {
+		header, err := client.HeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, "", err
+		}
+		return header, header.Hash().Hex(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*types.Header), nil
+}
+
+func (m *MultiEthClient) BlockInfoByNumber(ctx context.Context, number *big.Int) (*BlockInfo, error) {
+	if m.config.Quorum > 1 && number == nil {
+		var err error
+		number, err = m.agreedBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result, err := m.quorumCall(ctx, func(client EthClient) (interface{}, string, error) {
+		info, err := client.BlockInfoByNumber(ctx, number)
+		if err != nil {
+			return nil, "", err
+		}
+		return info, info.Hash.Hex(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*BlockInfo), nil
+}
+
+func (m *MultiEthClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if m.config.Quorum > 1 && blockNumber == nil {
+		var err error
+		blockNumber, err = m.agreedBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result, err := m.quorumCall(ctx, func(client EthClient) (interface{}, string, error) {
+		output, err := client.CallContract(ctx, call, blockNumber)
+		return output, hexutil.Encode(output), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+func (m *MultiEthClient) BlockInfoByTag(ctx context.Context, tag string) (*BlockInfo, error) {
+	var info *BlockInfo
+	err := m.call(ctx, func(client EthClient) error {
+		fetcher, ok := client.(BlockTagFetcher)
+		if !ok {
+			return errors.New("L1 endpoint doesn't support fetching blocks by tag")
+		}
+		var err error
+		info, err = fetcher.BlockInfoByTag(ctx, tag)
+		return err
+	})
+	return info, err
+}
+
+type chainIDFetcher interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// ChainID returns the chain ID of the reachable endpoints, failing if they
+// aren't all on the same chain
+func (m *MultiEthClient) ChainID(ctx context.Context) (*big.Int, error) {
+	var chainID *big.Int
+	var lastErr error
+	for _, e := range m.endpoints {
+		fetcher, ok := e.client.(chainIDFetcher)
+		if !ok {
+			continue
+		}
+		id, err := fetcher.ChainID(ctx)
+		if err != nil {
+			m.markFailed(e, err)
+			lastErr = err
+			continue
+		}
+		if chainID != nil && chainID.Cmp(id) != 0 {
+			return nil, errors.Errorf("L1 endpoint %v is on chain %v instead of %v", e.url, id, chainID)
+		}
+		chainID = id
+	}
+	if chainID == nil {
+		if lastErr == nil {
+			lastErr = errors.New("no L1 endpoint supports fetching the chain ID")
+		}
+		return nil, lastErr
+	}
+	return chainID, nil
+}
+
+func (m *MultiEthClient) FeeHistory(ctx context.Context, blockCount uint64, rewardPercentiles []float64) (*FeeHistory, error) {
+	var history *FeeHistory
+	err := m.call(ctx, func(client EthClient) error {
+		fetcher, ok := client.(FeeHistoryFetcher)
+		if !ok {
+			return errors.New("L1 endpoint doesn't support fetching fee history")
+		}
+		var err error
+		history, err = fetcher.FeeHistory(ctx, blockCount, rewardPercentiles)
+		return err
+	})
+	return history, err
+}
+
+func (m *MultiEthClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	var sub ethereum.Subscription
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		sub, err = client.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
+
+func (m *MultiEthClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code []byte
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		code, err = client.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (m *MultiEthClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	var code []byte
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		code, err = client.PendingCodeAt(ctx, account)
+		return err
+	})
+	return code, err
+}
+
+func (m *MultiEthClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	var nonce uint64
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		nonce, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (m *MultiEthClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	var price *big.Int
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		price, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+func (m *MultiEthClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	var tip *big.Int
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		tip, err = client.SuggestGasTipCap(ctx)
+		return err
+	})
+	return tip, err
+}
+
+func (m *MultiEthClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	var gas uint64
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		gas, err = client.EstimateGas(ctx, call)
+		return err
+	})
+	return gas, err
+}
+
+func (m *MultiEthClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return m.call(ctx, func(client EthClient) error {
+		return client.SendTransaction(ctx, tx)
+	})
+}
+
+func (m *MultiEthClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		receipt, err = client.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+func (m *MultiEthClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	var header *types.Header
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		header, err = client.HeaderByHash(ctx, hash)
+		return err
+	})
+	return header, err
+}
+
+func (m *MultiEthClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	var block *types.Block
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		block, err = client.BlockByHash(ctx, hash)
+		return err
+	})
+	return block, err
+}
+
+func (m *MultiEthClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	var tx *types.Transaction
+	var isPending bool
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		tx, isPending, err = client.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (m *MultiEthClient) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		tx, err = client.TransactionInBlock(ctx, blockHash, index)
+		return err
+	})
+	return tx, err
+}
+
+func (m *MultiEthClient) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	var output []byte
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		output, err = client.PendingCallContract(ctx, call)
+		return err
+	})
+	return output, err
+}
+
+func (m *MultiEthClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance *big.Int
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		balance, err = client.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (m *MultiEthClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	var nonce uint64
+	err := m.call(ctx, func(client EthClient) error {
+		var err error
+		nonce, err = client.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
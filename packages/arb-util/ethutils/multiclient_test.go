@@ -0,0 +1,227 @@
+/*
+ * Copyright 2021, Offchain Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ethutils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+type testRPCError struct {
+	code int
+}
+
+func (e testRPCError) Error() string {
+	return "rpc error"
+}
+
+func (e testRPCError) ErrorCode() int {
+	return e.code
+}
+
+var errUnreachable = errors.New("connection refused")
+
+type testEthClient struct {
+	EthClient
+
+	// Errors to return before answering requests
+	errs  []error
+	calls int
+
+	head     int64
+	blockTag byte
+	logs     []types.Log
+}
+
+func (c *testEthClient) request() error {
+	c.calls++
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func (c *testEthClient) header(number *big.Int) *types.Header {
+	if number == nil {
+		number = big.NewInt(c.head)
+	}
+	return &types.Header{Number: number, Extra: []byte{c.blockTag}}
+}
+
+func (c *testEthClient) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if err := c.request(); err != nil {
+		return nil, err
+	}
+	if number != nil && number.Int64() > c.head {
+		return nil, ethereum.NotFound
+	}
+	return c.header(number), nil
+}
+
+func (c *testEthClient) BlockInfoByNumber(_ context.Context, number *big.Int) (*BlockInfo, error) {
+	if err := c.request(); err != nil {
+		return nil, err
+	}
+	header := c.header(number)
+	return &BlockInfo{Hash: header.Hash(), Number: (*hexutil.Big)(header.Number)}, nil
+}
+
+func (c *testEthClient) FilterLogs(_ context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if err := c.request(); err != nil {
+		return nil, err
+	}
+	var logs []types.Log
+	for _, ethLog := range c.logs {
+		if query.ToBlock == nil || ethLog.BlockNumber <= query.ToBlock.Uint64() {
+			logs = append(logs, ethLog)
+		}
+	}
+	return logs, nil
+}
+
+func newTestMultiClient(t *testing.T, config MultiEthClientConfig, clients ...*testEthClient) *MultiEthClient {
+	urls := make([]string, 0, len(clients))
+	ethClients := make([]EthClient, 0, len(clients))
+	for i, client := range clients {
+		urls = append(urls, string(rune('a'+i)))
+		ethClients = append(ethClients, client)
+	}
+	m, err := newMultiEthClient(urls, ethClients, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMultiEthClientFailover(t *testing.T) {
+	ctx := context.Background()
+	primary := &testEthClient{head: 10, errs: []error{errUnreachable}}
+	backup := &testEthClient{head: 10}
+	m := newTestMultiClient(t, MultiEthClientConfig{RecheckInterval: time.Hour}, primary, backup)
+
+	if _, err := m.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if primary.calls != 1 || backup.calls != 1 {
+		t.Fatal("expected request to fail over to backup")
+	}
+
+	// The failed endpoint is skipped until the recheck interval passes
+	if _, err := m.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if primary.calls != 1 || backup.calls != 2 {
+		t.Fatal("expected failed endpoint to be skipped")
+	}
+
+	// Errors returned by the node itself aren't failed over
+	backup.errs = []error{testRPCError{code: -32000}}
+	if _, err := m.HeaderByNumber(ctx, nil); err == nil {
+		t.Fatal("expected rpc error to be returned")
+	}
+	if primary.calls != 1 {
+		t.Fatal("rpc error shouldn't fail over")
+	}
+
+	// Rate limiting is failed over
+	backup.errs = []error{testRPCError{code: limitExceededCode}}
+	if _, err := m.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if primary.calls != 2 {
+		t.Fatal("expected rate limited request to fail over")
+	}
+}
+
+func TestMultiEthClientRetries(t *testing.T) {
+	ctx := context.Background()
+	client := &testEthClient{head: 10, errs: []error{errUnreachable, errUnreachable}}
+	m := newTestMultiClient(t, MultiEthClientConfig{Retries: 1}, client)
+	if _, err := m.HeaderByNumber(ctx, nil); err == nil {
+		t.Fatal("expected request to fail after retries")
+	}
+
+	client.errs = []error{errUnreachable, errUnreachable}
+	m = newTestMultiClient(t, MultiEthClientConfig{Retries: 2}, client)
+	if _, err := m.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultiEthClientQuorum(t *testing.T) {
+	ctx := context.Background()
+	log := func(block uint64, tx byte) types.Log {
+		return types.Log{BlockNumber: block, BlockHash: common.Hash{byte(block)}, TxHash: common.Hash{tx}}
+	}
+	logs := []types.Log{log(5, 1), log(8, 2), log(12, 3)}
+
+	a := &testEthClient{head: 12, logs: logs}
+	b := &testEthClient{head: 10, logs: logs}
+	c := &testEthClient{head: 12, logs: []types.Log{log(5, 1), log(8, 4)}}
+	m := newTestMultiClient(t, MultiEthClientConfig{Quorum: 2}, a, b, c)
+
+	// Reads of the latest block are pinned to the block both endpoints have
+	header, err := m.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Number.Int64() != 10 {
+		t.Fatal("expected latest header to be pinned to block 10, got", header.Number)
+	}
+
+	filtered, err := m.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 2 || filtered[1].TxHash != logs[1].TxHash {
+		t.Fatal("unexpected logs", filtered)
+	}
+
+	// A dissenting endpoint is outvoted
+	filtered, err = m.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 2 || filtered[1].TxHash != logs[1].TxHash {
+		t.Fatal("unexpected logs", filtered)
+	}
+
+	// Without a majority no result is returned
+	m = newTestMultiClient(t, MultiEthClientConfig{Quorum: 2}, a, c)
+	if _, err := m.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(9)}); err == nil {
+		t.Fatal("expected disagreeing endpoints to fail")
+	}
+
+	a.blockTag = 1
+	if _, err := m.HeaderByNumber(ctx, big.NewInt(5)); err == nil {
+		t.Fatal("expected differing block hashes to fail")
+	}
+
+	if _, err := newMultiEthClient([]string{"a"}, []EthClient{a}, MultiEthClientConfig{Quorum: 2}); err == nil {
+		t.Fatal("expected quorum larger than the endpoints to be rejected")
+	}
+}